@@ -0,0 +1,94 @@
+import { useState } from 'react';
+import { Key, X } from 'lucide-react';
+import { useAppStore } from '../../stores/appStore';
+import { authenticateAgent } from '../../lib/api';
+import { openSessionView } from '../../lib/sessionLoader';
+
+export function AuthDialog() {
+  const { authRequests, removeAuthRequest, setError } = useAppStore();
+  const [busyMethod, setBusyMethod] = useState<string | null>(null);
+
+  const request = authRequests[0];
+  if (!request) return null;
+
+  const handleMethod = async (methodId: string) => {
+    setBusyMethod(methodId);
+    try {
+      // The backend retries the session operation that needed the login
+      // and returns its session, if any.
+      const sessionId = await authenticateAgent(request.connectionId, methodId);
+      removeAuthRequest(request.connectionId);
+      if (sessionId) {
+        await openSessionView(
+          useAppStore.getState(),
+          { connectionID: request.connectionId, sessionID: sessionId },
+          { ensureConnected: false, trackHistory: true }
+        );
+      }
+    } catch (err) {
+      const message = err instanceof Error ? err.message : String(err);
+      setError(`Falha ao autenticar agente: ${message}`);
+    } finally {
+      setBusyMethod(null);
+    }
+  };
+
+  return (
+    <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/70 backdrop-blur-sm">
+      <div className="bg-[var(--bg-elevated)] border border-[var(--border)] rounded-xl shadow-elevated max-w-md w-full mx-4 overflow-hidden animate-slide-up">
+        {/* Header */}
+        <div className="flex items-center gap-3 px-4 py-3 border-b border-[var(--border-subtle)]">
+          <div className="w-8 h-8 rounded-lg bg-[var(--warning-muted)] flex items-center justify-center">
+            <Key className="w-4 h-4 text-[var(--warning)]" />
+          </div>
+          <div className="flex-1">
+            <h3 className="text-sm font-semibold text-[var(--text-primary)]">Login Required</h3>
+            <p className="text-[10px] text-[var(--text-muted)]">
+              {request.agentName} needs you to authenticate
+            </p>
+          </div>
+          <button
+            onClick={() => removeAuthRequest(request.connectionId)}
+            disabled={busyMethod !== null}
+            className="p-1 rounded-md text-[var(--text-muted)] hover:text-[var(--text-primary)] hover:bg-[var(--bg-tertiary)] transition-colors"
+          >
+            <X className="w-4 h-4" />
+          </button>
+        </div>
+
+        {/* Body */}
+        {request.error && (
+          <div className="px-4 py-3">
+            <p className="text-[10px] text-[var(--text-muted)] font-mono break-words">
+              {request.error}
+            </p>
+          </div>
+        )}
+
+        {/* Actions */}
+        <div className="px-4 py-3 border-t border-[var(--border-subtle)] flex flex-col gap-2">
+          {request.methods.length === 0 && (
+            <p className="text-xs text-[var(--text-secondary)]">
+              The agent did not offer a login method. Log in with its own CLI and reconnect.
+            </p>
+          )}
+          {request.methods.map((method) => (
+            <button
+              key={method.id}
+              onClick={() => handleMethod(method.id)}
+              disabled={busyMethod !== null}
+              className="w-full text-left px-3 py-2 rounded-md bg-[var(--bg-tertiary)] hover:bg-[var(--border)] border border-[var(--border-subtle)] transition-all duration-200 disabled:opacity-50"
+            >
+              <p className="text-xs font-medium text-[var(--text-primary)]">
+                {busyMethod === method.id ? `${method.name}…` : method.name}
+              </p>
+              {method.description && (
+                <p className="text-[10px] text-[var(--text-muted)] mt-0.5">{method.description}</p>
+              )}
+            </button>
+          ))}
+        </div>
+      </div>
+    </div>
+  );
+}
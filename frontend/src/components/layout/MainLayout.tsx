@@ -1,6 +1,7 @@
 import { Sidebar } from './Sidebar';
 import { StatusBar } from './StatusBar';
 import { ModelPickerModal } from '../common/ModelPickerModal';
+import { AuthDialog } from '../common/AuthDialog';
 import { TerminalPanel } from '../terminal/TerminalPanel';
 import { useAppStore } from '../../stores/appStore';
 
@@ -30,6 +31,7 @@ export function MainLayout({ children }: MainLayoutProps) {
 
       {/* Global modals */}
       <ModelPickerModal />
+      <AuthDialog />
     </div>
   );
 }
@@ -76,7 +76,6 @@ export function Sidebar() {
 
   const refreshHistory = useCallback(async () => {
     const sessions = await listSessions();
-    sessions.sort((a, b) => b.updatedAt.localeCompare(a.updatedAt));
     setHistorySessions(sessions);
   }, []);
 
@@ -387,7 +386,7 @@ export function Sidebar() {
                   >
                     <MessageSquare className="w-2.5 h-2.5 shrink-0" />
                     <span className="truncate">
-                      {session.cwd.split('/').pop() || session.id.slice(0, 8)}
+                      {session.title || session.cwd.split('/').pop() || session.id.slice(0, 8)}
                     </span>
                     <span className="ml-auto text-[9px] opacity-40 font-mono">
                       {session.messageCount}
@@ -433,7 +432,7 @@ export function Sidebar() {
             title={`${session.agentName} • ${session.cwd}`}
           >
             <History className="w-2.5 h-2.5 shrink-0" />
-            <span className="truncate">{session.cwd.split('/').pop() || session.id.slice(0, 8)}</span>
+            <span className="truncate">{session.title || session.cwd.split('/').pop() || session.id.slice(0, 8)}</span>
             <span className="ml-auto text-[9px] opacity-40 font-mono">{session.agentName}</span>
           </button>
         ))}
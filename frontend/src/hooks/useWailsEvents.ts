@@ -7,9 +7,12 @@ import type {
   AgentPlanEvent,
   AgentCommandsEvent,
   PromptDoneEvent,
+  PromptQueueEvent,
+  SessionHistoryEvent,
   AgentErrorEvent,
   AgentModelsEvent,
   AgentModesEvent,
+  AuthRequiredEvent,
   MessageKind,
   PermissionRequest,
   QuestionRequest,
@@ -28,10 +31,12 @@ function mapMessageKind(type: string): MessageKind {
 export function useWailsEvents() {
   const {
     activeSession,
+    addMessage,
     appendAgentMessageChunk,
     finalizeAgentMessage,
     addToolCall,
     updateToolCall,
+    addHistory,
     setPlan,
     setCommands,
     setSessionModels,
@@ -39,6 +44,7 @@ export function useWailsEvents() {
     setSessionAccessModes,
     addPermissionRequest,
     addQuestionRequest,
+    addAuthRequest,
     appendTerminalOutput,
     markTerminalExited,
     setSessionLoading,
@@ -92,6 +98,17 @@ export function useWailsEvents() {
       }
     });
 
+    // History fetched from the agent when a session is loaded or resumed
+    EventsOn('session:history', (data: SessionHistoryEvent) => {
+      if (
+        activeSession &&
+        data.connectionId === activeSession.connectionID &&
+        data.sessionId === activeSession.sessionID
+      ) {
+        addHistory(data.messages || [], data.toolCalls || []);
+      }
+    });
+
     // Plan updates
     EventsOn('agent:plan', (data: AgentPlanEvent) => {
       if (
@@ -157,11 +174,35 @@ export function useWailsEvents() {
       addQuestionRequest(data);
     });
 
+    // The agent asked the user to log in
+    EventsOn('agent:auth-required', (data: AuthRequiredEvent) => {
+      addAuthRequest(data);
+    });
+
     // Prompt done
     EventsOn('agent:prompt-done', (data: PromptDoneEvent) => {
       setSessionLoading(data.connectionId, data.sessionId, false);
     });
 
+    // A queued prompt started once the turn before it ended
+    EventsOn('agent:prompt-queue', (data: PromptQueueEvent) => {
+      const started = data.started;
+      if (!started) return;
+      if (
+        activeSession &&
+        started.connectionId === activeSession.connectionID &&
+        data.sessionId === activeSession.sessionID
+      ) {
+        addMessage({
+          id: started.id,
+          role: 'user',
+          content: started.text,
+          timestamp: new Date().toISOString(),
+        });
+      }
+      setSessionLoading(started.connectionId, data.sessionId, true);
+    });
+
     // Error
     EventsOn('agent:error', (data: AgentErrorEvent) => {
       if (
@@ -187,6 +228,7 @@ export function useWailsEvents() {
     return () => {
       EventsOff('agent:message');
       EventsOff('agent:toolcall');
+      EventsOff('session:history');
       EventsOff('agent:plan');
       EventsOff('agent:commands');
       EventsOff('agent:models');
@@ -194,17 +236,21 @@ export function useWailsEvents() {
       EventsOff('agent:access-modes');
       EventsOff('agent:permission');
       EventsOff('agent:question');
+      EventsOff('agent:auth-required');
       EventsOff('agent:prompt-done');
+      EventsOff('agent:prompt-queue');
       EventsOff('agent:error');
       EventsOff('ui:terminal-output');
       EventsOff('ui:terminal-exit');
     };
   }, [
     activeSession,
+    addMessage,
     appendAgentMessageChunk,
     finalizeAgentMessage,
     addToolCall,
     updateToolCall,
+    addHistory,
     setPlan,
     setCommands,
     setSessionModels,
@@ -212,6 +258,7 @@ export function useWailsEvents() {
     setSessionAccessModes,
     addPermissionRequest,
     addQuestionRequest,
+    addAuthRequest,
     appendTerminalOutput,
     markTerminalExited,
     setSessionLoading,
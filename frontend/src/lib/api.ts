@@ -2,14 +2,28 @@ import type {
   AgentInfo,
   ConnectionInfo,
   SessionListItem,
+  SessionListFilters,
+  ExportFormat,
+  HistoryImportResult,
+  SessionListOptions,
+  StoredSessionPage,
+  SessionHistoryPage,
   SessionModelsInfo,
   SessionModesInfo,
+  SessionState,
+  FileChangeInfo,
+  RevertResult,
+  StagedFileInfo,
+  ToolCallDiffInfo,
   ResumeHistoricalResult,
+  SearchFilters,
+  SearchHit,
   MessageInfo,
   ToolCallInfo,
   AvailableCommand,
   EmbeddedTerminalSession,
   TimelineItem,
+  QueuedPrompt,
 } from "../types";
 
 // API layer that wraps Wails Go backend calls.
@@ -67,6 +81,13 @@ export async function disconnectAgent(connectionID: string): Promise<void> {
   await callWails<void>("DisconnectAgent", connectionID);
 }
 
+export async function authenticateAgent(
+  connectionID: string,
+  methodID: string,
+): Promise<string> {
+  return await callWails<string>("AuthenticateAgent", connectionID, methodID);
+}
+
 // --- Connection Management ---
 
 export async function listConnections(): Promise<ConnectionInfo[]> {
@@ -88,18 +109,76 @@ export async function createSession(
 
 export async function listSessions(
   connectionID?: string,
+  filters: SessionListFilters = {},
 ): Promise<SessionListItem[]> {
   try {
-    const sessions = await callWails<SessionListItem[]>("ListSessions");
     if (!connectionID) {
-      return sessions;
+      return await callWails<SessionListItem[]>("ListSessions", filters);
     }
-    return sessions.filter((s) => s.connectionId === connectionID);
+    const page = await listSessionsPage({ ...filters, connectionId: connectionID });
+    return page.sessions;
   } catch {
     return [];
   }
 }
 
+export async function listSessionsPage(
+  options: SessionListOptions = {},
+): Promise<StoredSessionPage> {
+  return await callWails<StoredSessionPage>("ListSessionsPage", options);
+}
+
+export async function renameSession(
+  sessionID: string,
+  title: string,
+): Promise<SessionListItem> {
+  return await callWails<SessionListItem>("RenameSession", sessionID, title);
+}
+
+export async function setSessionTags(
+  sessionID: string,
+  tags: string[],
+): Promise<SessionListItem> {
+  return await callWails<SessionListItem>("SetSessionTags", sessionID, tags);
+}
+
+export async function setSessionPinned(
+  sessionID: string,
+  pinned: boolean,
+): Promise<SessionListItem> {
+  return await callWails<SessionListItem>("SetSessionPinned", sessionID, pinned);
+}
+
+export async function setSessionArchived(
+  sessionID: string,
+  archived: boolean,
+): Promise<SessionListItem> {
+  return await callWails<SessionListItem>("SetSessionArchived", sessionID, archived);
+}
+
+export async function exportSession(
+  sessionID: string,
+  format: ExportFormat,
+  path = "",
+): Promise<string> {
+  return await callWails<string>("ExportSession", sessionID, format, path);
+}
+
+export async function importSession(path = ""): Promise<SessionListItem> {
+  return await callWails<SessionListItem>("ImportSession", path);
+}
+
+export async function importCodexHistory(dir = ""): Promise<HistoryImportResult> {
+  return await callWails<HistoryImportResult>("ImportCodexHistory", dir);
+}
+
+export async function importOpenCodeHistory(
+  connectionID: string,
+  cwd = "",
+): Promise<HistoryImportResult> {
+  return await callWails<HistoryImportResult>("ImportOpenCodeHistory", connectionID, cwd);
+}
+
 export async function listRemoteSessions(
   connectionID: string,
   cwd: string,
@@ -145,6 +224,19 @@ export async function getSessionHistory(sessionID: string): Promise<{
   }
 }
 
+export async function getSessionHistoryPage(
+  sessionID: string,
+  offset = 0,
+  limit = 0,
+): Promise<SessionHistoryPage> {
+  return await callWails<SessionHistoryPage>(
+    "GetSessionHistoryPage",
+    sessionID,
+    offset,
+    limit,
+  );
+}
+
 export async function getSessionModels(
   sessionID: string,
 ): Promise<SessionModelsInfo | null> {
@@ -184,6 +276,16 @@ export async function getSessionAccessModes(
   }
 }
 
+export async function getSessionState(
+  sessionID: string,
+): Promise<SessionState | null> {
+  try {
+    return await callWails<SessionState>("GetSessionState", sessionID);
+  } catch {
+    return null;
+  }
+}
+
 export async function setSessionModel(
   connectionID: string,
   sessionID: string,
@@ -236,8 +338,36 @@ export async function sendPrompt(
 export async function cancelPrompt(
   connectionID: string,
   sessionID: string,
+  flushQueue = false,
+): Promise<void> {
+  await callWails<void>("CancelPrompt", connectionID, sessionID, flushQueue);
+}
+
+export async function getPromptQueue(sessionID: string): Promise<QueuedPrompt[]> {
+  return (await callWails<QueuedPrompt[]>("GetPromptQueue", sessionID)) ?? [];
+}
+
+export async function moveQueuedPrompt(
+  sessionID: string,
+  promptID: string,
+  index: number,
 ): Promise<void> {
-  await callWails<void>("CancelPrompt", connectionID, sessionID);
+  await callWails<void>("MoveQueuedPrompt", sessionID, promptID, index);
+}
+
+export async function editQueuedPrompt(
+  sessionID: string,
+  promptID: string,
+  text: string,
+): Promise<void> {
+  await callWails<void>("EditQueuedPrompt", sessionID, promptID, text);
+}
+
+export async function removeQueuedPrompt(
+  sessionID: string,
+  promptID: string,
+): Promise<void> {
+  await callWails<void>("RemoveQueuedPrompt", sessionID, promptID);
 }
 
 // --- Permissions ---
@@ -274,6 +404,10 @@ export async function getSettings(): Promise<{
   defaultAgent: string;
   defaultCwd: string;
   autoApprove: boolean;
+  requestTimeoutSeconds?: number;
+  timeoutDecision?: "deny" | "allow" | "cancel" | "";
+  disableBuiltinMcp?: boolean;
+  controlApi?: string;
 }> {
   return await callWails("GetSettings");
 }
@@ -283,10 +417,95 @@ export async function saveSettings(settings: {
   defaultAgent: string;
   defaultCwd: string;
   autoApprove: boolean;
+  requestTimeoutSeconds?: number;
+  timeoutDecision?: "deny" | "allow" | "cancel" | "";
+  disableBuiltinMcp?: boolean;
+  controlApi?: string;
 }): Promise<void> {
   await callWails<void>("SaveSettings", settings);
 }
 
+// --- Search ---
+
+export async function searchSessions(
+  query: string,
+  filters: SearchFilters = {},
+): Promise<SearchHit[]> {
+  return (await callWails<SearchHit[]>("SearchSessions", query, filters)) ?? [];
+}
+
+// --- File Changes ---
+
+export async function listFileChanges(
+  sessionID: string,
+): Promise<FileChangeInfo[]> {
+  return (await callWails<FileChangeInfo[]>("ListFileChanges", sessionID)) ?? [];
+}
+
+export async function revertFileChange(changeID: string): Promise<RevertResult> {
+  return await callWails<RevertResult>("RevertFileChange", changeID);
+}
+
+export async function revertSession(sessionID: string): Promise<RevertResult[]> {
+  return (await callWails<RevertResult[]>("RevertSession", sessionID)) ?? [];
+}
+
+// --- Review Mode ---
+
+export async function setReviewMode(
+  sessionID: string,
+  enabled: boolean,
+): Promise<void> {
+  await callWails<void>("SetReviewMode", sessionID, enabled);
+}
+
+export async function getReviewMode(sessionID: string): Promise<boolean> {
+  return await callWails<boolean>("GetReviewMode", sessionID);
+}
+
+export async function listStagedChanges(
+  sessionID: string,
+): Promise<StagedFileInfo[]> {
+  return (await callWails<StagedFileInfo[]>("ListStagedChanges", sessionID)) ?? [];
+}
+
+export async function acceptStagedFile(
+  sessionID: string,
+  path: string,
+): Promise<void> {
+  await callWails<void>("AcceptStagedFile", sessionID, path);
+}
+
+export async function rejectStagedFile(
+  sessionID: string,
+  path: string,
+): Promise<void> {
+  await callWails<void>("RejectStagedFile", sessionID, path);
+}
+
+export async function acceptStagedHunk(
+  sessionID: string,
+  path: string,
+  hunkIndex: number,
+): Promise<void> {
+  await callWails<void>("AcceptStagedHunk", sessionID, path, hunkIndex);
+}
+
+export async function rejectStagedHunk(
+  sessionID: string,
+  path: string,
+  hunkIndex: number,
+): Promise<void> {
+  await callWails<void>("RejectStagedHunk", sessionID, path, hunkIndex);
+}
+
+export async function getToolCallDiff(
+  sessionID: string,
+  toolCallID: string,
+): Promise<ToolCallDiffInfo> {
+  return await callWails<ToolCallDiffInfo>("GetToolCallDiff", sessionID, toolCallID);
+}
+
 // --- File System ---
 
 export async function listFiles(
@@ -1,6 +1,7 @@
 import { create } from 'zustand';
 import type {
   AgentInfo,
+  AuthRequiredEvent,
   ConnectionInfo,
   MessageInfo,
   MessageKind,
@@ -139,6 +140,7 @@ interface AppState {
   toolCalls: ToolCallInfo[];
   setToolCalls: (toolCalls: ToolCallInfo[]) => void;
   addToolCall: (toolCall: ToolCallInfo) => void;
+  addHistory: (messages: MessageInfo[], toolCalls: ToolCallInfo[]) => void;
   updateToolCall: (id: string, updates: Partial<ToolCallInfo>) => void;
 
   // Timeline (computed from messages + tool calls)
@@ -177,6 +179,11 @@ interface AppState {
   addQuestionRequest: (req: QuestionRequest) => void;
   removeQuestionRequest: (requestId: string) => void;
 
+  // Agents waiting for the user to log in
+  authRequests: AuthRequiredEvent[];
+  addAuthRequest: (req: AuthRequiredEvent) => void;
+  removeAuthRequest: (connectionId: string) => void;
+
   // Model picker modal
   modelPickerOpen: boolean;
   setModelPickerOpen: (open: boolean) => void;
@@ -433,6 +440,15 @@ export const useAppStore = create<AppState>((set, get) => ({
   setToolCalls: (toolCalls) => set({ toolCalls }),
   addToolCall: (toolCall) =>
     set((s) => ({ toolCalls: [...s.toolCalls, toolCall] })),
+  addHistory: (messages, toolCalls) =>
+    set((s) => {
+      const messageIds = new Set(s.messages.map((m) => m.id));
+      const toolCallIds = new Set(s.toolCalls.map((tc) => tc.id));
+      return {
+        messages: [...s.messages, ...messages.filter((m) => !messageIds.has(m.id))],
+        toolCalls: [...s.toolCalls, ...toolCalls.filter((tc) => !toolCallIds.has(tc.id))],
+      };
+    }),
   updateToolCall: (id, updates) =>
     set((s) => ({
       toolCalls: s.toolCalls.map((tc) =>
@@ -494,6 +510,20 @@ export const useAppStore = create<AppState>((set, get) => ({
       questionRequests: s.questionRequests.filter((r) => r.requestId !== requestId),
     })),
 
+  // Auth requests
+  authRequests: [],
+  addAuthRequest: (req) =>
+    set((s) => ({
+      authRequests: [
+        ...s.authRequests.filter((r) => r.connectionId !== req.connectionId),
+        req,
+      ],
+    })),
+  removeAuthRequest: (connectionId) =>
+    set((s) => ({
+      authRequests: s.authRequests.filter((r) => r.connectionId !== connectionId),
+    })),
+
   // Sidebar
   sidebarCollapsed: false,
   toggleSidebar: () => set((s) => ({ sidebarCollapsed: !s.sidebarCollapsed })),
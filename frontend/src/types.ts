@@ -12,6 +12,35 @@ export interface ConnectionInfo {
   displayName: string;
   sessions: string[];
   integrator: string;
+  capabilities?: ConnectionCapabilities;
+  authMethods?: AuthMethodInfo[];
+}
+
+export interface AuthMethodInfo {
+  id: string;
+  name: string;
+  description?: string;
+}
+
+export interface AuthRequiredEvent {
+  connectionId: string;
+  agentName: string;
+  methods: AuthMethodInfo[];
+  error: string;
+}
+
+export interface ConnectionCapabilities {
+  listSessions: boolean;
+  loadSession: boolean;
+  resumeSession: boolean;
+  setMode: boolean;
+  setModel: boolean;
+  setConfigOption: boolean;
+  imageInput: boolean;
+  audioInput: boolean;
+  embeddedContext: boolean;
+  mcpHttp: boolean;
+  mcpSse: boolean;
 }
 
 export interface SessionListItem {
@@ -19,11 +48,53 @@ export interface SessionListItem {
   agentName: string;
   connectionId: string;
   cwd: string;
+  title?: string;
+  tags?: string[];
+  pinned?: boolean;
+  archived?: boolean;
   messageCount: number;
+  toolCallCount?: number;
   createdAt: string;
   updatedAt: string;
 }
 
+export type ExportFormat = "markdown" | "json" | "html";
+
+export interface HistoryImportResult {
+  sessions: number;
+  created: number;
+  messages: number;
+  toolCalls: number;
+  skipped?: string[];
+}
+
+export interface SessionListFilters {
+  tags?: string[];
+  archived?: "" | "only" | "include";
+}
+
+export interface SessionListOptions extends SessionListFilters {
+  sortBy?: "updated" | "created";
+  connectionId?: string;
+  offset?: number;
+  limit?: number;
+}
+
+export interface StoredSessionPage {
+  sessions: SessionListItem[];
+  total: number;
+  hasMore: boolean;
+}
+
+export interface SessionHistoryPage {
+  session: SessionListItem;
+  messages: MessageInfo[];
+  toolCalls: ToolCallInfo[];
+  offset: number;
+  totalMessages: number;
+  hasMore: boolean;
+}
+
 export interface SessionModelInfo {
   modelId: string;
   name: string;
@@ -46,6 +117,85 @@ export interface SessionModesInfo {
 
 export type MessageKind = 'text' | 'thought';
 
+export interface FileChangeInfo {
+  id: string;
+  sessionId: string;
+  toolCallId: string;
+  agentName: string;
+  path: string;
+  oldContent: string;
+  newContent: string;
+  created: boolean;
+  status: "applied" | "reverted";
+  timestamp: string;
+  revertedAt?: string;
+}
+
+export interface RevertResult {
+  changeId: string;
+  path: string;
+  reverted: boolean;
+  conflict: boolean;
+  reason?: string;
+}
+
+export interface DiffLineInfo {
+  op: " " | "+" | "-";
+  text: string;
+  noEol?: boolean;
+}
+
+export interface DiffHunkInfo {
+  oldStart: number;
+  oldLines: number;
+  newStart: number;
+  newLines: number;
+  lines: DiffLineInfo[];
+}
+
+export interface FileDiffInfo {
+  path: string;
+  created: boolean;
+  additions: number;
+  deletions: number;
+  hunks: DiffHunkInfo[];
+}
+
+export interface ToolCallDiffInfo {
+  sessionId: string;
+  toolCallId: string;
+  files: FileDiffInfo[];
+}
+
+export interface StagedFileInfo {
+  path: string;
+  created: boolean;
+  hunks: DiffHunkInfo[];
+  updatedAt: string;
+}
+
+export interface StreamingMessageInfo {
+  messageId: string;
+  type: string;
+  content: string;
+  startedAt: string;
+}
+
+export interface SessionState {
+  sessionId: string;
+  connectionId: string;
+  models: SessionModelsInfo | null;
+  modes: SessionModesInfo | null;
+  accessModes: SessionModesInfo | null;
+  pendingPermissions: PermissionRequest[];
+  pendingQuestions: QuestionRequest[];
+  streamingMessage: StreamingMessageInfo | null;
+  promptActive: boolean;
+  promptQueue: QueuedPrompt[];
+  reviewMode: boolean;
+  stagedFiles: number;
+}
+
 export interface MessageInfo {
   id: string;
   role: 'user' | 'agent' | 'system';
@@ -101,6 +251,19 @@ export interface PermissionRequest {
   title: string;
   kind: string;
   options: PermissionOption[];
+  policyRuleId?: string;
+}
+
+export interface PermissionDecision {
+  connectionId: string;
+  sessionId: string;
+  toolCallId: string;
+  title: string;
+  kind: string;
+  decision: "allow" | "deny" | "ask";
+  optionId: string;
+  ruleId: string;
+  reason: string;
 }
 
 export interface PermissionOption {
@@ -109,6 +272,28 @@ export interface PermissionOption {
   kind: string;
 }
 
+export interface OpenFileRequest {
+  sessionId: string;
+  path: string;
+  line?: number;
+}
+
+export interface AgentNotification {
+  sessionId: string;
+  title?: string;
+  message: string;
+  level: "info" | "success" | "warning" | "error";
+}
+
+export interface PendingResolved {
+  requestId: string;
+  connectionId: string;
+  sessionId: string;
+  toolCallId: string;
+  outcome: "answered" | "cancelled" | "timeout";
+  optionId?: string;
+}
+
 export interface QuestionRequest {
   requestId: string;
   connectionId: string;
@@ -187,12 +372,32 @@ export interface AgentCommandsEvent {
   commands: AvailableCommand[];
 }
 
+export interface SessionHistoryEvent {
+  connectionId: string;
+  sessionId: string;
+  messages: MessageInfo[];
+  toolCalls: ToolCallInfo[];
+}
+
 export interface PromptDoneEvent {
   connectionId: string;
   sessionId: string;
   stopReason: string;
 }
 
+export interface QueuedPrompt {
+  id: string;
+  connectionId: string;
+  text: string;
+  queuedAt: string;
+}
+
+export interface PromptQueueEvent {
+  sessionId: string;
+  prompts: QueuedPrompt[];
+  started?: QueuedPrompt;
+}
+
 export interface AgentErrorEvent {
   connectionId: string;
   sessionId: string;
@@ -227,3 +432,24 @@ export interface UITerminalExitEvent {
 export type TimelineItem =
   | { type: 'message'; data: MessageInfo }
   | { type: 'toolcall'; data: ToolCallInfo };
+
+export interface SearchFilters {
+  agentName?: string;
+  cwd?: string;
+  from?: string;
+  to?: string;
+  limit?: number;
+}
+
+export interface SearchHit {
+  kind: "message" | "tool_call";
+  sessionId: string;
+  agentName: string;
+  cwd: string;
+  messageId?: string;
+  toolCallId?: string;
+  role: string;
+  snippet: string;
+  timestamp: string;
+  score: number;
+}
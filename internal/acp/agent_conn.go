@@ -0,0 +1,240 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// RequestHandler serves one client-to-agent request. The returned value is
+// sent as the result; a *JSONRPCError is sent as is and any other error as
+// an internal error. ctx is cancelled when the connection closes.
+type RequestHandler func(ctx context.Context, params json.RawMessage) (any, error)
+
+// NotificationHandler serves one client-to-agent notification.
+type NotificationHandler func(params json.RawMessage)
+
+// AgentConn is the agent end of an ACP connection. It is the counterpart of
+// Client: it serves the requests a client sends (initialize, session/new,
+// session/prompt, ...) with registered handlers, and lets the agent send
+// session/update notifications and its own requests (permissions, fs,
+// terminals) back to the client.
+//
+// Incoming messages go through the same per-session dispatcher as Client,
+// so the notifications of one session are handled in order and a long
+// request (a prompt turn) does not hold up others.
+type AgentConn struct {
+	transport Transport
+
+	nextID    atomic.Int64
+	pending   map[int64]chan JSONRPCMessage
+	pendingMu sync.Mutex
+
+	requests      map[string]RequestHandler
+	notifications map[string]NotificationHandler
+	handlerMu     sync.RWMutex
+
+	dispatcher *dispatcher
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewAgentConn creates the agent end of a connection over transport. Register
+// handlers, then call Start.
+func NewAgentConn(transport Transport) *AgentConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &AgentConn{
+		transport:     transport,
+		pending:       make(map[int64]chan JSONRPCMessage),
+		requests:      make(map[string]RequestHandler),
+		notifications: make(map[string]NotificationHandler),
+		dispatcher:    newDispatcher(),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	transport.SetHandler(c.dispatch)
+	return c
+}
+
+// HandleRequest registers the handler for a client-to-agent method.
+// Requests without a handler are answered with "method not found".
+func (c *AgentConn) HandleRequest(method string, h RequestHandler) {
+	c.handlerMu.Lock()
+	c.requests[method] = h
+	c.handlerMu.Unlock()
+}
+
+// HandleNotification registers the handler for a client-to-agent
+// notification. Notifications without a handler are dropped.
+func (c *AgentConn) HandleNotification(method string, h NotificationHandler) {
+	c.handlerMu.Lock()
+	c.notifications[method] = h
+	c.handlerMu.Unlock()
+}
+
+// Start starts the transport and begins serving.
+func (c *AgentConn) Start() error {
+	return c.transport.Start()
+}
+
+// Done is closed when the client has gone away.
+func (c *AgentConn) Done() <-chan struct{} {
+	return c.transport.Done()
+}
+
+// Notify sends a notification to the client.
+func (c *AgentConn) Notify(method string, params any) error {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshal params: %w", err)
+	}
+	return c.transport.Send(JSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  paramsJSON,
+	})
+}
+
+// Call sends a request to the client and waits for its result. Requests to
+// the client usually wait on the user, so only ctx bounds the wait.
+func (c *AgentConn) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal params: %w", err)
+	}
+
+	id := c.nextID.Add(1)
+	idJSON := json.RawMessage(fmt.Sprintf("%d", id))
+	ch := make(chan JSONRPCMessage, 1)
+	c.pendingMu.Lock()
+	c.pending[id] = ch
+	c.pendingMu.Unlock()
+
+	err = c.transport.Send(JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      &idJSON,
+		Method:  method,
+		Params:  paramsJSON,
+	})
+	if err != nil {
+		c.forgetPending(id)
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("request %s (id=%d) cancelled (connection closing)", method, id)
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		c.forgetPending(id)
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		c.forgetPending(id)
+		return nil, fmt.Errorf("request %s (id=%d) cancelled (connection closing)", method, id)
+	}
+}
+
+// Close fails pending calls, cancels running handlers and closes the
+// transport.
+func (c *AgentConn) Close() error {
+	c.cancel()
+
+	c.pendingMu.Lock()
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.pendingMu.Unlock()
+
+	err := c.transport.Close()
+	c.dispatcher.close()
+	return err
+}
+
+func (c *AgentConn) forgetPending(id int64) {
+	c.pendingMu.Lock()
+	delete(c.pending, id)
+	c.pendingMu.Unlock()
+}
+
+// dispatch runs on the transport read loop; see Client.dispatch.
+func (c *AgentConn) dispatch(msg JSONRPCMessage) {
+	switch {
+	case msg.IsResponse():
+		c.handleResponse(msg)
+	case msg.IsNotification():
+		c.dispatcher.enqueue(dispatchKey(msg.Params), func() { c.handleNotification(msg) })
+	case msg.IsRequest():
+		c.dispatcher.enqueue(dispatchKey(msg.Params), func() { go c.handleRequest(msg) })
+	default:
+		log.Printf("acp: received unrecognized message: %+v", msg)
+	}
+}
+
+func (c *AgentConn) handleResponse(msg JSONRPCMessage) {
+	id := msg.IDAsInt64()
+	c.pendingMu.Lock()
+	ch, ok := c.pending[id]
+	delete(c.pending, id)
+	c.pendingMu.Unlock()
+
+	if !ok {
+		log.Printf("acp: received response for unknown request id=%d", id)
+		return
+	}
+	ch <- msg
+}
+
+func (c *AgentConn) handleNotification(msg JSONRPCMessage) {
+	c.handlerMu.RLock()
+	h := c.notifications[msg.Method]
+	c.handlerMu.RUnlock()
+	if h != nil {
+		h(msg.Params)
+	}
+}
+
+func (c *AgentConn) handleRequest(msg JSONRPCMessage) {
+	c.handlerMu.RLock()
+	h := c.requests[msg.Method]
+	c.handlerMu.RUnlock()
+	if h == nil {
+		c.sendError(msg.ID, &JSONRPCError{Code: ErrCodeMethodNotFound, Message: "method not found: " + msg.Method})
+		return
+	}
+
+	result, err := h(c.ctx, msg.Params)
+	if err != nil {
+		var rpcErr *JSONRPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &JSONRPCError{Code: ErrCodeInternal, Message: err.Error()}
+		}
+		c.sendError(msg.ID, rpcErr)
+		return
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		c.sendError(msg.ID, &JSONRPCError{Code: ErrCodeInternal, Message: "failed to marshal result"})
+		return
+	}
+	if err := c.transport.Send(JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID, Result: resultJSON}); err != nil {
+		log.Printf("acp: failed to send response: %v", err)
+	}
+}
+
+func (c *AgentConn) sendError(id *json.RawMessage, rpcErr *JSONRPCError) {
+	if err := c.transport.Send(JSONRPCMessage{JSONRPC: "2.0", ID: id, Error: rpcErr}); err != nil {
+		log.Printf("acp: failed to send error response: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAgentConnServesClient(t *testing.T) {
+	clientEnd, agentEnd := NewPipeTransports()
+
+	agent := NewAgentConn(agentEnd)
+	defer agent.Close()
+	agent.HandleRequest(MethodInitialize, func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p InitializeParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.ClientCapabilities.FS == nil || !p.ClientCapabilities.FS.ReadTextFile {
+			t.Errorf("client capabilities = %+v", p.ClientCapabilities)
+		}
+		return InitializeResult{ProtocolVersion: 1, AgentInfo: ImplementationInfo{Name: "proxy"}}, nil
+	})
+	agent.HandleRequest(MethodSessionNew, func(ctx context.Context, params json.RawMessage) (any, error) {
+		return SessionNewResult{SessionID: "s1"}, nil
+	})
+	agent.HandleRequest(MethodSessionPrompt, func(ctx context.Context, params json.RawMessage) (any, error) {
+		raw, err := agent.Call(ctx, MethodRequestPermission, RequestPermissionParams{
+			SessionID: "s1",
+			ToolCall:  ToolCallUpdate{ToolCallID: "t1", Title: "Run make"},
+			Options:   []PermissionOption{{OptionID: "allow", Name: "Allow", Kind: "allow_once"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var res RequestPermissionResult
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, err
+		}
+		err = agent.Notify(MethodSessionUpdate, SessionUpdateParams{
+			SessionID: "s1",
+			Update: SessionUpdate{
+				Type:           UpdateAgentMessageChunk,
+				MessageContent: &ContentBlock{Type: "text", Text: "picked " + res.Outcome.OptionID},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return SessionPromptResult{StopReason: "end_turn"}, nil
+	})
+	if err := agent.Start(); err != nil {
+		t.Fatalf("start agent: %v", err)
+	}
+
+	client := NewClient(clientEnd)
+	defer client.Close()
+	client.OnRequestPermission(func(p RequestPermissionParams) RequestPermissionResult {
+		return RequestPermissionResult{Outcome: PermissionOutcome{Outcome: "selected", OptionID: p.Options[0].OptionID}}
+	})
+	var mu sync.Mutex
+	var chunks []string
+	client.OnSessionUpdate(func(p SessionUpdateParams) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Update.MessageContent != nil {
+			chunks = append(chunks, p.Update.MessageContent.Text)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	init, err := client.Initialize(ctx)
+	if err != nil || init.AgentInfo.Name != "proxy" {
+		t.Fatalf("initialize = %+v, %v", init, err)
+	}
+	if _, err := client.NewSession(ctx, "/tmp", nil); err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	res, err := client.Prompt(ctx, "s1", []ContentBlock{{Type: "text", Text: "build"}})
+	if err != nil || res.StopReason != "end_turn" {
+		t.Fatalf("prompt = %+v, %v", res, err)
+	}
+	if _, err := client.ListSessions(ctx, "/tmp", ""); !IsMethodNotFound(err) {
+		t.Fatalf("unhandled method: err = %v, want method not found", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(chunks) != 1 || chunks[0] != "picked allow" {
+		t.Fatalf("chunks = %v, want [picked allow]", chunks)
+	}
+}
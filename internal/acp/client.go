@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,15 +17,18 @@ import (
 const DefaultRequestTimeout = 30 * time.Second
 
 // Client is the main ACP protocol client. It orchestrates communication with
-// an AI coding agent over a StdioTransport by:
+// an AI coding agent over a Transport (stdio, socket, WebSocket or in-memory) by:
 //
 //  1. Managing the transport lifecycle.
 //  2. Dispatching outgoing JSON-RPC requests and tracking responses via ID.
 //  3. Routing incoming notifications to registered handlers.
 //  4. Routing incoming requests (from agent) to registered method handlers and
 //     sending back responses.
+//
+// Incoming messages are handled off the transport read loop (see dispatcher),
+// so a handler waiting on the user never blocks other sessions or responses.
 type Client struct {
-	transport *StdioTransport
+	transport Transport
 
 	nextID atomic.Int64
 
@@ -42,6 +46,28 @@ type Client struct {
 
 	// --- agent-to-client request handlers ---
 
+	handlers  requestHandlers
+	handlerMu sync.RWMutex
+
+	// dispatcher runs incoming notifications, responses and requests off the
+	// transport read loop, ordered per session.
+	dispatcher *dispatcher
+
+	// pendingLane records which dispatcher lane a pending request's response
+	// must be delivered on, so a prompt result never overtakes the session
+	// updates the agent sent before it.
+	pendingLane map[int64]string
+
+	// codexSessions stores compatibility state for sessions created via
+	// `codex app-server` (non-ACP JSON-RPC dialect).
+	codexSessions map[string]*codexSessionState
+	codexMu       sync.RWMutex
+}
+
+// requestHandlers holds the callbacks for agent-to-client requests. It is
+// copied under handlerMu before a request runs so that a handler blocked on
+// the user never holds the lock.
+type requestHandlers struct {
 	onRequestPermission func(RequestPermissionParams) RequestPermissionResult
 	onRequestUserInput  func(ToolRequestUserInputParams) ToolRequestUserInputResponse
 	onFSReadTextFile    func(FSReadTextFileParams) (*FSReadTextFileResult, error)
@@ -51,12 +77,6 @@ type Client struct {
 	onTerminalWait      func(TerminalWaitParams) (*TerminalWaitResult, error)
 	onTerminalKill      func(TerminalKillParams) error
 	onTerminalRelease   func(TerminalReleaseParams) error
-	handlerMu           sync.RWMutex
-
-	// codexSessions stores compatibility state for sessions created via
-	// `codex app-server` (non-ACP JSON-RPC dialect).
-	codexSessions map[string]*codexSessionState
-	codexMu       sync.RWMutex
 }
 
 type codexSessionState struct {
@@ -74,12 +94,14 @@ type codexSessionState struct {
 // NewClient creates an ACP client bound to the given transport. The transport
 // must not be started yet; call Initialize to perform the handshake which
 // also starts the transport if it hasn't been started.
-func NewClient(transport *StdioTransport) *Client {
+func NewClient(transport Transport) *Client {
 	c := &Client{
 		transport:      transport,
 		pending:        make(map[int64]chan json.RawMessage),
+		pendingLane:    make(map[int64]string),
 		RequestTimeout: DefaultRequestTimeout,
 		codexSessions:  make(map[string]*codexSessionState),
+		dispatcher:     newDispatcher(),
 	}
 	transport.SetHandler(c.dispatch)
 	return c
@@ -129,6 +151,30 @@ func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
 	return &result, nil
 }
 
+// Authenticate runs one of the auth methods advertised in InitializeResult.
+// Agents typically complete the login out of band (browser, API key from the
+// environment) and reply once credentials are in place, so only ctx bounds
+// how long this waits.
+func (c *Client) Authenticate(ctx context.Context, methodID string) error {
+	params := AuthenticateParams{MethodID: methodID}
+	_, err := c.callWithTimeout(ctx, MethodAuthenticate, params, 0)
+	return err
+}
+
+// IsAuthRequired reports whether err is the agent asking the client to
+// authenticate before retrying.
+func IsAuthRequired(err error) bool {
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if rpcErr.Code == ErrCodeAuthRequired {
+		return true
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	return strings.Contains(msg, "auth") && strings.Contains(msg, "required")
+}
+
 // NewSession asks the agent to create a new session and returns the full
 // session/new result.
 func (c *Client) NewSession(ctx context.Context, cwd string, mcpServers []MCPServer) (*SessionNewResult, error) {
@@ -144,7 +190,7 @@ func (c *Client) NewSession(ctx context.Context, cwd string, mcpServers []MCPSer
 	raw, err := c.call(ctx, MethodSessionNew, params)
 	if err != nil {
 		if isMethodUnavailable(err, MethodSessionNew) {
-			return c.newSessionCodex(ctx, cwd)
+			return c.newSessionCodex(ctx, cwd, mcpServers)
 		}
 		return nil, fmt.Errorf("session/new: %w", err)
 	}
@@ -156,14 +202,19 @@ func (c *Client) NewSession(ctx context.Context, cwd string, mcpServers []MCPSer
 	return &result, nil
 }
 
-func (c *Client) newSessionCodex(ctx context.Context, cwd string) (*SessionNewResult, error) {
+func (c *Client) newSessionCodex(ctx context.Context, cwd string, mcpServers []MCPServer) (*SessionNewResult, error) {
+	config := map[string]any{
+		"features.default_mode_request_user_input": true,
+	}
+	for _, server := range mcpServers {
+		config["mcp_servers."+server.Name] = codexMCPServerConfig(server)
+	}
+
 	raw, err := c.call(ctx, "thread/start", map[string]any{
-		"cwd":            cwd,
-		"approvalPolicy": "on-request",
-		"sandbox":        "workspace-write",
-		"config": map[string]any{
-			"features.default_mode_request_user_input": true,
-		},
+		"cwd":                    cwd,
+		"approvalPolicy":         "on-request",
+		"sandbox":                "workspace-write",
+		"config":                 config,
 		"experimentalRawEvents":  false,
 		"persistExtendedHistory": true,
 	})
@@ -252,6 +303,35 @@ func (c *Client) newSessionCodex(ctx context.Context, cwd string) (*SessionNewRe
 	return result, nil
 }
 
+// codexMCPServerConfig converts an MCP server into a Codex mcp_servers
+// config entry.
+func codexMCPServerConfig(server MCPServer) map[string]any {
+	if server.Type != "" {
+		entry := map[string]any{"url": server.URL}
+		if len(server.Headers) > 0 {
+			headers := make(map[string]string, len(server.Headers))
+			for _, h := range server.Headers {
+				headers[h.Name] = h.Value
+			}
+			entry["http_headers"] = headers
+		}
+		return entry
+	}
+
+	entry := map[string]any{
+		"command": server.Command,
+		"args":    append([]string{}, server.Args...),
+	}
+	if len(server.Env) > 0 {
+		env := make(map[string]string, len(server.Env))
+		for _, e := range server.Env {
+			env[e.Name] = e.Value
+		}
+		entry["env"] = env
+	}
+	return entry
+}
+
 func sessionModelExists(models []SessionModel, modelID string) bool {
 	for _, m := range models {
 		if m.ModelID == modelID {
@@ -677,6 +757,13 @@ func (c *Client) SetModel(ctx context.Context, sessionID, modelID string) error
 	return nil
 }
 
+// IsMethodNotFound reports whether err is the agent telling us it does not
+// implement the method that was called (JSON-RPC -32601, or the Codex
+// app-server "unknown variant" equivalent).
+func IsMethodNotFound(err error) bool {
+	return isMethodUnavailable(err, "")
+}
+
 func isMethodUnavailable(err error, method string) bool {
 	var rpcErr *JSONRPCError
 	if errors.As(err, &rpcErr) {
@@ -701,6 +788,7 @@ func (c *Client) Close() error {
 	for id, ch := range c.pending {
 		close(ch)
 		delete(c.pending, id)
+		delete(c.pendingLane, id)
 	}
 	c.pendingMu.Unlock()
 
@@ -710,11 +798,13 @@ func (c *Client) Close() error {
 	}
 	c.codexMu.Unlock()
 
-	return c.transport.Close()
+	err := c.transport.Close()
+	c.dispatcher.close()
+	return err
 }
 
 // Transport returns the underlying transport for direct access if needed.
-func (c *Client) Transport() *StdioTransport {
+func (c *Client) Transport() Transport {
 	return c.transport
 }
 
@@ -738,63 +828,63 @@ func (c *Client) OnSessionUpdate(handler func(SessionUpdateParams)) {
 // OnRequestPermission registers a handler for requestPermission requests.
 func (c *Client) OnRequestPermission(handler func(RequestPermissionParams) RequestPermissionResult) {
 	c.handlerMu.Lock()
-	c.onRequestPermission = handler
+	c.handlers.onRequestPermission = handler
 	c.handlerMu.Unlock()
 }
 
 // OnRequestUserInput registers a handler for item/tool/requestUserInput requests.
 func (c *Client) OnRequestUserInput(handler func(ToolRequestUserInputParams) ToolRequestUserInputResponse) {
 	c.handlerMu.Lock()
-	c.onRequestUserInput = handler
+	c.handlers.onRequestUserInput = handler
 	c.handlerMu.Unlock()
 }
 
 // OnFSReadTextFile registers a handler for fs/readTextFile requests.
 func (c *Client) OnFSReadTextFile(handler func(FSReadTextFileParams) (*FSReadTextFileResult, error)) {
 	c.handlerMu.Lock()
-	c.onFSReadTextFile = handler
+	c.handlers.onFSReadTextFile = handler
 	c.handlerMu.Unlock()
 }
 
 // OnFSWriteTextFile registers a handler for fs/writeTextFile requests.
 func (c *Client) OnFSWriteTextFile(handler func(FSWriteTextFileParams) error) {
 	c.handlerMu.Lock()
-	c.onFSWriteTextFile = handler
+	c.handlers.onFSWriteTextFile = handler
 	c.handlerMu.Unlock()
 }
 
 // OnTerminalCreate registers a handler for terminal/create requests.
 func (c *Client) OnTerminalCreate(handler func(TerminalCreateParams) (*TerminalCreateResult, error)) {
 	c.handlerMu.Lock()
-	c.onTerminalCreate = handler
+	c.handlers.onTerminalCreate = handler
 	c.handlerMu.Unlock()
 }
 
 // OnTerminalOutput registers a handler for terminal/output requests.
 func (c *Client) OnTerminalOutput(handler func(TerminalOutputParams) (*TerminalOutputResult, error)) {
 	c.handlerMu.Lock()
-	c.onTerminalOutput = handler
+	c.handlers.onTerminalOutput = handler
 	c.handlerMu.Unlock()
 }
 
 // OnTerminalWait registers a handler for terminal/wait requests.
 func (c *Client) OnTerminalWait(handler func(TerminalWaitParams) (*TerminalWaitResult, error)) {
 	c.handlerMu.Lock()
-	c.onTerminalWait = handler
+	c.handlers.onTerminalWait = handler
 	c.handlerMu.Unlock()
 }
 
 // OnTerminalKill registers a handler for terminal/kill requests.
 func (c *Client) OnTerminalKill(handler func(TerminalKillParams) error) {
 	c.handlerMu.Lock()
-	c.onTerminalKill = handler
+	c.handlers.onTerminalKill = handler
 	c.handlerMu.Unlock()
 }
 
 // OnTerminalRelease registers a handler for terminal/release requests.
 func (c *Client) OnTerminalRelease(handler func(TerminalReleaseParams) error) {
 	c.handlerMu.Lock()
-	c.onTerminalRelease = handler
+	c.handlers.onTerminalRelease = handler
 	c.handlerMu.Unlock()
 }
 
@@ -805,6 +895,12 @@ func (c *Client) OnTerminalRelease(handler func(TerminalReleaseParams) error) {
 // call sends a JSON-RPC request and blocks until a response is received or
 // the context expires. Returns the raw result JSON on success.
 func (c *Client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return c.callWithTimeout(ctx, method, params, c.RequestTimeout)
+}
+
+// callWithTimeout is call with an explicit timeout. A timeout <= 0 waits for
+// as long as ctx allows.
+func (c *Client) callWithTimeout(ctx context.Context, method string, params any, timeout time.Duration) (json.RawMessage, error) {
 	id := c.nextID.Add(1)
 
 	paramsJSON, err := json.Marshal(params)
@@ -825,27 +921,31 @@ func (c *Client) call(ctx context.Context, method string, params any) (json.RawM
 	ch := make(chan json.RawMessage, 1)
 	c.pendingMu.Lock()
 	c.pending[id] = ch
+	if lane := dispatchKey(paramsJSON); lane != "" {
+		c.pendingLane[id] = lane
+	}
 	c.pendingMu.Unlock()
 
 	if err := c.transport.Send(msg); err != nil {
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
+		c.forgetPending(id)
 		return nil, err
 	}
 
 	// Determine timeout from context or default.
-	timeout := c.RequestTimeout
-	deadline, hasDeadline := ctx.Deadline()
-	if hasDeadline {
-		remaining := time.Until(deadline)
-		if remaining < timeout {
-			timeout = remaining
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		deadline, hasDeadline := ctx.Deadline()
+		if hasDeadline {
+			remaining := time.Until(deadline)
+			if remaining < timeout {
+				timeout = remaining
+			}
 		}
-	}
 
-	timer := time.NewTimer(timeout)
-	defer timer.Stop()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
 
 	select {
 	case raw, ok := <-ch:
@@ -862,20 +962,25 @@ func (c *Client) call(ctx context.Context, method string, params any) (json.RawM
 		}
 		return resp.Result, nil
 
-	case <-timer.C:
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
+	case <-timeoutC:
+		c.forgetPending(id)
 		return nil, fmt.Errorf("request %s (id=%d) timed out after %v", method, id, timeout)
 
 	case <-ctx.Done():
-		c.pendingMu.Lock()
-		delete(c.pending, id)
-		c.pendingMu.Unlock()
+		c.forgetPending(id)
 		return nil, ctx.Err()
 	}
 }
 
+// forgetPending drops the bookkeeping for a request that will no longer be
+// waited on.
+func (c *Client) forgetPending(id int64) {
+	c.pendingMu.Lock()
+	delete(c.pending, id)
+	delete(c.pendingLane, id)
+	c.pendingMu.Unlock()
+}
+
 // notify sends a JSON-RPC notification (no ID, no response expected).
 func (c *Client) notify(method string, params any) error {
 	paramsJSON, err := json.Marshal(params)
@@ -896,16 +1001,26 @@ func (c *Client) notify(method string, params any) error {
 // Internal: incoming message dispatch
 // ---------------------------------------------------------------------------
 
-// dispatch is the handler registered with the transport. It routes each
-// incoming JSON-RPC message to the appropriate handler.
+// dispatch is the handler registered with the transport. It runs on the
+// transport read loop and therefore never blocks: notifications are queued on
+// their session's lane, requests are started from that lane on their own
+// goroutine (so a pending approval cannot stall later messages), and
+// responses are delivered after any updates queued for the same session.
 func (c *Client) dispatch(msg JSONRPCMessage) {
 	switch {
 	case msg.IsResponse():
-		c.handleResponse(msg)
+		c.pendingMu.Lock()
+		lane, ok := c.pendingLane[msg.IDAsInt64()]
+		c.pendingMu.Unlock()
+		if !ok {
+			c.handleResponse(msg)
+			return
+		}
+		c.dispatcher.enqueue(lane, func() { c.handleResponse(msg) })
 	case msg.IsNotification():
-		c.handleNotification(msg)
+		c.dispatcher.enqueue(dispatchKey(msg.Params), func() { c.handleNotification(msg) })
 	case msg.IsRequest():
-		c.handleRequest(msg)
+		c.dispatcher.enqueue(dispatchKey(msg.Params), func() { go c.handleRequest(msg) })
 	default:
 		log.Printf("acp: received unrecognized message: %+v", msg)
 	}
@@ -925,6 +1040,7 @@ func (c *Client) handleResponse(msg JSONRPCMessage) {
 	if ok {
 		delete(c.pending, id)
 	}
+	delete(c.pendingLane, id)
 	c.pendingMu.Unlock()
 
 	if !ok {
@@ -1079,20 +1195,21 @@ func (c *Client) signalCodexPromptDone(sessionID, stopReason string) {
 // handler, and sends back a JSON-RPC response.
 func (c *Client) handleRequest(msg JSONRPCMessage) {
 	c.handlerMu.RLock()
-	defer c.handlerMu.RUnlock()
+	h := c.handlers
+	c.handlerMu.RUnlock()
 
 	var result any
 	var handlerErr error
 
 	switch msg.Method {
 	case MethodRequestPermission:
-		if c.onRequestPermission != nil {
+		if h.onRequestPermission != nil {
 			var params RequestPermissionParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			res := c.onRequestPermission(params)
+			res := h.onRequestPermission(params)
 			result = res
 		} else {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
@@ -1100,7 +1217,7 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 		}
 
 	case MethodExecCommandApproval:
-		if c.onRequestPermission == nil {
+		if h.onRequestPermission == nil {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
@@ -1110,11 +1227,11 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 			return
 		}
 		req, toResult := buildLegacyExecApprovalBridge(params)
-		res := c.onRequestPermission(req)
+		res := h.onRequestPermission(req)
 		result = toResult(permissionSelection(res))
 
 	case MethodApplyPatchApproval:
-		if c.onRequestPermission == nil {
+		if h.onRequestPermission == nil {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
@@ -1124,11 +1241,11 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 			return
 		}
 		req, toResult := buildLegacyPatchApprovalBridge(params)
-		res := c.onRequestPermission(req)
+		res := h.onRequestPermission(req)
 		result = toResult(permissionSelection(res))
 
 	case MethodItemCommandExecutionRequestApproval:
-		if c.onRequestPermission == nil {
+		if h.onRequestPermission == nil {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
@@ -1138,11 +1255,11 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 			return
 		}
 		req, toResult := buildV2ExecApprovalBridge(params)
-		res := c.onRequestPermission(req)
+		res := h.onRequestPermission(req)
 		result = toResult(permissionSelection(res))
 
 	case MethodItemFileChangeRequestApproval:
-		if c.onRequestPermission == nil {
+		if h.onRequestPermission == nil {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
@@ -1152,7 +1269,7 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 			return
 		}
 		req, toResult := buildV2FileChangeApprovalBridge(params)
-		res := c.onRequestPermission(req)
+		res := h.onRequestPermission(req)
 		result = toResult(permissionSelection(res))
 
 	case MethodItemToolRequestUserInput:
@@ -1161,39 +1278,39 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 			c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 			return
 		}
-		if c.onRequestUserInput != nil {
-			result = c.onRequestUserInput(params)
+		if h.onRequestUserInput != nil {
+			result = h.onRequestUserInput(params)
 			break
 		}
-		if c.onRequestPermission == nil {
+		if h.onRequestPermission == nil {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
 		req, toResult := buildV2ToolUserInputBridge(params)
-		res := c.onRequestPermission(req)
+		res := h.onRequestPermission(req)
 		result = toResult(permissionSelection(res))
 
 	case MethodFSReadTextFile:
-		if c.onFSReadTextFile != nil {
+		if h.onFSReadTextFile != nil {
 			var params FSReadTextFileParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			result, handlerErr = c.onFSReadTextFile(params)
+			result, handlerErr = h.onFSReadTextFile(params)
 		} else {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
 
 	case MethodFSWriteTextFile:
-		if c.onFSWriteTextFile != nil {
+		if h.onFSWriteTextFile != nil {
 			var params FSWriteTextFileParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			handlerErr = c.onFSWriteTextFile(params)
+			handlerErr = h.onFSWriteTextFile(params)
 			if handlerErr == nil {
 				result = struct{}{}
 			}
@@ -1203,52 +1320,52 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 		}
 
 	case MethodTerminalCreate:
-		if c.onTerminalCreate != nil {
+		if h.onTerminalCreate != nil {
 			var params TerminalCreateParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			result, handlerErr = c.onTerminalCreate(params)
+			result, handlerErr = h.onTerminalCreate(params)
 		} else {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
 
 	case MethodTerminalOutput:
-		if c.onTerminalOutput != nil {
+		if h.onTerminalOutput != nil {
 			var params TerminalOutputParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			result, handlerErr = c.onTerminalOutput(params)
+			result, handlerErr = h.onTerminalOutput(params)
 		} else {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
 
 	case MethodTerminalWait:
-		if c.onTerminalWait != nil {
+		if h.onTerminalWait != nil {
 			var params TerminalWaitParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			result, handlerErr = c.onTerminalWait(params)
+			result, handlerErr = h.onTerminalWait(params)
 		} else {
 			c.sendError(msg.ID, ErrCodeMethodNotFound, "no handler for "+msg.Method)
 			return
 		}
 
 	case MethodTerminalKill:
-		if c.onTerminalKill != nil {
+		if h.onTerminalKill != nil {
 			var params TerminalKillParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			handlerErr = c.onTerminalKill(params)
+			handlerErr = h.onTerminalKill(params)
 			if handlerErr == nil {
 				result = struct{}{}
 			}
@@ -1258,13 +1375,13 @@ func (c *Client) handleRequest(msg JSONRPCMessage) {
 		}
 
 	case MethodTerminalRelease:
-		if c.onTerminalRelease != nil {
+		if h.onTerminalRelease != nil {
 			var params TerminalReleaseParams
 			if err := json.Unmarshal(msg.Params, &params); err != nil {
 				c.sendError(msg.ID, ErrCodeInvalidParams, "invalid params: "+err.Error())
 				return
 			}
-			handlerErr = c.onTerminalRelease(params)
+			handlerErr = h.onTerminalRelease(params)
 			if handlerErr == nil {
 				result = struct{}{}
 			}
@@ -1314,6 +1431,10 @@ func buildLegacyExecApprovalBridge(params ExecCommandApprovalParams) (RequestPer
 			Title:      title,
 			Kind:       "command_execution",
 			Status:     "pending",
+			RawInput: commandRawInput(
+				strings.TrimSpace(strings.Join(params.Command, " ")),
+				params.CWD,
+			),
 		},
 		Options: options,
 	}
@@ -1325,6 +1446,38 @@ func buildLegacyExecApprovalBridge(params ExecCommandApprovalParams) (RequestPer
 	}
 }
 
+// commandRawInput builds the rawInput of a bridged shell approval so that
+// consumers see the same shape as ACP execute tool calls.
+func commandRawInput(command, cwd string) json.RawMessage {
+	if command == "" && cwd == "" {
+		return nil
+	}
+	raw, err := json.Marshal(map[string]string{"command": command, "cwd": cwd})
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// fileChangeLocations lists the files touched by a Codex patch, in a stable
+// order.
+func fileChangeLocations(changes map[string]FileChangePreview) []ToolCallLocation {
+	if len(changes) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(changes))
+	for path := range changes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	locations := make([]ToolCallLocation, 0, len(paths))
+	for _, path := range paths {
+		locations = append(locations, ToolCallLocation{Path: path})
+	}
+	return locations
+}
+
 func buildLegacyPatchApprovalBridge(params ApplyPatchApprovalParams) (RequestPermissionParams, func(string) any) {
 	options := buildDecisionOptions(nil, []string{"approved", "approved_for_session", "denied"})
 
@@ -1345,6 +1498,7 @@ func buildLegacyPatchApprovalBridge(params ApplyPatchApprovalParams) (RequestPer
 			Title:      title,
 			Kind:       "file_change",
 			Status:     "pending",
+			Locations:  fileChangeLocations(params.FileChanges),
 		},
 		Options: options,
 	}
@@ -1389,6 +1543,7 @@ func buildV2ExecApprovalBridge(params CommandExecutionRequestApprovalParams) (Re
 			Title:      title,
 			Kind:       "command_execution",
 			Status:     "pending",
+			RawInput:   commandRawInput(command, ptrString(params.CWD)),
 		},
 		Options: options,
 	}
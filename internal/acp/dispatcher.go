@@ -0,0 +1,112 @@
+package acp
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// dispatcher moves incoming message handling off the transport read loop.
+//
+// Work is grouped into lanes keyed by session (ACP sessionId, Codex threadId
+// or conversationId). Each lane runs its tasks one at a time in arrival order
+// on its own goroutine, so updates for one session are never reordered while
+// different sessions make progress independently. Lanes are created on demand
+// and their goroutine exits once the queue drains.
+//
+// Tasks must not block: anything that waits on the user (permission prompts,
+// questions) is started from a lane task on a separate goroutine.
+type dispatcher struct {
+	mu     sync.Mutex
+	lanes  map[string]*dispatchLane
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type dispatchLane struct {
+	queue   []func()
+	running bool
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		lanes: make(map[string]*dispatchLane),
+	}
+}
+
+// enqueue appends fn to the lane identified by key. Tasks submitted after
+// close are dropped.
+func (d *dispatcher) enqueue(key string, fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	lane, ok := d.lanes[key]
+	if !ok {
+		lane = &dispatchLane{}
+		d.lanes[key] = lane
+	}
+	lane.queue = append(lane.queue, fn)
+	if lane.running {
+		return
+	}
+
+	lane.running = true
+	d.wg.Add(1)
+	go d.drain(key, lane)
+}
+
+// drain runs queued tasks for one lane until it is empty.
+func (d *dispatcher) drain(key string, lane *dispatchLane) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		if len(lane.queue) == 0 || d.closed {
+			lane.queue = nil
+			lane.running = false
+			if d.lanes[key] == lane {
+				delete(d.lanes, key)
+			}
+			d.mu.Unlock()
+			return
+		}
+		fn := lane.queue[0]
+		lane.queue[0] = nil
+		lane.queue = lane.queue[1:]
+		d.mu.Unlock()
+
+		fn()
+	}
+}
+
+// close drops queued tasks and waits for in-flight lane tasks to finish.
+// Request handlers already started on their own goroutines are not awaited.
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+// dispatchKey extracts the session a message belongs to from its params. It
+// understands both ACP (sessionId) and the Codex app-server dialect (threadId,
+// conversationId). Messages without a session share the "" lane.
+func dispatchKey(params json.RawMessage) string {
+	if len(params) == 0 || params[0] != '{' {
+		return ""
+	}
+
+	var ids struct {
+		SessionID      string `json:"sessionId"`
+		ThreadID       string `json:"threadId"`
+		ConversationID string `json:"conversationId"`
+	}
+	if err := json.Unmarshal(params, &ids); err != nil {
+		return ""
+	}
+	return firstNonEmpty(ids.SessionID, ids.ThreadID, ids.ConversationID)
+}
@@ -0,0 +1,116 @@
+package acp
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherPreservesOrderWithinLane(t *testing.T) {
+	d := newDispatcher()
+	defer d.close()
+
+	var mu sync.Mutex
+	var got []int
+	done := make(chan struct{})
+
+	for i := 0; i < 100; i++ {
+		i := i
+		d.enqueue("s1", func() {
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+			if i == 99 {
+				close(done)
+			}
+		})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for lane to drain")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("got[%d] = %d, want %d", i, v, i)
+		}
+	}
+}
+
+func TestDispatcherLanesDoNotBlockEachOther(t *testing.T) {
+	d := newDispatcher()
+
+	release := make(chan struct{})
+	d.enqueue("slow", func() { <-release })
+
+	ran := make(chan struct{})
+	d.enqueue("fast", func() { close(ran) })
+
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task on an independent lane was blocked")
+	}
+
+	close(release)
+	d.close()
+}
+
+func TestDispatchKey(t *testing.T) {
+	cases := map[string]string{
+		`{"sessionId":"s1","update":{}}`: "s1",
+		`{"threadId":"t1","delta":"x"}`:  "t1",
+		`{"conversationId":"c1"}`:        "c1",
+		`{"path":"/tmp/x"}`:              "",
+		`[1,2]`:                          "",
+		``:                               "",
+	}
+	for params, want := range cases {
+		if got := dispatchKey(json.RawMessage(params)); got != want {
+			t.Fatalf("dispatchKey(%s) = %q, want %q", params, got, want)
+		}
+	}
+}
+
+func TestClientPendingPermissionDoesNotStallNotifications(t *testing.T) {
+	c := NewClient(NewStdioTransport("true", nil, nil, ""))
+	defer c.dispatcher.close()
+
+	release := make(chan struct{})
+	c.OnRequestPermission(func(RequestPermissionParams) RequestPermissionResult {
+		<-release
+		return RequestPermissionResult{Outcome: PermissionOutcome{Outcome: "cancelled"}}
+	})
+
+	updates := make(chan SessionUpdateParams, 1)
+	c.OnSessionUpdate(func(p SessionUpdateParams) { updates <- p })
+
+	id := json.RawMessage(`7`)
+	c.dispatch(JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      &id,
+		Method:  MethodRequestPermission,
+		Params:  json.RawMessage(`{"sessionId":"s1","toolCall":{"toolCallId":"tc1"},"options":[]}`),
+	})
+	c.dispatch(JSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  MethodSessionUpdate,
+		Params:  json.RawMessage(`{"sessionId":"s1","update":{"sessionUpdate":"agent_message_chunk","content":{"type":"text","text":"hi"}}}`),
+	})
+
+	select {
+	case got := <-updates:
+		if got.SessionID != "s1" {
+			t.Fatalf("session id = %q, want s1", got.SessionID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("session update was blocked by a pending permission request")
+	}
+
+	close(release)
+}
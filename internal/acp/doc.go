@@ -1,5 +1,7 @@
 // Package acp implements the Agent Client Protocol (ACP) types and client.
-// ACP uses JSON-RPC 2.0 over stdio for communication between a client
-// (this desktop app) and an AI coding agent subprocess.
+// ACP uses JSON-RPC 2.0 for communication between a client (this desktop
+// app) and an AI coding agent, normally over the stdio of an agent
+// subprocess. Agents running as daemons can be reached over TCP, Unix
+// sockets or WebSockets through the Transport interface.
 // Spec: https://agentclientprotocol.com
 package acp
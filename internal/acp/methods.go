@@ -3,6 +3,7 @@ package acp
 // ACP method names (JSON-RPC method strings).
 const (
 	MethodInitialize        = "initialize"
+	MethodAuthenticate      = "authenticate"
 	MethodSessionNew        = "session/new"
 	MethodSessionLoad       = "session/load"
 	MethodSessionResume     = "session/resume"
@@ -0,0 +1,133 @@
+package acp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// Transport carries JSON-RPC messages between the client and an agent.
+//
+// Implementations must deliver each incoming message to the handler set via
+// SetHandler, in the order received, from a single goroutine. Send must be
+// safe to call from multiple goroutines.
+type Transport interface {
+	// Start opens the underlying connection (spawning a process, dialing a
+	// socket, ...) and begins reading messages.
+	Start() error
+
+	// SetHandler registers the function called for each incoming message.
+	// Must be called before Start or messages may be missed.
+	SetHandler(h func(JSONRPCMessage))
+
+	// Send writes a single message to the agent.
+	Send(msg JSONRPCMessage) error
+
+	// StderrCh returns diagnostic output from the agent. Transports without
+	// a side channel return a channel that is closed on Close.
+	StderrCh() <-chan string
+
+	// Done is closed when the read side of the transport has stopped.
+	Done() <-chan struct{}
+
+	// IsRunning reports whether the transport can still send and receive.
+	IsRunning() bool
+
+	// Close shuts the connection down and releases its resources.
+	Close() error
+}
+
+var (
+	_ Transport = (*StdioTransport)(nil)
+	_ Transport = (*StreamTransport)(nil)
+	_ Transport = (*WebSocketTransport)(nil)
+)
+
+// maxMessageSize bounds a single incoming JSON-RPC message. Tool outputs can
+// be large, so this is generous.
+const maxMessageSize = 10 * 1024 * 1024
+
+// readMessages reads newline-delimited JSON-RPC messages from r until EOF or
+// a read error, calling deliver for each valid message. The returned error is
+// nil on clean EOF.
+func readMessages(r io.Reader, deliver func(JSONRPCMessage)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg JSONRPCMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Printf("acp: invalid JSON from agent: %v (line: %s)", err, string(line))
+			continue
+		}
+		deliver(msg)
+	}
+
+	return scanner.Err()
+}
+
+// writeMessage marshals msg and writes it to w as a single line.
+func writeMessage(w io.Writer, msg JSONRPCMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("acp: marshal message: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("acp: write message: %w", err)
+	}
+	return nil
+}
+
+// NewTransportForAddress returns a transport that attaches to an already
+// running agent. Supported address forms:
+//
+//	tcp://host:port
+//	unix:///path/to/agent.sock
+//	ws://host:port/path, wss://host/path
+//
+// A bare host:port is treated as TCP.
+func NewTransportForAddress(address string) (Transport, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("acp: empty agent address")
+	}
+	if !strings.Contains(address, "://") {
+		return NewSocketTransport("tcp", address), nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("acp: parse agent address %q: %w", address, err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("acp: agent address %q has no host", address)
+		}
+		return NewSocketTransport("tcp", u.Host), nil
+	case "unix":
+		path := u.Path
+		if path == "" {
+			path = u.Opaque
+		}
+		if path == "" {
+			return nil, fmt.Errorf("acp: agent address %q has no socket path", address)
+		}
+		return NewSocketTransport("unix", path), nil
+	case "ws", "wss":
+		return NewWebSocketTransport(address, nil), nil
+	default:
+		return nil, fmt.Errorf("acp: unsupported agent address scheme %q", u.Scheme)
+	}
+}
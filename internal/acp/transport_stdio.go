@@ -2,7 +2,6 @@ package acp
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -97,11 +96,6 @@ func (t *StdioTransport) SetHandler(h func(JSONRPCMessage)) {
 // Send marshals a JSON-RPC message and writes it as a single line to the
 // subprocess stdin. It is safe to call from multiple goroutines.
 func (t *StdioTransport) Send(msg JSONRPCMessage) error {
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("acp: marshal message: %w", err)
-	}
-
 	t.writeMu.Lock()
 	defer t.writeMu.Unlock()
 
@@ -109,15 +103,7 @@ func (t *StdioTransport) Send(msg JSONRPCMessage) error {
 		return fmt.Errorf("acp: transport is closed")
 	}
 
-	// Write the JSON line followed by a newline.
-	if _, err := t.stdin.Write(data); err != nil {
-		return fmt.Errorf("acp: write stdin: %w", err)
-	}
-	if _, err := t.stdin.Write([]byte("\n")); err != nil {
-		return fmt.Errorf("acp: write stdin newline: %w", err)
-	}
-
-	return nil
+	return writeMessage(t.stdin, msg)
 }
 
 // StderrCh returns a channel that receives lines written to the subprocess
@@ -184,22 +170,7 @@ func (t *StdioTransport) readLoop() {
 		close(t.done)
 	}()
 
-	scanner := bufio.NewScanner(t.stdout)
-	// Allow up to 10 MB per line to handle large tool outputs.
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
-
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		var msg JSONRPCMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			log.Printf("acp: invalid JSON from agent: %v (line: %s)", err, string(line))
-			continue
-		}
-
+	err := readMessages(t.stdout, func(msg JSONRPCMessage) {
 		t.handlerMu.RLock()
 		h := t.handler
 		t.handlerMu.RUnlock()
@@ -207,12 +178,9 @@ func (t *StdioTransport) readLoop() {
 		if h != nil {
 			h(msg)
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		if t.running.Load() {
-			log.Printf("acp: stdout read error: %v", err)
-		}
+	})
+	if err != nil && t.running.Load() {
+		log.Printf("acp: stdout read error: %v", err)
 	}
 }
 
@@ -0,0 +1,165 @@
+package acp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDialTimeout bounds how long StreamTransport waits to connect to an
+// agent listening on a socket.
+const DefaultDialTimeout = 10 * time.Second
+
+// StreamTransport speaks newline-delimited JSON-RPC over any byte stream:
+// a TCP or Unix socket to an agent running as a daemon, or one end of an
+// in-memory pipe.
+type StreamTransport struct {
+	dial func() (io.ReadWriteCloser, error)
+	conn io.ReadWriteCloser
+
+	handler   func(JSONRPCMessage)
+	handlerMu sync.RWMutex
+
+	writeMu sync.Mutex
+
+	stderrCh  chan string
+	done      chan struct{}
+	running   atomic.Bool
+	closeOnce sync.Once
+}
+
+// NewStreamTransport wraps an already open stream. Closing the transport
+// closes the stream.
+func NewStreamTransport(conn io.ReadWriteCloser) *StreamTransport {
+	return newStreamTransport(func() (io.ReadWriteCloser, error) {
+		return conn, nil
+	})
+}
+
+// NewSocketTransport prepares a transport that dials network/address (as
+// accepted by net.Dial, e.g. "tcp" or "unix") when started.
+func NewSocketTransport(network, address string) *StreamTransport {
+	return newStreamTransport(func() (io.ReadWriteCloser, error) {
+		conn, err := net.DialTimeout(network, address, DefaultDialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("acp: dial %s %s: %w", network, address, err)
+		}
+		return conn, nil
+	})
+}
+
+// NewPipeTransports returns two connected in-memory transports. Messages sent
+// on one are received by the other, which makes it possible to run a Client
+// against an in-process agent without spawning a subprocess.
+func NewPipeTransports() (client, agent *StreamTransport) {
+	a, b := net.Pipe()
+	return NewStreamTransport(a), NewStreamTransport(b)
+}
+
+func newStreamTransport(dial func() (io.ReadWriteCloser, error)) *StreamTransport {
+	return &StreamTransport{
+		dial:     dial,
+		stderrCh: make(chan string),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start opens the stream and begins reading messages.
+func (t *StreamTransport) Start() error {
+	conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	t.conn = conn
+	t.writeMu.Unlock()
+	t.running.Store(true)
+
+	go t.readLoop()
+	return nil
+}
+
+// SetHandler registers the function called for each incoming message.
+func (t *StreamTransport) SetHandler(h func(JSONRPCMessage)) {
+	t.handlerMu.Lock()
+	t.handler = h
+	t.handlerMu.Unlock()
+}
+
+// Send writes msg as a single line. It is safe to call from multiple
+// goroutines.
+func (t *StreamTransport) Send(msg JSONRPCMessage) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if !t.running.Load() || t.conn == nil {
+		return fmt.Errorf("acp: transport is closed")
+	}
+	return writeMessage(t.conn, msg)
+}
+
+// StderrCh returns a channel that never receives; it is closed on Close.
+// Remote agents keep their diagnostics on their own side.
+func (t *StreamTransport) StderrCh() <-chan string {
+	return t.stderrCh
+}
+
+// Done is closed when the read loop exits.
+func (t *StreamTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// IsRunning reports whether the stream is still open.
+func (t *StreamTransport) IsRunning() bool {
+	return t.running.Load()
+}
+
+// Close closes the stream and waits for the read loop to finish.
+func (t *StreamTransport) Close() error {
+	var firstErr error
+
+	t.closeOnce.Do(func() {
+		t.running.Store(false)
+
+		t.writeMu.Lock()
+		conn := t.conn
+		t.writeMu.Unlock()
+
+		if conn != nil {
+			if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				firstErr = fmt.Errorf("acp: close stream: %w", err)
+			}
+			<-t.done
+		}
+
+		close(t.stderrCh)
+	})
+
+	return firstErr
+}
+
+func (t *StreamTransport) readLoop() {
+	defer func() {
+		t.running.Store(false)
+		close(t.done)
+	}()
+
+	err := readMessages(t.conn, func(msg JSONRPCMessage) {
+		t.handlerMu.RLock()
+		h := t.handler
+		t.handlerMu.RUnlock()
+
+		if h != nil {
+			h(msg)
+		}
+	})
+	if err != nil && t.running.Load() && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, net.ErrClosed) {
+		log.Printf("acp: stream read error: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAgent answers client requests on the agent end of an in-memory pipe.
+type fakeAgent struct {
+	t         *testing.T
+	transport *StreamTransport
+
+	mu        sync.Mutex
+	responses map[string]chan JSONRPCMessage
+}
+
+func newFakeAgent(t *testing.T, transport *StreamTransport, handle func(a *fakeAgent, msg JSONRPCMessage)) *fakeAgent {
+	a := &fakeAgent{
+		t:         t,
+		transport: transport,
+		responses: make(map[string]chan JSONRPCMessage),
+	}
+	transport.SetHandler(func(msg JSONRPCMessage) {
+		if msg.IsResponse() {
+			a.mu.Lock()
+			ch := a.responses[string(*msg.ID)]
+			a.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+			return
+		}
+		go handle(a, msg)
+	})
+	if err := transport.Start(); err != nil {
+		t.Fatalf("start agent transport: %v", err)
+	}
+	return a
+}
+
+func (a *fakeAgent) reply(msg JSONRPCMessage, result any) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		a.t.Errorf("marshal result: %v", err)
+		return
+	}
+	if err := a.transport.Send(JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID, Result: raw}); err != nil {
+		a.t.Errorf("send result: %v", err)
+	}
+}
+
+func (a *fakeAgent) notify(method string, params any) {
+	raw, _ := json.Marshal(params)
+	if err := a.transport.Send(JSONRPCMessage{JSONRPC: "2.0", Method: method, Params: raw}); err != nil {
+		a.t.Errorf("send notification: %v", err)
+	}
+}
+
+func (a *fakeAgent) request(id, method string, params any) JSONRPCMessage {
+	ch := make(chan JSONRPCMessage, 1)
+	a.mu.Lock()
+	a.responses[id] = ch
+	a.mu.Unlock()
+
+	raw, _ := json.Marshal(params)
+	rawID := json.RawMessage(id)
+	if err := a.transport.Send(JSONRPCMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: raw}); err != nil {
+		a.t.Errorf("send request: %v", err)
+	}
+
+	select {
+	case resp := <-ch:
+		return resp
+	case <-time.After(2 * time.Second):
+		a.t.Errorf("timed out waiting for client response to %s", method)
+		return JSONRPCMessage{}
+	}
+}
+
+func TestClientOverPipeTransport(t *testing.T) {
+	clientEnd, agentEnd := NewPipeTransports()
+
+	newFakeAgent(t, agentEnd, func(a *fakeAgent, msg JSONRPCMessage) {
+		switch msg.Method {
+		case MethodInitialize:
+			a.reply(msg, InitializeResult{
+				ProtocolVersion:   1,
+				AgentCapabilities: AgentCapabilities{LoadSession: true},
+				AgentInfo:         ImplementationInfo{Name: "fake"},
+			})
+		case MethodSessionNew:
+			a.reply(msg, SessionNewResult{SessionID: "s1"})
+		case MethodSessionPrompt:
+			resp := a.request(`"r1"`, MethodFSReadTextFile, FSReadTextFileParams{SessionID: "s1", Path: "/a.txt"})
+			var read FSReadTextFileResult
+			if err := json.Unmarshal(resp.Result, &read); err != nil || read.Content != "contents" {
+				t.Errorf("read result = %s (%v), want contents", resp.Result, err)
+			}
+			a.notify(MethodSessionUpdate, map[string]any{
+				"sessionId": "s1",
+				"update": map[string]any{
+					"sessionUpdate": "agent_message_chunk",
+					"content":       map[string]any{"type": "text", "text": "done"},
+				},
+			})
+			a.reply(msg, SessionPromptResult{StopReason: "end_turn"})
+		}
+	})
+
+	client := NewClient(clientEnd)
+	defer client.Close()
+
+	client.OnFSReadTextFile(func(p FSReadTextFileParams) (*FSReadTextFileResult, error) {
+		return &FSReadTextFileResult{Content: "contents"}, nil
+	})
+
+	var mu sync.Mutex
+	var chunks []string
+	client.OnSessionUpdate(func(p SessionUpdateParams) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Update.MessageContent != nil {
+			chunks = append(chunks, p.Update.MessageContent.Text)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	init, err := client.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	if init.AgentInfo.Name != "fake" || !init.AgentCapabilities.LoadSession {
+		t.Fatalf("initialize result = %+v", init)
+	}
+
+	session, err := client.NewSession(ctx, "/tmp", nil)
+	if err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	if session.SessionID != "s1" {
+		t.Fatalf("session id = %q, want s1", session.SessionID)
+	}
+
+	res, err := client.Prompt(ctx, "s1", []ContentBlock{{Type: "text", Text: "hi"}})
+	if err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if res.StopReason != "end_turn" {
+		t.Fatalf("stop reason = %q, want end_turn", res.StopReason)
+	}
+
+	// The update was sent before the prompt result, so it must have been
+	// delivered by the time Prompt returns.
+	mu.Lock()
+	defer mu.Unlock()
+	if len(chunks) != 1 || chunks[0] != "done" {
+		t.Fatalf("chunks = %v, want [done]", chunks)
+	}
+}
+
+func TestNewTransportForAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		want    string
+	}{
+		{"tcp://127.0.0.1:7000", "*acp.StreamTransport"},
+		{"127.0.0.1:7000", "*acp.StreamTransport"},
+		{"unix:///tmp/agent.sock", "*acp.StreamTransport"},
+		{"ws://localhost:7000/acp", "*acp.WebSocketTransport"},
+	}
+	for _, tc := range cases {
+		tr, err := NewTransportForAddress(tc.address)
+		if err != nil {
+			t.Fatalf("NewTransportForAddress(%q): %v", tc.address, err)
+		}
+		if got := typeName(tr); got != tc.want {
+			t.Fatalf("NewTransportForAddress(%q) = %s, want %s", tc.address, got, tc.want)
+		}
+	}
+
+	if _, err := NewTransportForAddress("http://example.com"); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case *StreamTransport:
+		return "*acp.StreamTransport"
+	case *WebSocketTransport:
+		return "*acp.WebSocketTransport"
+	case *StdioTransport:
+		return "*acp.StdioTransport"
+	default:
+		return "unknown"
+	}
+}
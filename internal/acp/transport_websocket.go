@@ -0,0 +1,174 @@
+package acp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketTransport connects to an agent exposed over a WebSocket. Each
+// text frame carries exactly one JSON-RPC message.
+type WebSocketTransport struct {
+	url    string
+	header http.Header
+	conn   *websocket.Conn
+
+	handler   func(JSONRPCMessage)
+	handlerMu sync.RWMutex
+
+	writeMu sync.Mutex
+
+	stderrCh  chan string
+	done      chan struct{}
+	running   atomic.Bool
+	closeOnce sync.Once
+}
+
+// NewWebSocketTransport prepares a transport that dials url when started.
+// header is sent with the handshake and may carry credentials.
+func NewWebSocketTransport(url string, header http.Header) *WebSocketTransport {
+	return &WebSocketTransport{
+		url:      url,
+		header:   header,
+		stderrCh: make(chan string),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start performs the WebSocket handshake and begins reading messages.
+func (t *WebSocketTransport) Start() error {
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: DefaultDialTimeout,
+	}
+	conn, resp, err := dialer.Dial(t.url, t.header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("acp: dial %s: %w (status %s)", t.url, err, resp.Status)
+		}
+		return fmt.Errorf("acp: dial %s: %w", t.url, err)
+	}
+	conn.SetReadLimit(maxMessageSize)
+
+	t.writeMu.Lock()
+	t.conn = conn
+	t.writeMu.Unlock()
+	t.running.Store(true)
+
+	go t.readLoop()
+	return nil
+}
+
+// SetHandler registers the function called for each incoming message.
+func (t *WebSocketTransport) SetHandler(h func(JSONRPCMessage)) {
+	t.handlerMu.Lock()
+	t.handler = h
+	t.handlerMu.Unlock()
+}
+
+// Send writes msg as a single text frame. It is safe to call from multiple
+// goroutines.
+func (t *WebSocketTransport) Send(msg JSONRPCMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("acp: marshal message: %w", err)
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if !t.running.Load() || t.conn == nil {
+		return fmt.Errorf("acp: transport is closed")
+	}
+	if err := t.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("acp: write websocket: %w", err)
+	}
+	return nil
+}
+
+// StderrCh returns a channel that never receives; it is closed on Close.
+func (t *WebSocketTransport) StderrCh() <-chan string {
+	return t.stderrCh
+}
+
+// Done is closed when the read loop exits.
+func (t *WebSocketTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// IsRunning reports whether the WebSocket is still open.
+func (t *WebSocketTransport) IsRunning() bool {
+	return t.running.Load()
+}
+
+// Close sends a close frame, closes the connection and waits for the read
+// loop to finish.
+func (t *WebSocketTransport) Close() error {
+	var firstErr error
+
+	t.closeOnce.Do(func() {
+		t.running.Store(false)
+
+		t.writeMu.Lock()
+		conn := t.conn
+		if conn != nil {
+			_ = conn.WriteMessage(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			)
+		}
+		t.writeMu.Unlock()
+
+		if conn != nil {
+			if err := conn.Close(); err != nil {
+				firstErr = fmt.Errorf("acp: close websocket: %w", err)
+			}
+			<-t.done
+		}
+
+		close(t.stderrCh)
+	})
+
+	return firstErr
+}
+
+func (t *WebSocketTransport) readLoop() {
+	defer func() {
+		t.running.Store(false)
+		close(t.done)
+	}()
+
+	for {
+		kind, data, err := t.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if t.running.Load() && !errors.As(err, &closeErr) {
+				log.Printf("acp: websocket read error: %v", err)
+			}
+			return
+		}
+		if kind != websocket.TextMessage && kind != websocket.BinaryMessage {
+			continue
+		}
+
+		var msg JSONRPCMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Printf("acp: invalid JSON from agent: %v (frame: %s)", err, string(data))
+			continue
+		}
+
+		t.handlerMu.RLock()
+		h := t.handler
+		t.handlerMu.RUnlock()
+
+		if h != nil {
+			h(msg)
+		}
+	}
+}
@@ -41,10 +41,24 @@ type FSCapabilities struct {
 type AgentCapabilities struct {
 	LoadSession         bool                 `json:"loadSession,omitempty"`
 	PromptCapabilities  *PromptCapabilities  `json:"promptCapabilities,omitempty"`
-	MCP                 *MCPCapabilities     `json:"mcp,omitempty"`
+	MCPCapabilities     *MCPCapabilities     `json:"mcpCapabilities,omitempty"`
+	MCP                 *MCPCapabilities     `json:"mcp,omitempty"` // pre-release name of mcpCapabilities
 	SessionCapabilities *SessionCapabilities `json:"sessionCapabilities,omitempty"`
 }
 
+// MCPSupport returns the MCP transports the agent accepts, whichever field
+// name it used to report them.
+func (c AgentCapabilities) MCPSupport() MCPCapabilities {
+	var out MCPCapabilities
+	for _, m := range []*MCPCapabilities{c.MCPCapabilities, c.MCP} {
+		if m != nil {
+			out.HTTP = out.HTTP || m.HTTP
+			out.SSE = out.SSE || m.SSE
+		}
+	}
+	return out
+}
+
 // PromptCapabilities describes what content types the agent accepts in prompts.
 type PromptCapabilities struct {
 	Image           bool `json:"image,omitempty"`
@@ -58,8 +72,18 @@ type MCPCapabilities struct {
 	SSE  bool `json:"sse,omitempty"`
 }
 
-// SessionCapabilities is a future extension point for session-level features.
-type SessionCapabilities struct{}
+// SessionCapabilities advertises optional session-level methods. Each field
+// is an object in the protocol; its presence means the method is supported.
+type SessionCapabilities struct {
+	List   *SessionListCapabilities   `json:"list,omitempty"`
+	Resume *SessionResumeCapabilities `json:"resume,omitempty"`
+}
+
+// SessionListCapabilities is present when the agent supports session/list.
+type SessionListCapabilities struct{}
+
+// SessionResumeCapabilities is present when the agent supports session/resume.
+type SessionResumeCapabilities struct{}
 
 // ImplementationInfo identifies an ACP implementation (client or agent).
 type ImplementationInfo struct {
@@ -68,7 +92,16 @@ type ImplementationInfo struct {
 	Version string `json:"version"`
 }
 
-// AuthMethod describes an authentication method the agent requires.
+// AuthMethod describes one way the user can authenticate with the agent.
+// Its ID is passed back in AuthenticateParams.
 type AuthMethod struct {
-	Type string `json:"type"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// AuthenticateParams selects one of the agent's advertised auth methods.
+type AuthenticateParams struct {
+	MethodID string `json:"methodId"`
 }
@@ -68,3 +68,10 @@ const (
 	ErrCodeInvalidParams  = -32602
 	ErrCodeInternal       = -32603
 )
+
+// ACP-specific error codes.
+const (
+	// ErrCodeAuthRequired is returned by agents that need the client to call
+	// authenticate before creating or loading sessions.
+	ErrCodeAuthRequired = -32000
+)
@@ -1,5 +1,7 @@
 package acp
 
+import "encoding/json"
+
 // RequestPermissionParams is sent by the agent to ask the user for permission
 // before performing a sensitive action.
 type RequestPermissionParams struct {
@@ -10,11 +12,15 @@ type RequestPermissionParams struct {
 
 // ToolCallUpdate carries tool call details within a permission request.
 type ToolCallUpdate struct {
-	ToolCallID string            `json:"toolCallId"`
-	Title      string            `json:"title,omitempty"`
-	Kind       string            `json:"kind,omitempty"`
-	Status     string            `json:"status,omitempty"`
-	Content    []ToolCallContent `json:"content,omitempty"`
+	ToolCallID string             `json:"toolCallId"`
+	Title      string             `json:"title,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Status     string             `json:"status,omitempty"`
+	Content    []ToolCallContent  `json:"content,omitempty"`
+	Locations  []ToolCallLocation `json:"locations,omitempty"`
+	// RawInput is the tool's input as sent by the agent (for shell tools it
+	// usually carries "command" and "cwd").
+	RawInput json.RawMessage `json:"rawInput,omitempty"`
 }
 
 // PermissionOption is a single choice presented to the user.
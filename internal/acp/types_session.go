@@ -1,5 +1,7 @@
 package acp
 
+import "encoding/json"
+
 // SessionNewParams requests the agent to create a new session.
 type SessionNewParams struct {
 	CWD        string      `json:"cwd"`
@@ -97,6 +99,48 @@ type MCPServer struct {
 	Headers []HTTPHeader  `json:"headers,omitempty"`
 }
 
+// MCP server transports reported in MCPServer.Type. Stdio servers leave
+// Type empty.
+const (
+	MCPServerTypeHTTP = "http"
+	MCPServerTypeSSE  = "sse"
+)
+
+// MarshalJSON encodes the server in the shape of its transport. The protocol
+// requires args and env for stdio servers and headers for HTTP and SSE
+// servers, even when empty.
+func (s MCPServer) MarshalJSON() ([]byte, error) {
+	headers := s.Headers
+	if headers == nil {
+		headers = []HTTPHeader{}
+	}
+
+	switch s.Type {
+	case MCPServerTypeHTTP, MCPServerTypeSSE:
+		return json.Marshal(struct {
+			Type    string       `json:"type"`
+			Name    string       `json:"name"`
+			URL     string       `json:"url"`
+			Headers []HTTPHeader `json:"headers"`
+		}{s.Type, s.Name, s.URL, headers})
+	}
+
+	args := s.Args
+	if args == nil {
+		args = []string{}
+	}
+	env := s.Env
+	if env == nil {
+		env = []EnvVariable{}
+	}
+	return json.Marshal(struct {
+		Name    string        `json:"name"`
+		Command string        `json:"command"`
+		Args    []string      `json:"args"`
+		Env     []EnvVariable `json:"env"`
+	}{s.Name, s.Command, args, env})
+}
+
 // EnvVariable is a name/value pair for environment variables.
 type EnvVariable struct {
 	Name  string `json:"name"`
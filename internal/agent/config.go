@@ -5,12 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-)
+	"strings"
 
-var supportedAgentNames = map[string]struct{}{
-	"opencode":         {},
-	"codex-app-server": {},
-}
+	"bytesmith/internal/policy"
+)
 
 // AgentConfig represents the configuration for a single agent.
 type AgentConfig struct {
@@ -21,6 +19,32 @@ type AgentConfig struct {
 	Env         map[string]string `json:"env,omitempty"`
 	Description string            `json:"description,omitempty"`
 	AutoDetect  bool              `json:"autoDetect"`
+
+	// Address attaches to an agent that is already running instead of
+	// spawning Command, e.g. "tcp://127.0.0.1:7000",
+	// "unix:///run/agent.sock" or "ws://localhost:7000/acp".
+	Address string `json:"address,omitempty"`
+
+	// MCPServers names disabled MCP servers to enable for this agent.
+	MCPServers []string `json:"mcpServers,omitempty"`
+}
+
+// ProjectConfig holds settings that apply to sessions whose cwd is Path or
+// one of its subdirectories. A leading "~/" in Path expands to the home
+// directory.
+type ProjectConfig struct {
+	Path string `json:"path"`
+	// MCPServers names disabled MCP servers to enable for the project.
+	MCPServers []string `json:"mcpServers,omitempty"`
+}
+
+// SandboxConfig configures the workspace sandbox applied to agent file
+// access. Each session may access its cwd and ExtraRoots; other paths and
+// files matching SensitivePatterns need approval.
+type SandboxConfig struct {
+	ExtraRoots []string `json:"extraRoots,omitempty"`
+	// SensitivePatterns replaces the built-in list when set.
+	SensitivePatterns []string `json:"sensitivePatterns,omitempty"`
 }
 
 // Config is the top-level configuration.
@@ -28,14 +52,111 @@ type Config struct {
 	Agents     []AgentConfig     `json:"agents"`
 	MCPServers []MCPServerConfig `json:"mcpServers,omitempty"`
 	Settings   AppSettings       `json:"settings"`
+
+	// Projects holds per-workspace settings; the longest matching Path wins.
+	Projects []ProjectConfig `json:"projects,omitempty"`
+
+	// Sandbox widens or narrows what agents may access through fs/*.
+	Sandbox SandboxConfig `json:"sandbox,omitempty"`
+
+	// PermissionRules are evaluated in order before a permission request is
+	// shown to the user; see package policy.
+	PermissionRules []policy.Rule `json:"permissionRules,omitempty"`
 }
 
-// MCPServerConfig describes an MCP server that can be launched alongside agents.
+// MCP server transports accepted in MCPServerConfig.Type.
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportHTTP  = "http"
+	MCPTransportSSE   = "sse"
+)
+
+// MCPServerConfig describes an MCP server that is attached to agent
+// sessions. Stdio servers are launched by the agent from Command; HTTP and
+// SSE servers are reached at URL.
 type MCPServerConfig struct {
 	Name    string            `json:"name"`
-	Command string            `json:"command"`
+	Type    string            `json:"type,omitempty"` // "stdio" (default), "http" or "sse"
+	Command string            `json:"command,omitempty"`
 	Args    []string          `json:"args,omitempty"`
 	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Disabled servers are only attached for the agents and projects that
+	// list them in their MCPServers.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Transport returns the normalized transport of the server.
+func (s MCPServerConfig) Transport() string {
+	switch strings.ToLower(strings.TrimSpace(s.Type)) {
+	case "", MCPTransportStdio:
+		return MCPTransportStdio
+	case MCPTransportHTTP, "streamable-http", "streamable_http":
+		return MCPTransportHTTP
+	case MCPTransportSSE:
+		return MCPTransportSSE
+	default:
+		return strings.ToLower(strings.TrimSpace(s.Type))
+	}
+}
+
+// MCPServersFor returns the MCP servers to attach to a session of agentName
+// in cwd: every enabled server, plus the disabled ones named by the agent or
+// by the project containing cwd.
+func (c *Config) MCPServersFor(agentName, cwd string) []MCPServerConfig {
+	enabled := make(map[string]bool)
+	for _, ac := range c.Agents {
+		if ac.Name == agentName {
+			for _, name := range ac.MCPServers {
+				enabled[name] = true
+			}
+		}
+	}
+	if project := c.ProjectFor(cwd); project != nil {
+		for _, name := range project.MCPServers {
+			enabled[name] = true
+		}
+	}
+
+	var out []MCPServerConfig
+	for _, s := range c.MCPServers {
+		if strings.TrimSpace(s.Name) == "" {
+			continue
+		}
+		if s.Disabled && !enabled[s.Name] {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// ProjectFor returns the project whose Path contains cwd, preferring the
+// most specific one, or nil.
+func (c *Config) ProjectFor(cwd string) *ProjectConfig {
+	if strings.TrimSpace(cwd) == "" {
+		return nil
+	}
+	cwd = filepath.Clean(cwd)
+
+	var best *ProjectConfig
+	for i := range c.Projects {
+		p := &c.Projects[i]
+		if strings.TrimSpace(p.Path) == "" {
+			continue
+		}
+		root := filepath.Clean(expandHome(p.Path))
+		rel, err := filepath.Rel(root, cwd)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(root) > len(filepath.Clean(expandHome(best.Path))) {
+			best = p
+		}
+	}
+	return best
 }
 
 // AppSettings holds application-wide preferences.
@@ -44,6 +165,35 @@ type AppSettings struct {
 	DefaultAgent string `json:"defaultAgent"`
 	DefaultCWD   string `json:"defaultCwd"`
 	AutoApprove  bool   `json:"autoApprove"`
+
+	// RequestTimeoutSeconds bounds how long a permission or question request
+	// waits for the user; zero waits until the prompt ends.
+	RequestTimeoutSeconds int `json:"requestTimeoutSeconds,omitempty"`
+	// TimeoutDecision is applied to permission requests that time out:
+	// "deny" (the default), "allow" or "cancel".
+	TimeoutDecision string `json:"timeoutDecision,omitempty"`
+
+	// DisableBuiltinMCP stops ByteSmith from attaching its own MCP server
+	// to agent sessions.
+	DisableBuiltinMCP bool `json:"disableBuiltinMcp,omitempty"`
+
+	// ControlAPI turns on the local control API for editor plugins and
+	// scripts: a loopback "host:port" or "unix:" followed by a socket path.
+	// Empty leaves it off.
+	ControlAPI string `json:"controlApi,omitempty"`
+}
+
+// expandHome replaces a leading "~/" with the home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
 }
 
 // ConfigPath returns the default configuration file path
@@ -108,7 +258,7 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	changed := migrateCodexACPEntries(&cfg)
-	if removeUnsupportedAgents(&cfg) {
+	if removeInvalidAgents(&cfg) {
 		changed = true
 	}
 	if len(cfg.Agents) == 0 {
@@ -143,11 +293,15 @@ func migrateCodexACPEntries(cfg *Config) bool {
 	return changed
 }
 
-func removeUnsupportedAgents(cfg *Config) bool {
+// removeInvalidAgents drops entries that cannot be started: those without a
+// name, or with neither a command to spawn nor an address to attach to. Any
+// other agent is kept and treated as a generic ACP agent.
+func removeInvalidAgents(cfg *Config) bool {
 	kept := make([]AgentConfig, 0, len(cfg.Agents))
 	changed := false
 	for _, a := range cfg.Agents {
-		if _, isSupported := supportedAgentNames[a.Name]; !isSupported {
+		if strings.TrimSpace(a.Name) == "" ||
+			(strings.TrimSpace(a.Command) == "" && strings.TrimSpace(a.Address) == "") {
 			changed = true
 			continue
 		}
@@ -6,16 +6,16 @@ import (
 	"testing"
 )
 
-func TestLoadConfigMigratesCodexAndKeepsOnlySupportedAgents(t *testing.T) {
+func TestLoadConfigMigratesCodexAndKeepsCustomAgents(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.json")
 	seed := `{
 		"agents": [
 			{"name":"codex-acp","displayName":"Codex ACP","command":"codex-acp","args":[]},
-			{"name":"unsupported-a","displayName":"Unsupported A","command":"unsupported-a","args":["--acp"]},
-			{"name":"unsupported-b","displayName":"Unsupported B","command":"unsupported-b","args":["serve"]}
+			{"name":"gemini","displayName":"Gemini CLI","command":"gemini","args":["--experimental-acp"]},
+			{"name":"daemon","displayName":"Daemon","command":"","address":"tcp://127.0.0.1:7000"}
 		],
-		"settings": {"theme":"dark","defaultAgent":"unsupported-a","defaultCwd":"","autoApprove":false}
+		"settings": {"theme":"dark","defaultAgent":"gemini","defaultCwd":"","autoApprove":false}
 	}`
 	if err := os.WriteFile(path, []byte(seed), 0o644); err != nil {
 		t.Fatalf("write seed config: %v", err)
@@ -26,26 +26,26 @@ func TestLoadConfigMigratesCodexAndKeepsOnlySupportedAgents(t *testing.T) {
 		t.Fatalf("load config: %v", err)
 	}
 
-	if hasAgent(cfg.Agents, "unsupported-a") || hasAgent(cfg.Agents, "unsupported-b") {
-		t.Fatalf("unsupported agents were not removed: %#v", cfg.Agents)
+	if !hasAgent(cfg.Agents, "gemini") || !hasAgent(cfg.Agents, "daemon") {
+		t.Fatalf("custom ACP agents were removed: %#v", cfg.Agents)
 	}
 	if !hasAgent(cfg.Agents, "codex-app-server") {
 		t.Fatalf("codex-acp was not migrated: %#v", cfg.Agents)
 	}
-	if cfg.Settings.DefaultAgent != "codex-app-server" {
-		t.Fatalf("defaultAgent = %q, want codex-app-server", cfg.Settings.DefaultAgent)
+	if cfg.Settings.DefaultAgent != "gemini" {
+		t.Fatalf("defaultAgent = %q, want gemini", cfg.Settings.DefaultAgent)
 	}
 }
 
-func TestLoadConfigRepopulatesDefaultsWhenOnlyUnsupportedRemain(t *testing.T) {
+func TestLoadConfigRepopulatesDefaultsWhenOnlyInvalidRemain(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.json")
 	seed := `{
 		"agents": [
-			{"name":"unsupported-a","displayName":"Unsupported A","command":"unsupported-a","args":["--acp"]},
-			{"name":"unsupported-b","displayName":"Unsupported B","command":"unsupported-b","args":[]}
+			{"name":"no-command","displayName":"No Command","command":"","args":["--acp"]},
+			{"name":"","displayName":"No Name","command":"agent","args":[]}
 		],
-		"settings": {"theme":"dark","defaultAgent":"unsupported-a","defaultCwd":"","autoApprove":false}
+		"settings": {"theme":"dark","defaultAgent":"no-command","defaultCwd":"","autoApprove":false}
 	}`
 	if err := os.WriteFile(path, []byte(seed), 0o644); err != nil {
 		t.Fatalf("write seed config: %v", err)
@@ -59,6 +59,9 @@ func TestLoadConfigRepopulatesDefaultsWhenOnlyUnsupportedRemain(t *testing.T) {
 	if len(cfg.Agents) == 0 {
 		t.Fatal("agents should be repopulated with defaults")
 	}
+	if hasAgent(cfg.Agents, "no-command") {
+		t.Fatalf("agent without command or address was kept: %#v", cfg.Agents)
+	}
 	if !hasAgent(cfg.Agents, "opencode") || !hasAgent(cfg.Agents, "codex-app-server") {
 		t.Fatalf("default agents not restored: %#v", cfg.Agents)
 	}
@@ -75,3 +78,47 @@ func hasAgent(agents []AgentConfig, name string) bool {
 	}
 	return false
 }
+
+func TestMCPServersForHonorsEnableLists(t *testing.T) {
+	cfg := &Config{
+		Agents: []AgentConfig{
+			{Name: "gemini", MCPServers: []string{"browser"}},
+			{Name: "opencode"},
+		},
+		MCPServers: []MCPServerConfig{
+			{Name: "fs", Command: "mcp-fs"},
+			{Name: "browser", Type: "http", URL: "http://localhost:9000/mcp", Disabled: true},
+			{Name: "db", Command: "mcp-db", Disabled: true},
+		},
+		Projects: []ProjectConfig{
+			{Path: "/work", MCPServers: []string{"browser"}},
+			{Path: "/work/api", MCPServers: []string{"db"}},
+		},
+	}
+
+	cases := []struct {
+		agent, cwd string
+		want       []string
+	}{
+		{"opencode", "/tmp", []string{"fs"}},
+		{"gemini", "/tmp", []string{"fs", "browser"}},
+		{"opencode", "/work/site", []string{"fs", "browser"}},
+		{"opencode", "/work/api/cmd", []string{"fs", "db"}},
+		{"opencode", "/workspace", []string{"fs"}},
+	}
+	for _, tc := range cases {
+		got := cfg.MCPServersFor(tc.agent, tc.cwd)
+		names := make([]string, 0, len(got))
+		for _, s := range got {
+			names = append(names, s.Name)
+		}
+		if len(names) != len(tc.want) {
+			t.Fatalf("MCPServersFor(%q, %q) = %v, want %v", tc.agent, tc.cwd, names, tc.want)
+		}
+		for i := range names {
+			if names[i] != tc.want[i] {
+				t.Fatalf("MCPServersFor(%q, %q) = %v, want %v", tc.agent, tc.cwd, names, tc.want)
+			}
+		}
+	}
+}
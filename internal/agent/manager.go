@@ -1,15 +1,23 @@
 package agent
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"sync"
+	"time"
 
+	"bytesmith/internal/acp"
 	"bytesmith/internal/agentclient"
 	"bytesmith/internal/integrator"
 
 	"github.com/google/uuid"
 )
 
+// capabilityProbeTimeout bounds each request used to probe an agent for
+// optional methods it did not advertise.
+const capabilityProbeTimeout = 5 * time.Second
+
 // Connection represents a live connection to an agent runtime.
 type Connection struct {
 	ID           string
@@ -17,7 +25,28 @@ type Connection struct {
 	Client       agentclient.Client
 	Sessions     []string
 	IntegratorID string
+	Integrator   integrator.AgentServer
 	release      func()
+
+	// capabilities starts from the integrator table merged with the ACP
+	// handshake and is narrowed as calls reveal unsupported methods.
+	capabilities integrator.Capabilities
+	capsMu       sync.RWMutex
+}
+
+// Capabilities returns the capabilities negotiated for this connection.
+func (c *Connection) Capabilities() integrator.Capabilities {
+	c.capsMu.RLock()
+	defer c.capsMu.RUnlock()
+	return c.capabilities
+}
+
+// UpdateCapabilities applies fn to the connection's capabilities, e.g. to
+// turn off a method the agent answered with method-not-found.
+func (c *Connection) UpdateCapabilities(fn func(*integrator.Capabilities)) {
+	c.capsMu.Lock()
+	fn(&c.capabilities)
+	c.capsMu.Unlock()
 }
 
 // Manager handles the lifecycle of multiple agent connections.
@@ -73,6 +102,15 @@ func (m *Manager) Connect(agentName string, cwd string) (*Connection, error) {
 			}
 			return nil, fmt.Errorf("agent: initialize opencode runtime: %w", err)
 		}
+	} else if agent.Address != "" {
+		transport, terr := acp.NewTransportForAddress(agent.Address)
+		if terr != nil {
+			return nil, fmt.Errorf("agent: %s: %w", agentName, terr)
+		}
+		client, err = agentclient.NewACPWithTransport(transport)
+		if err != nil {
+			return nil, fmt.Errorf("agent: attach %s at %s: %w", agentName, agent.Address, err)
+		}
 	} else {
 		env := make([]string, 0, len(agent.Env))
 		for k, v := range agent.Env {
@@ -84,13 +122,20 @@ func (m *Manager) Connect(agentName string, cwd string) (*Connection, error) {
 		}
 	}
 
+	init := client.InitializeResult()
+	server := integrator.ForAgent(agent.Name, init)
 	conn := &Connection{
 		ID:           uuid.New().String(),
 		Agent:        agent,
 		Client:       client,
 		Sessions:     make([]string, 0),
-		IntegratorID: integrator.ForAgent(agent.Name).ID(),
+		IntegratorID: server.ID(),
+		Integrator:   server,
 		release:      release,
+		capabilities: integrator.Negotiate(server.Capabilities(), init),
+	}
+	if server.ID() == integrator.GenericID {
+		probeCapabilities(conn, cwd)
 	}
 
 	m.mu.Lock()
@@ -100,6 +145,28 @@ func (m *Manager) Connect(agentName string, cwd string) (*Connection, error) {
 	return conn, nil
 }
 
+// probeCapabilities asks a generic ACP agent for optional methods it did not
+// advertise. Only a definite method-not-found turns a capability off, and only
+// a successful call turns one on; other failures leave it untouched.
+func probeCapabilities(conn *Connection, cwd string) {
+	if conn.Capabilities().ListSessions {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), capabilityProbeTimeout)
+	defer cancel()
+
+	_, err := conn.Client.ListSessions(ctx, cwd, "")
+	switch {
+	case err == nil:
+		conn.UpdateCapabilities(func(c *integrator.Capabilities) { c.ListSessions = true })
+	case acp.IsMethodNotFound(err):
+		conn.UpdateCapabilities(func(c *integrator.Capabilities) { c.ListSessions = false })
+	default:
+		log.Printf("agent: probe session/list on %s: %v", conn.Agent.Name, err)
+	}
+}
+
 // Disconnect gracefully shuts down a single connection by ID.
 func (m *Manager) Disconnect(connectionID string) error {
 	m.mu.Lock()
@@ -10,24 +10,41 @@ import (
 // ACPClient is a thin adapter over internal/acp.Client.
 type ACPClient struct {
 	client    *acp.Client
-	transport *acp.StdioTransport
+	transport acp.Transport
+	init      *acp.InitializeResult
 }
 
 var _ Client = (*ACPClient)(nil)
 
+// NewACP spawns an ACP agent as a child process and performs the handshake.
 func NewACP(command string, args []string, env []string, cwd string) (*ACPClient, error) {
-	transport := acp.NewStdioTransport(command, args, env, cwd)
+	return NewACPWithTransport(acp.NewStdioTransport(command, args, env, cwd))
+}
+
+// NewACPWithTransport performs the ACP handshake over an arbitrary transport,
+// e.g. a socket to an agent that is already running.
+func NewACPWithTransport(transport acp.Transport) (*ACPClient, error) {
 	client := acp.NewClient(transport)
-	if _, err := client.Initialize(context.Background()); err != nil {
+	init, err := client.Initialize(context.Background())
+	if err != nil {
 		_ = transport.Close()
 		return nil, fmt.Errorf("initialize acp client: %w", err)
 	}
 	return &ACPClient{
 		client:    client,
 		transport: transport,
+		init:      init,
 	}, nil
 }
 
+func (c *ACPClient) InitializeResult() *acp.InitializeResult {
+	return c.init
+}
+
+func (c *ACPClient) Authenticate(ctx context.Context, methodID string) error {
+	return c.client.Authenticate(ctx, methodID)
+}
+
 func (c *ACPClient) Close() error {
 	return c.client.Close()
 }
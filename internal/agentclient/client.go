@@ -12,6 +12,11 @@ type Client interface {
 	Close() error
 	StderrCh() <-chan string
 
+	// InitializeResult returns what the agent reported during the ACP
+	// handshake, or nil for runtimes that do not perform one.
+	InitializeResult() *acp.InitializeResult
+	Authenticate(ctx context.Context, methodID string) error
+
 	NewSession(ctx context.Context, cwd string, mcpServers []acp.MCPServer) (*acp.SessionNewResult, error)
 	LoadSession(ctx context.Context, sessionID, cwd string, mcpServers []acp.MCPServer) error
 	ResumeSession(ctx context.Context, sessionID, cwd string, mcpServers []acp.MCPServer) (*acp.SessionResumeResult, error)
@@ -51,12 +51,20 @@ type OpenCodeClient struct {
 	sessionModel map[string]openCodeModelRef
 	sessionMode  map[string]string
 
+	// mcpRegistered maps directory and server name to the config last
+	// registered through POST /mcp, so servers are added once per instance.
+	mcpMu         sync.Mutex
+	mcpRegistered map[string]string
+
 	promptMu      sync.Mutex
 	promptWaiters map[string][]chan string
 }
 
 var _ Client = (*OpenCodeClient)(nil)
 
+// maxResponseBytes caps the responses read from the OpenCode server.
+const maxResponseBytes = 4 * 1024 * 1024
+
 func NewOpenCode(baseURL, defaultCWD string) (*OpenCodeClient, error) {
 	trimmed := strings.TrimSpace(baseURL)
 	if trimmed == "" {
@@ -88,6 +96,7 @@ func NewOpenCode(baseURL, defaultCWD string) (*OpenCodeClient, error) {
 		toolCallSeen:  make(map[string]map[string]bool),
 		sessionModel:  make(map[string]openCodeModelRef),
 		sessionMode:   make(map[string]string),
+		mcpRegistered: make(map[string]string),
 		promptWaiters: make(map[string][]chan string),
 	}
 
@@ -106,7 +115,19 @@ func (c *OpenCodeClient) StderrCh() <-chan string {
 	return c.stderrCh
 }
 
-func (c *OpenCodeClient) NewSession(ctx context.Context, cwd string, _ []acp.MCPServer) (*acp.SessionNewResult, error) {
+func (c *OpenCodeClient) InitializeResult() *acp.InitializeResult {
+	return nil
+}
+
+// Authenticate is not part of the OpenCode server API; providers are logged in
+// with `opencode auth login` outside ByteSmith.
+func (c *OpenCodeClient) Authenticate(_ context.Context, _ string) error {
+	return fmt.Errorf("opencode: authentication is managed by `opencode auth login`")
+}
+
+func (c *OpenCodeClient) NewSession(ctx context.Context, cwd string, mcpServers []acp.MCPServer) (*acp.SessionNewResult, error) {
+	c.registerMCPServers(ctx, resolveSessionDir(cwd, "", c.defaultCWD), mcpServers)
+
 	var resp openCodeSession
 	if err := c.requestJSON(ctx, http.MethodPost, "/session", directoryQuery(cwd), map[string]any{}, &resp); err != nil {
 		return nil, err
@@ -130,13 +151,14 @@ func (c *OpenCodeClient) NewSession(ctx context.Context, cwd string, _ []acp.MCP
 	}, nil
 }
 
-func (c *OpenCodeClient) LoadSession(ctx context.Context, sessionID, cwd string, _ []acp.MCPServer) error {
+func (c *OpenCodeClient) LoadSession(ctx context.Context, sessionID, cwd string, mcpServers []acp.MCPServer) error {
 	var resp openCodeSession
 	path := fmt.Sprintf("/session/%s", url.PathEscape(sessionID))
 	if err := c.requestJSON(ctx, http.MethodGet, path, directoryQuery(cwd), nil, &resp); err != nil {
 		return err
 	}
 	finalCWD := resolveSessionDir(cwd, resp.Directory, c.defaultCWD)
+	c.registerMCPServers(ctx, finalCWD, mcpServers)
 	c.trackSession(sessionID, finalCWD)
 	model, modeID, okModel, okMode := c.loadSessionState(ctx, sessionID, finalCWD)
 	if okModel {
@@ -152,8 +174,8 @@ func (c *OpenCodeClient) LoadSession(ctx context.Context, sessionID, cwd string,
 	return nil
 }
 
-func (c *OpenCodeClient) ResumeSession(ctx context.Context, sessionID, cwd string, _ []acp.MCPServer) (*acp.SessionResumeResult, error) {
-	if err := c.LoadSession(ctx, sessionID, cwd, nil); err != nil {
+func (c *OpenCodeClient) ResumeSession(ctx context.Context, sessionID, cwd string, mcpServers []acp.MCPServer) (*acp.SessionResumeResult, error) {
+	if err := c.LoadSession(ctx, sessionID, cwd, mcpServers); err != nil {
 		return nil, err
 	}
 	finalCWD := c.sessionDirectory(sessionID)
@@ -182,6 +204,69 @@ func (c *OpenCodeClient) ResumeSession(ctx context.Context, sessionID, cwd strin
 	}, nil
 }
 
+// registerMCPServers adds MCP servers to the OpenCode instance serving dir
+// through POST /mcp. Failures are logged: a missing MCP server should not
+// prevent the session from opening.
+func (c *OpenCodeClient) registerMCPServers(ctx context.Context, dir string, servers []acp.MCPServer) {
+	for _, server := range servers {
+		config := openCodeMCPConfig(server)
+		fingerprint, _ := json.Marshal(config)
+		key := dir + "\x00" + server.Name
+
+		c.mcpMu.Lock()
+		registered := c.mcpRegistered[key] == string(fingerprint)
+		c.mcpMu.Unlock()
+		if registered {
+			continue
+		}
+
+		payload := map[string]any{
+			"name":   server.Name,
+			"config": config,
+		}
+		if err := c.requestJSON(ctx, http.MethodPost, "/mcp", directoryQuery(dir), payload, nil); err != nil {
+			log.Printf("opencode: failed to add MCP server %q: %v", server.Name, err)
+			continue
+		}
+
+		c.mcpMu.Lock()
+		c.mcpRegistered[key] = string(fingerprint)
+		c.mcpMu.Unlock()
+	}
+}
+
+// openCodeMCPConfig converts an MCP server into OpenCode's local or remote
+// MCP config.
+func openCodeMCPConfig(server acp.MCPServer) map[string]any {
+	if server.Type != "" {
+		config := map[string]any{
+			"type": "remote",
+			"url":  server.URL,
+		}
+		if len(server.Headers) > 0 {
+			headers := make(map[string]string, len(server.Headers))
+			for _, h := range server.Headers {
+				headers[h.Name] = h.Value
+			}
+			config["headers"] = headers
+		}
+		return config
+	}
+
+	config := map[string]any{
+		"type":    "local",
+		"command": append([]string{server.Command}, server.Args...),
+	}
+	if len(server.Env) > 0 {
+		env := make(map[string]string, len(server.Env))
+		for _, e := range server.Env {
+			env[e.Name] = e.Value
+		}
+		config["environment"] = env
+	}
+	return config
+}
+
 func (c *OpenCodeClient) ListSessions(ctx context.Context, cwd, _ string) (*acp.SessionListResult, error) {
 	var sessions []openCodeSession
 	if err := c.requestJSON(ctx, http.MethodGet, "/session", directoryQuery(cwd), nil, &sessions); err != nil {
@@ -579,18 +664,27 @@ func (c *OpenCodeClient) handleToolPart(part openCodePart) {
 		return
 	}
 
-	updateType := c.nextToolUpdateType(part.SessionID, callID)
+	update := openCodeToolUpdate(part)
+	update.Type = c.nextToolUpdateType(part.SessionID, callID)
 	if part.State.Status == "pending" {
-		updateType = acp.UpdateToolCall
+		update.Type = acp.UpdateToolCall
 	}
 
+	c.emitSessionUpdate(acp.SessionUpdateParams{
+		SessionID: part.SessionID,
+		Update:    update,
+	})
+}
+
+// openCodeToolUpdate converts a tool part into a tool call update. The
+// caller sets the update type.
+func openCodeToolUpdate(part openCodePart) acp.SessionUpdate {
 	kind := mapToolKind(part.Tool)
 	status := normalizeToolStatus(part.State.Status)
 	title := nonEmpty(part.State.Title, part.Tool, "Tool")
 
 	update := acp.SessionUpdate{
-		Type:       updateType,
-		ToolCallID: callID,
+		ToolCallID: nonEmpty(part.CallID, part.ID),
 		Title:      title,
 		Kind:       kind,
 		Status:     status,
@@ -655,10 +749,7 @@ func (c *OpenCodeClient) handleToolPart(part openCodePart) {
 		update.Status = "pending"
 	}
 
-	c.emitSessionUpdate(acp.SessionUpdateParams{
-		SessionID: part.SessionID,
-		Update:    update,
-	})
+	return update
 }
 
 func (c *OpenCodeClient) handlePermissionAsked(raw json.RawMessage) {
@@ -673,6 +764,7 @@ func (c *OpenCodeClient) handlePermissionAsked(raw json.RawMessage) {
 		nonEmpty(perm.Tool.CallID, perm.ID),
 		nonEmpty(asString(perm.Metadata["title"]), perm.Permission, "Permission"),
 		perm.Permission,
+		permissionRawInput(perm.Metadata, perm.Patterns),
 	)
 }
 
@@ -682,16 +774,45 @@ func (c *OpenCodeClient) handlePermissionUpdated(raw json.RawMessage) {
 		log.Printf("opencode: invalid permission.updated: %v", err)
 		return
 	}
+	var patterns []string
+	switch p := perm.Pattern.(type) {
+	case string:
+		patterns = []string{p}
+	case []any:
+		for _, v := range p {
+			if s := asString(v); s != "" {
+				patterns = append(patterns, s)
+			}
+		}
+	}
 	c.handlePermission(
 		perm.SessionID,
 		perm.ID,
 		nonEmpty(perm.CallID, perm.ID),
 		nonEmpty(perm.Title, perm.Type, "Permission"),
 		perm.Type,
+		permissionRawInput(perm.Metadata, patterns),
 	)
 }
 
-func (c *OpenCodeClient) handlePermission(sessionID, permissionID, toolCallID, title, kind string) {
+// permissionRawInput exposes what OpenCode tells us about a permission
+// (metadata such as the command or file path, and the glob patterns the
+// approval would cover) as the ACP rawInput of the tool call.
+func permissionRawInput(metadata map[string]any, patterns []string) json.RawMessage {
+	input := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		input[k] = v
+	}
+	if len(patterns) > 0 {
+		input["patterns"] = patterns
+	}
+	if len(input) == 0 {
+		return nil
+	}
+	return marshalRaw(input)
+}
+
+func (c *OpenCodeClient) handlePermission(sessionID, permissionID, toolCallID, title, kind string, rawInput json.RawMessage) {
 	if strings.TrimSpace(sessionID) == "" || strings.TrimSpace(permissionID) == "" || !c.sessionTracked(sessionID) {
 		return
 	}
@@ -711,6 +832,7 @@ func (c *OpenCodeClient) handlePermission(sessionID, permissionID, toolCallID, t
 				Title:      title,
 				Kind:       mapToolKind(kind),
 				Status:     "pending",
+				RawInput:   rawInput,
 			},
 			Options: []acp.PermissionOption{
 				{OptionID: "approved", Name: "Allow once", Kind: "allow_once"},
@@ -1196,6 +1318,11 @@ func (c *OpenCodeClient) signalPromptDone(sessionID, reason string) {
 }
 
 func (c *OpenCodeClient) requestJSON(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	return c.requestJSONLimit(ctx, method, path, query, body, out, maxResponseBytes)
+}
+
+// requestJSONLimit is requestJSON reading at most limit bytes of response.
+func (c *OpenCodeClient) requestJSONLimit(ctx context.Context, method, path string, query url.Values, body any, out any, limit int64) error {
 	fullURL := c.baseURL + path
 	u, err := url.Parse(fullURL)
 	if err != nil {
@@ -1234,7 +1361,7 @@ func (c *OpenCodeClient) requestJSON(ctx context.Context, method, path string, q
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, limit))
 	if err != nil {
 		return fmt.Errorf("opencode: read response: %w", err)
 	}
@@ -1557,6 +1684,7 @@ type openCodePart struct {
 	Text      string            `json:"text"`
 	Tool      string            `json:"tool"`
 	Ignored   bool              `json:"ignored"`
+	Synthetic bool              `json:"synthetic"`
 	Time      *openCodePartTime `json:"time"`
 	State     openCodeToolState `json:"state"`
 }
@@ -1567,18 +1695,20 @@ type openCodePartTime struct {
 }
 
 type openCodeToolState struct {
-	Status   string         `json:"status"`
-	Title    string         `json:"title"`
-	Output   string         `json:"output"`
-	Error    string         `json:"error"`
-	Input    map[string]any `json:"input"`
-	Metadata map[string]any `json:"metadata"`
+	Status   string            `json:"status"`
+	Title    string            `json:"title"`
+	Output   string            `json:"output"`
+	Error    string            `json:"error"`
+	Input    map[string]any    `json:"input"`
+	Metadata map[string]any    `json:"metadata"`
+	Time     *openCodePartTime `json:"time"`
 }
 
 type openCodePermissionAsked struct {
 	ID         string         `json:"id"`
 	SessionID  string         `json:"sessionID"`
 	Permission string         `json:"permission"`
+	Patterns   []string       `json:"patterns"`
 	Metadata   map[string]any `json:"metadata"`
 	Tool       struct {
 		CallID string `json:"callID"`
@@ -1586,11 +1716,13 @@ type openCodePermissionAsked struct {
 }
 
 type openCodePermissionUpdated struct {
-	ID        string `json:"id"`
-	CallID    string `json:"callID"`
-	SessionID string `json:"sessionID"`
-	Title     string `json:"title"`
-	Type      string `json:"type"`
+	ID        string         `json:"id"`
+	CallID    string         `json:"callID"`
+	SessionID string         `json:"sessionID"`
+	Title     string         `json:"title"`
+	Type      string         `json:"type"`
+	Pattern   any            `json:"pattern"`
+	Metadata  map[string]any `json:"metadata"`
 }
 
 type openCodeQuestionAsked struct {
@@ -1622,6 +1754,7 @@ type openCodeSession struct {
 	Directory string `json:"directory"`
 	Title     string `json:"title"`
 	Time      struct {
+		Created float64 `json:"created"`
 		Updated float64 `json:"updated"`
 	} `json:"time"`
 }
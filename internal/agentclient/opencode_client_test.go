@@ -237,6 +237,56 @@ func TestNewSessionReturnsModesFromAgents(t *testing.T) {
 	}
 }
 
+func TestNewSessionRegistersMCPServersOnce(t *testing.T) {
+	var added []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/mcp" && r.Method == http.MethodPost:
+			if got := r.URL.Query().Get("directory"); got != "/repo" {
+				t.Errorf("directory = %q, want /repo", got)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decode /mcp body: %v", err)
+			}
+			added = append(added, body)
+			_, _ = w.Write([]byte(`{}`))
+		case r.URL.Path == "/session" && r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":"s1","directory":"/repo"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	servers := []acp.MCPServer{
+		{Name: "fs", Command: "mcp-fs", Args: []string{"--root", "."}, Env: []acp.EnvVariable{{Name: "DEBUG", Value: "1"}}},
+		{Name: "docs", Type: acp.MCPServerTypeHTTP, URL: "http://localhost:9000/mcp"},
+	}
+
+	client := newTestOpenCodeClient(srv.URL)
+	for i := 0; i < 2; i++ {
+		if _, err := client.NewSession(context.Background(), "/repo", servers); err != nil {
+			t.Fatalf("new session should succeed: %v", err)
+		}
+	}
+
+	if len(added) != 2 {
+		t.Fatalf("expected each MCP server to be added once, got %d requests", len(added))
+	}
+	local, _ := added[0]["config"].(map[string]any)
+	if added[0]["name"] != "fs" || local["type"] != "local" {
+		t.Fatalf("unexpected local MCP config: %#v", added[0])
+	}
+	if cmd, _ := local["command"].([]any); len(cmd) != 3 || cmd[0] != "mcp-fs" {
+		t.Fatalf("unexpected local command: %#v", local["command"])
+	}
+	remote, _ := added[1]["config"].(map[string]any)
+	if remote["type"] != "remote" || remote["url"] != "http://localhost:9000/mcp" {
+		t.Fatalf("unexpected remote MCP config: %#v", added[1])
+	}
+}
+
 func TestSetModeStoresSelection(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/agent" && r.Method == http.MethodGet {
@@ -376,6 +426,57 @@ func newTestOpenCodeClient(baseURL string) *OpenCodeClient {
 		toolCallSeen:  make(map[string]map[string]bool),
 		sessionModel:  make(map[string]openCodeModelRef),
 		sessionMode:   make(map[string]string),
+		mcpRegistered: make(map[string]string),
 		promptWaiters: make(map[string][]chan string),
 	}
 }
+
+func TestSessionMessagesConvertsParts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/s1/message" || r.URL.Query().Get("directory") != "/repo" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"info":{"id":"msg1","role":"user","time":{"created":1735732800000}},
+			 "parts":[
+				{"id":"p1","type":"text","text":"fix the bug"},
+				{"id":"p2","type":"text","text":"<file contents>","synthetic":true}
+			 ]},
+			{"info":{"id":"msg2","role":"assistant","time":{"created":1735732801000}},
+			 "parts":[
+				{"id":"p3","type":"step-start"},
+				{"id":"p4","type":"reasoning","text":"thinking","time":{"start":1735732802000}},
+				{"id":"p5","type":"tool","callID":"call1","tool":"edit","state":{
+					"status":"completed","title":"main.go","output":"ok",
+					"input":{"filePath":"/repo/main.go","oldString":"a","newString":"b"},
+					"time":{"start":1735732803000}}},
+				{"id":"p6","type":"text","text":"done"}
+			 ]}
+		]`))
+	}))
+	defer srv.Close()
+
+	client := newTestOpenCodeClient(srv.URL)
+	messages, err := client.SessionMessages(context.Background(), "s1", "/repo")
+	if err != nil {
+		t.Fatalf("session messages: %v", err)
+	}
+	if len(messages) != 2 || len(messages[0].Parts) != 1 || len(messages[1].Parts) != 3 {
+		t.Fatalf("unexpected messages: %#v", messages)
+	}
+	if !messages[0].Created.Equal(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("created = %v", messages[0].Created)
+	}
+
+	tool := messages[1].Parts[1]
+	if tool.ToolCall == nil || tool.ToolCall.ToolCallID != "call1" || tool.ToolCall.Status != "completed" || tool.ToolCall.Kind != "edit" {
+		t.Fatalf("tool part = %#v", tool)
+	}
+	if len(tool.ToolCall.ToolContent) != 2 || tool.ToolCall.ToolContent[1].Type != "diff" {
+		t.Fatalf("tool content = %#v", tool.ToolCall.ToolContent)
+	}
+	if !tool.Time.Equal(time.Date(2025, 1, 1, 12, 0, 3, 0, time.UTC)) {
+		t.Fatalf("tool time = %v", tool.Time)
+	}
+}
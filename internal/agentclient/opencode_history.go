@@ -0,0 +1,137 @@
+package agentclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bytesmith/internal/acp"
+)
+
+// maxHistoryBytes caps the message history read for one session.
+const maxHistoryBytes = 64 * 1024 * 1024
+
+// OpenCodeSession is a session stored by the OpenCode server.
+type OpenCodeSession struct {
+	ID        string
+	Directory string
+	Title     string
+	Created   time.Time
+	Updated   time.Time
+}
+
+// OpenCodeMessage is a stored OpenCode message with the parts ByteSmith
+// keeps: text, reasoning and tool calls.
+type OpenCodeMessage struct {
+	ID      string
+	Role    string // "user" or "assistant"
+	Created time.Time
+	Parts   []OpenCodeMessagePart
+}
+
+// OpenCodeMessagePart is one part of a stored message.
+type OpenCodeMessagePart struct {
+	ID   string
+	Type string // "text", "reasoning" or "tool"
+	Text string
+	// Time is when the part started, or the zero time if unknown.
+	Time time.Time
+	// ToolCall is set for tool parts, as the final update of the call.
+	ToolCall *acp.SessionUpdate
+}
+
+type openCodeStoredMessage struct {
+	Info struct {
+		ID   string `json:"id"`
+		Role string `json:"role"`
+		Time struct {
+			Created float64 `json:"created"`
+		} `json:"time"`
+	} `json:"info"`
+	Parts []openCodePart `json:"parts"`
+}
+
+// StoredSessions lists the sessions the OpenCode server keeps for cwd, or
+// for its current project when cwd is empty.
+func (c *OpenCodeClient) StoredSessions(ctx context.Context, cwd string) ([]OpenCodeSession, error) {
+	var sessions []openCodeSession
+	if err := c.requestJSON(ctx, http.MethodGet, "/session", directoryQuery(cwd), nil, &sessions); err != nil {
+		return nil, err
+	}
+
+	result := make([]OpenCodeSession, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, OpenCodeSession{
+			ID:        s.ID,
+			Directory: s.Directory,
+			Title:     s.Title,
+			Created:   openCodeTime(s.Time.Created),
+			Updated:   openCodeTime(s.Time.Updated),
+		})
+	}
+	return result, nil
+}
+
+// SessionMessages returns the stored messages of a session, oldest first.
+// Synthetic and ignored text is dropped, as are part types ByteSmith does
+// not show.
+func (c *OpenCodeClient) SessionMessages(ctx context.Context, sessionID, cwd string) ([]OpenCodeMessage, error) {
+	path := fmt.Sprintf("/session/%s/message", url.PathEscape(sessionID))
+	var stored []openCodeStoredMessage
+	if err := c.requestJSONLimit(ctx, http.MethodGet, path, directoryQuery(cwd), nil, &stored, maxHistoryBytes); err != nil {
+		return nil, err
+	}
+
+	result := make([]OpenCodeMessage, 0, len(stored))
+	for _, m := range stored {
+		msg := OpenCodeMessage{
+			ID:      m.Info.ID,
+			Role:    strings.ToLower(strings.TrimSpace(m.Info.Role)),
+			Created: openCodeTime(m.Info.Time.Created),
+		}
+		for _, p := range m.Parts {
+			part := OpenCodeMessagePart{ID: p.ID, Type: p.Type, Time: msg.Created}
+			if p.Time != nil && p.Time.Start > 0 {
+				part.Time = openCodeTime(p.Time.Start)
+			}
+			switch p.Type {
+			case "text", "reasoning":
+				if p.Ignored || p.Synthetic || strings.TrimSpace(p.Text) == "" {
+					continue
+				}
+				part.Text = p.Text
+			case "tool":
+				if nonEmpty(p.CallID, p.ID) == "" {
+					continue
+				}
+				update := openCodeToolUpdate(p)
+				update.Type = acp.UpdateToolCall
+				part.ToolCall = &update
+				if p.State.Time != nil && p.State.Time.Start > 0 {
+					part.Time = openCodeTime(p.State.Time.Start)
+				}
+			default:
+				continue
+			}
+			msg.Parts = append(msg.Parts, part)
+		}
+		result = append(result, msg)
+	}
+	return result, nil
+}
+
+// openCodeTime converts an OpenCode timestamp, in milliseconds since the
+// epoch, to a time. Values small enough to be seconds are read as seconds.
+func openCodeTime(v float64) time.Time {
+	switch {
+	case v <= 0:
+		return time.Time{}
+	case v < 1e11:
+		return time.Unix(int64(v), 0).UTC()
+	default:
+		return time.UnixMilli(int64(v)).UTC()
+	}
+}
@@ -2,6 +2,7 @@ package backend
 
 import (
 	"bytesmith/internal/agent"
+	"bytesmith/internal/integrator"
 )
 
 // ---------------------------------------------------------------------------
@@ -22,7 +23,7 @@ func (a *App) ListAvailableAgents() []AgentInfo {
 			DisplayName: ac.DisplayName,
 			Command:     ac.Command,
 			Description: ac.Description,
-			Installed:   agent.IsInstalled(ac.Command),
+			Installed:   ac.Address != "" || agent.IsInstalled(ac.Command),
 		})
 	}
 
@@ -72,6 +73,10 @@ func (a *App) ConnectAgent(agentName, cwd string) (string, error) {
 
 // DisconnectAgent gracefully shuts down a connection by ID.
 func (a *App) DisconnectAgent(connectionID string) error {
+	a.cancelConnectionRequests(connectionID)
+	a.dropQueuedPrompts(connectionID)
+	a.dropPendingAuth(connectionID)
+	a.revokeBuiltinMCP(a.manager.GetConnection(connectionID))
 	return a.manager.Disconnect(connectionID)
 }
 
@@ -88,11 +93,60 @@ func (a *App) ListConnections() []ConnectionInfo {
 			DisplayName: c.Agent.DisplayName,
 			Sessions:    sessions,
 			Integrator:  c.IntegratorID,
+
+			Capabilities: toConnectionCapabilitiesInfo(c.Capabilities()),
+			AuthMethods:  connectionAuthMethods(c),
 		})
 	}
 	return result
 }
 
+func toConnectionCapabilitiesInfo(caps integrator.Capabilities) ConnectionCapabilitiesInfo {
+	return ConnectionCapabilitiesInfo{
+		ListSessions:    caps.ListSessions,
+		LoadSession:     caps.LoadSession,
+		ResumeSession:   caps.ResumeSession,
+		SetMode:         caps.SetMode,
+		SetModel:        caps.SetModel,
+		SetConfigOption: caps.SetConfigOption,
+		ImageInput:      caps.ImageInput,
+		AudioInput:      caps.AudioInput,
+		EmbeddedContext: caps.EmbeddedContext,
+		MCPHTTP:         caps.MCPHTTP,
+		MCPSSE:          caps.MCPSSE,
+	}
+}
+
+// trackSession records a session opened on conn in the store and in the
+// in-memory caches keyed by session.
+func (a *App) trackSession(conn *agent.Connection, sessionID, cwd string) {
+	a.sessions.Create(sessionID, conn.Agent.Name, conn.ID, cwd)
+	appendSessionIfMissing(conn, sessionID)
+	a.fs.RegisterSession(sessionID, cwd, conn.Agent.Name)
+
+	a.sessionCWDsMu.Lock()
+	a.sessionCWDs[sessionID] = cwd
+	a.sessionCWDsMu.Unlock()
+
+	a.applyRemoteTitle(sessionID)
+}
+
+// sessionCWD returns the working directory a session was opened with.
+func (a *App) sessionCWD(sessionID string) string {
+	a.sessionCWDsMu.RLock()
+	cwd, ok := a.sessionCWDs[sessionID]
+	a.sessionCWDsMu.RUnlock()
+	if ok {
+		return cwd
+	}
+	if a.sessions != nil {
+		if rec := a.sessions.GetSummary(sessionID); rec != nil {
+			return rec.CWD
+		}
+	}
+	return ""
+}
+
 func appendSessionIfMissing(conn *agent.Connection, sessionID string) {
 	for _, existing := range conn.Sessions {
 		if existing == sessionID {
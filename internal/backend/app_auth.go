@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/events"
+)
+
+// authenticateTimeout bounds an AuthenticateAgent call. Logins usually go
+// through a browser, so this is generous.
+const authenticateTimeout = 10 * time.Minute
+
+// ---------------------------------------------------------------------------
+// Agent authentication
+// ---------------------------------------------------------------------------
+
+// AuthenticateAgent runs one of the connection's advertised auth methods.
+// If a session operation failed earlier because the agent required
+// authentication, it is retried and the resulting session ID is returned.
+func (a *App) AuthenticateAgent(connectionID, methodID string) (string, error) {
+	conn := a.manager.GetConnection(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection %q not found", connectionID)
+	}
+
+	methods := connectionAuthMethods(conn)
+	if len(methods) > 0 && !hasAuthMethod(methods, methodID) {
+		return "", fmt.Errorf("agent %q does not offer auth method %q", conn.Agent.Name, methodID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authenticateTimeout)
+	defer cancel()
+
+	if err := conn.Client.Authenticate(ctx, methodID); err != nil {
+		return "", err
+	}
+
+	a.pendingAuthMu.Lock()
+	retry := a.pendingAuth[connectionID]
+	delete(a.pendingAuth, connectionID)
+	a.pendingAuthMu.Unlock()
+
+	events.Emit(a.bus, TopicAgentAuthenticated, AgentAuthenticatedEvent{
+		ConnectionID: connectionID,
+		MethodID:     methodID,
+	})
+
+	if retry == nil {
+		return "", nil
+	}
+	return retry()
+}
+
+// handleAuthRequired inspects a failed session operation. When the agent
+// reports that authentication is required, the operation is remembered for
+// AuthenticateAgent to retry and "agent:auth-required" is emitted with the
+// available methods. The returned error is what the caller should surface.
+func (a *App) handleAuthRequired(conn *agent.Connection, err error, retry func() (string, error)) error {
+	if !acp.IsAuthRequired(err) {
+		return err
+	}
+
+	a.pendingAuthMu.Lock()
+	a.pendingAuth[conn.ID] = retry
+	a.pendingAuthMu.Unlock()
+
+	events.Emit(a.bus, TopicAgentAuthRequired, AuthRequiredInfo{
+		ConnectionID: conn.ID,
+		AgentName:    conn.Agent.Name,
+		Methods:      connectionAuthMethods(conn),
+		Error:        err.Error(),
+	})
+
+	return fmt.Errorf("agent %q requires authentication: %w", conn.Agent.Name, err)
+}
+
+// dropPendingAuth forgets the operation waiting for a connection to
+// authenticate.
+func (a *App) dropPendingAuth(connectionID string) {
+	a.pendingAuthMu.Lock()
+	delete(a.pendingAuth, connectionID)
+	a.pendingAuthMu.Unlock()
+}
+
+func connectionAuthMethods(conn *agent.Connection) []AuthMethodInfo {
+	init := conn.Client.InitializeResult()
+	if init == nil {
+		return []AuthMethodInfo{}
+	}
+
+	methods := make([]AuthMethodInfo, 0, len(init.AuthMethods))
+	for _, m := range init.AuthMethods {
+		name := m.Name
+		if name == "" {
+			name = m.ID
+		}
+		methods = append(methods, AuthMethodInfo{
+			ID:          m.ID,
+			Name:        name,
+			Description: m.Description,
+		})
+	}
+	return methods
+}
+
+func hasAuthMethod(methods []AuthMethodInfo, methodID string) bool {
+	for _, m := range methods {
+		if m.ID == methodID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,318 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/agentclient"
+	"bytesmith/internal/events"
+	"bytesmith/internal/mcpserver"
+)
+
+// ---------------------------------------------------------------------------
+// Built-in MCP server
+// ---------------------------------------------------------------------------
+
+// startBuiltinMCP starts the built-in MCP server unless it is disabled in
+// the settings.
+func (a *App) startBuiltinMCP() {
+	if a.config != nil && a.config.Settings.DisableBuiltinMCP {
+		return
+	}
+	srv := mcpserver.New(builtinMCPHost{app: a}, "0.1.0")
+	if err := srv.Start(); err != nil {
+		log.Printf("bytesmith: built-in MCP server disabled: %v", err)
+		return
+	}
+	a.mcp = srv
+}
+
+// builtinMCPServer returns the mcpServers entry for the built-in server in a
+// session of conn in cwd and the token it carries. Agents that support MCP
+// over HTTP connect directly; the others launch this binary as a stdio
+// bridge.
+func (a *App) builtinMCPServer(conn *agent.Connection, cwd string) (acp.MCPServer, string, bool) {
+	if a.mcp == nil || a.mcp.URL() == "" {
+		return acp.MCPServer{}, "", false
+	}
+
+	server := acp.MCPServer{
+		Name: mcpserver.Name,
+		Type: acp.MCPServerTypeHTTP,
+		URL:  a.mcp.URL(),
+	}
+	if !conn.Capabilities().MCPHTTP {
+		exe, err := os.Executable()
+		if err != nil {
+			log.Printf("bytesmith: built-in MCP bridge unavailable: %v", err)
+			return acp.MCPServer{}, "", false
+		}
+		server = acp.MCPServer{
+			Name:    mcpserver.Name,
+			Command: exe,
+			Args:    []string{mcpserver.BridgeCommand},
+			Env:     []acp.EnvVariable{{Name: mcpserver.EnvURL, Value: a.mcp.URL()}},
+		}
+	}
+
+	// OpenCode registers MCP servers per directory, not per session, so a
+	// token per session would be replaced by the newest one. Its sessions
+	// in a directory share a token instead; it is not bound to a session,
+	// so there is nothing to pass to bindBuiltinMCP.
+	if _, ok := conn.Client.(*agentclient.OpenCodeClient); ok {
+		return withBuiltinMCPToken(server, a.directoryMCPToken(conn, cwd)), "", true
+	}
+	token := a.mcp.NewToken()
+	return withBuiltinMCPToken(server, token), token, true
+}
+
+// withBuiltinMCPToken adds the token to the built-in server entry, as a
+// bearer token over HTTP and in the bridge's environment otherwise.
+func withBuiltinMCPToken(server acp.MCPServer, token string) acp.MCPServer {
+	if server.Type == acp.MCPServerTypeHTTP {
+		server.Headers = []acp.HTTPHeader{{Name: "Authorization", Value: "Bearer " + token}}
+		return server
+	}
+	server.Env = append(server.Env, acp.EnvVariable{Name: mcpserver.EnvToken, Value: token})
+	return server
+}
+
+// directoryMCPToken returns the token the sessions of conn in cwd share. A
+// request made with it acts on the one of them running a prompt.
+func (a *App) directoryMCPToken(conn *agent.Connection, cwd string) string {
+	key := conn.ID + "\x00" + cwd
+
+	a.mcpDirTokensMu.Lock()
+	defer a.mcpDirTokensMu.Unlock()
+	if token, ok := a.mcpDirTokens[key]; ok {
+		return token
+	}
+	token := a.mcp.NewToken()
+	connectionID := conn.ID
+	a.mcp.BindResolver(token, func() string { return a.promptingSession(connectionID, cwd) })
+	a.mcpDirTokens[key] = token
+	return token
+}
+
+// promptingSession returns the session of a connection in cwd that is
+// running a prompt, or "" when none is, or several are and the request
+// cannot be attributed.
+func (a *App) promptingSession(connectionID, cwd string) string {
+	a.activePromptsMu.Lock()
+	var running []string
+	for sessionID, p := range a.activePrompts {
+		if p.connectionID == connectionID {
+			running = append(running, sessionID)
+		}
+	}
+	a.activePromptsMu.Unlock()
+
+	found := ""
+	for _, sessionID := range running {
+		if a.sessionCWD(sessionID) != cwd {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = sessionID
+	}
+	return found
+}
+
+// bindBuiltinMCP binds the token handed to an agent to the session it
+// opened, or revokes it when the session could not be opened.
+func (a *App) bindBuiltinMCP(token, sessionID string, err error) {
+	if a.mcp == nil || token == "" {
+		return
+	}
+	if err != nil || sessionID == "" {
+		a.mcp.Revoke(token)
+		return
+	}
+	a.mcp.Bind(token, sessionID)
+}
+
+// revokeBuiltinMCP invalidates the tokens bound to a connection's sessions
+// and those its sessions share, so an agent process that outlives its
+// connection cannot keep calling the built-in tools.
+func (a *App) revokeBuiltinMCP(conn *agent.Connection) {
+	if a.mcp == nil || conn == nil {
+		return
+	}
+	for _, sessionID := range conn.Sessions {
+		a.mcp.RevokeSession(sessionID)
+	}
+
+	a.mcpDirTokensMu.Lock()
+	defer a.mcpDirTokensMu.Unlock()
+	for key, token := range a.mcpDirTokens {
+		if strings.HasPrefix(key, conn.ID+"\x00") {
+			a.mcp.Revoke(token)
+			delete(a.mcpDirTokens, key)
+		}
+	}
+}
+
+// builtinMCPHost serves the built-in MCP tools. It is a separate type so its
+// methods are not bound to the frontend.
+type builtinMCPHost struct {
+	app *App
+}
+
+// OpenFileInfo is emitted as "ui:open-file" when an agent asks to show a
+// file.
+type OpenFileInfo struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Line      int    `json:"line,omitempty"`
+}
+
+// NotificationInfo is emitted as "ui:notification" when an agent sends a
+// notification.
+type NotificationInfo struct {
+	SessionID string `json:"sessionId"`
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+	Level     string `json:"level"`
+}
+
+func (h builtinMCPHost) OpenFile(sessionID, path string, line int) error {
+	if !filepath.IsAbs(path) {
+		cwd := h.app.sessionCWD(sessionID)
+		if cwd == "" {
+			return fmt.Errorf("relative path %s without a session cwd", path)
+		}
+		path = filepath.Join(cwd, path)
+	}
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	events.Emit(h.app.bus, TopicUIOpenFile, OpenFileInfo{
+		SessionID: sessionID,
+		Path:      path,
+		Line:      max(line, 0),
+	})
+	return nil
+}
+
+func (h builtinMCPHost) AskUser(ctx context.Context, sessionID string, q mcpserver.Question) ([]string, bool, error) {
+	rec := h.app.sessions.GetSummary(sessionID)
+	if rec == nil {
+		return nil, false, fmt.Errorf("session %q not found", sessionID)
+	}
+
+	options := make([]acp.ToolRequestUserInputOption, 0, len(q.Options))
+	for _, opt := range q.Options {
+		options = append(options, acp.ToolRequestUserInputOption{Label: opt})
+	}
+
+	// Questions asked during a prompt end with it.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(h.app.promptContext(sessionID), cancel)
+	defer stop()
+
+	const questionID = "answer"
+	response, answered := h.app.askQuestions(ctx, rec.ConnectionID, acp.ToolRequestUserInputParams{
+		ThreadID: sessionID,
+		Questions: []acp.ToolRequestUserInputQuestion{{
+			ID:       questionID,
+			Header:   q.Header,
+			Question: q.Question,
+			Multiple: q.Multiple,
+			IsOther:  q.AllowOther || len(q.Options) == 0,
+			Options:  options,
+		}},
+	})
+	answers := response.Answers[questionID].Answers
+	if !answered || len(answers) == 0 {
+		return nil, false, nil
+	}
+	return answers, true, nil
+}
+
+func (h builtinMCPHost) Notify(sessionID string, n mcpserver.Notification) error {
+	level := strings.ToLower(strings.TrimSpace(n.Level))
+	switch level {
+	case "info", "success", "warning", "error":
+	default:
+		level = "info"
+	}
+
+	events.Emit(h.app.bus, TopicUINotification, NotificationInfo{
+		SessionID: sessionID,
+		Title:     n.Title,
+		Message:   n.Message,
+		Level:     level,
+	})
+	return nil
+}
+
+func (h builtinMCPHost) ReadTerminal(sessionID, terminalID string, maxBytes int) (mcpserver.TerminalOutput, error) {
+	cwd := h.app.sessionCWD(sessionID)
+	if cwd == "" {
+		return mcpserver.TerminalOutput{}, fmt.Errorf("session %q has no workspace", sessionID)
+	}
+
+	terminals := h.app.ensureUITerminalManager().List()
+	chosen := -1
+	for i := len(terminals) - 1; i >= 0; i-- {
+		t := terminals[i]
+		if (terminalID == "" || t.ID == terminalID) && isWithinDir(t.CWD, cwd) {
+			chosen = i
+			break
+		}
+	}
+	if chosen < 0 {
+		if terminalID != "" {
+			return mcpserver.TerminalOutput{}, fmt.Errorf("terminal %q not found", terminalID)
+		}
+		return mcpserver.TerminalOutput{}, fmt.Errorf("no terminal is open in %s", cwd)
+	}
+
+	term := terminals[chosen]
+	output, err := h.app.uiTerm.Scrollback(term.ID, maxBytes)
+	if err != nil {
+		return mcpserver.TerminalOutput{}, err
+	}
+	return mcpserver.TerminalOutput{
+		TerminalID: term.ID,
+		CWD:        term.CWD,
+		Output:     output,
+	}, nil
+}
+
+func (h builtinMCPHost) ListFileChanges(sessionID string) ([]mcpserver.FileChange, error) {
+	changes := h.app.sessions.ListFileChanges(sessionID)
+	result := make([]mcpserver.FileChange, 0, len(changes))
+	for _, c := range changes {
+		result = append(result, mcpserver.FileChange{
+			ID:         c.ID,
+			Path:       c.Path,
+			ToolCallID: c.ToolCallID,
+			Created:    c.Created,
+			Status:     c.Status,
+			Timestamp:  c.Timestamp,
+		})
+	}
+	return result, nil
+}
+
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
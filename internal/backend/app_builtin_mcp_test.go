@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/agentclient"
+	"bytesmith/internal/events"
+	"bytesmith/internal/integrator"
+	"bytesmith/internal/mcpserver"
+)
+
+func builtinMCPAuthorization(servers []acp.MCPServer) string {
+	for _, s := range servers {
+		if s.Name == mcpserver.Name && len(s.Headers) == 1 {
+			return s.Headers[0].Value
+		}
+	}
+	return ""
+}
+
+func TestOpenCodeSessionsShareBuiltinMCPToken(t *testing.T) {
+	a := newTestApp()
+	a.mcp = mcpserver.New(builtinMCPHost{app: a}, "test")
+	if err := a.mcp.Start(); err != nil {
+		t.Fatalf("start MCP server: %v", err)
+	}
+	defer a.mcp.Close()
+
+	var mu sync.Mutex
+	var notified []string
+	events.On(a.bus, TopicUINotification, func(n NotificationInfo) {
+		mu.Lock()
+		notified = append(notified, n.SessionID)
+		mu.Unlock()
+	})
+
+	conn := &agent.Connection{ID: "c1", Agent: agent.AgentConfig{Name: "opencode"}, Client: &agentclient.OpenCodeClient{}}
+	conn.UpdateCapabilities(func(c *integrator.Capabilities) { c.MCPHTTP = true })
+
+	// OpenCode keeps one "bytesmith" server per directory, so the second
+	// session's entry replaces the first one's there.
+	var auth []string
+	for _, sessionID := range []string{"s1", "s2"} {
+		servers, token := a.sessionMCPServers(conn, "/work")
+		a.bindBuiltinMCP(token, sessionID, nil)
+		a.sessionCWDs[sessionID] = "/work"
+		auth = append(auth, builtinMCPAuthorization(servers))
+	}
+	if auth[0] == "" || auth[0] != auth[1] {
+		t.Fatalf("sessions in one directory got %q, want one shared token", auth)
+	}
+	if servers, _ := a.sessionMCPServers(conn, "/other"); builtinMCPAuthorization(servers) == auth[0] {
+		t.Fatalf("another directory shares the token of /work")
+	}
+
+	notify := func() int {
+		t.Helper()
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"notify","arguments":{"message":"done"}}}`
+		req, _ := http.NewRequest(http.MethodPost, a.mcp.URL(), strings.NewReader(body))
+		req.Header.Set("Authorization", auth[0])
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("call notify: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Each call acts on the session running a prompt; with two running,
+	// it cannot tell which one called and acts on none.
+	for _, sessionID := range []string{"s1", "s2"} {
+		a.activePrompts = map[string]*activePrompt{sessionID: {connectionID: "c1", ctx: context.Background(), cancel: func() {}}}
+		notify()
+	}
+	a.activePrompts["s1"] = &activePrompt{connectionID: "c1", ctx: context.Background(), cancel: func() {}}
+	notify()
+	mu.Lock()
+	if got := strings.Join(notified, ","); got != "s1,s2" {
+		t.Fatalf("notifications from sessions %q, want s1,s2", got)
+	}
+	mu.Unlock()
+
+	a.revokeBuiltinMCP(conn)
+	if code := notify(); code != http.StatusUnauthorized {
+		t.Fatalf("shared token after the connection closed: status = %d, want 401", code)
+	}
+}
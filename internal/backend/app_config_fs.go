@@ -22,6 +22,11 @@ func (a *App) GetSettings() AppSettingsInfo {
 		DefaultAgent: a.config.Settings.DefaultAgent,
 		DefaultCWD:   a.config.Settings.DefaultCWD,
 		AutoApprove:  a.config.Settings.AutoApprove,
+
+		RequestTimeoutSeconds: a.config.Settings.RequestTimeoutSeconds,
+		TimeoutDecision:       a.config.Settings.TimeoutDecision,
+		DisableBuiltinMCP:     a.config.Settings.DisableBuiltinMCP,
+		ControlAPI:            a.config.Settings.ControlAPI,
 	}
 }
 
@@ -32,7 +37,13 @@ func (a *App) SaveSettings(settings AppSettingsInfo) error {
 		DefaultAgent: settings.DefaultAgent,
 		DefaultCWD:   settings.DefaultCWD,
 		AutoApprove:  settings.AutoApprove,
+
+		RequestTimeoutSeconds: settings.RequestTimeoutSeconds,
+		TimeoutDecision:       settings.TimeoutDecision,
+		DisableBuiltinMCP:     settings.DisableBuiltinMCP,
+		ControlAPI:            settings.ControlAPI,
 	}
+	a.reloadPolicy()
 	return agent.SaveConfig(a.configPath, a.config)
 }
 
@@ -6,10 +6,10 @@ import (
 
 	"bytesmith/internal/acp"
 	"bytesmith/internal/agent"
+	"bytesmith/internal/events"
 	"bytesmith/internal/session"
 
 	"github.com/google/uuid"
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // ---------------------------------------------------------------------------
@@ -63,9 +63,9 @@ func (a *App) wireConnection(conn *agent.Connection) {
 	// --- Forward stderr to frontend ---
 	go func() {
 		for line := range conn.Client.StderrCh() {
-			wailsRuntime.EventsEmit(a.ctx, "agent:stderr", map[string]string{
-				"connectionId": connID,
-				"line":         line,
+			events.Emit(a.bus, TopicAgentStderr, AgentStderrEvent{
+				ConnectionID: connID,
+				Line:         line,
 			})
 		}
 	}()
@@ -104,94 +104,81 @@ func (a *App) handleSessionUpdate(connectionID string, params acp.SessionUpdateP
 				Content:   update.MessageContent.Text,
 				Timestamp: time.Now(),
 			})
+			a.autoTitleSession(sid, update.MessageContent.Text)
 		}
 
 	case acp.UpdateToolCall:
-		parts := normalizeToolCallParts(update.ToolContent)
-		content := formatToolCallContent(parts, update)
-		diffSummary := summarizeDiffParts(parts)
-		record := session.ToolCallRecord{
-			ID:          update.ToolCallID,
-			Title:       update.Title,
-			Kind:        update.Kind,
-			Status:      update.Status,
-			Content:     content,
-			Parts:       parts,
-			DiffSummary: diffSummary,
-		}
+		a.trackToolCallStatus(sid, update.ToolCallID, update.Status)
+		parts := session.ToolCallParts(update.ToolContent)
+		a.captureTerminalOutput(parts, update.Status)
+		record := session.ToolCallFromUpdate(update, parts)
 		a.sessions.AddToolCall(sid, record)
 		info := toToolCallInfo(record)
-		wailsRuntime.EventsEmit(a.ctx, "agent:toolcall", map[string]interface{}{
-			"connectionId": connectionID,
-			"sessionId":    sid,
-			"toolCallId":   update.ToolCallID,
-			"title":        update.Title,
-			"kind":         update.Kind,
-			"status":       update.Status,
-			"content":      content,
-			"parts":        info.Parts,
-			"diffSummary":  info.DiffSummary,
-			"isUpdate":     false,
+		events.Emit(a.bus, TopicAgentToolCall, AgentToolCallEvent{
+			ConnectionID: connectionID,
+			SessionID:    sid,
+			ToolCallID:   update.ToolCallID,
+			Title:        update.Title,
+			Kind:         update.Kind,
+			Status:       update.Status,
+			Content:      record.Content,
+			Parts:        info.Parts,
+			DiffSummary:  info.DiffSummary,
+			IsUpdate:     false,
 		})
 
 	case acp.UpdateToolCallUpdate:
-		parts := normalizeToolCallParts(update.ToolContent)
-		content := formatToolCallContent(parts, update)
-		diffSummary := summarizeDiffParts(parts)
-		a.sessions.UpdateToolCall(sid, update.ToolCallID, update.Status, content, parts, diffSummary)
-		info := toToolCallInfo(session.ToolCallRecord{
-			ID:          update.ToolCallID,
-			Title:       update.Title,
-			Kind:        update.Kind,
-			Status:      update.Status,
-			Content:     content,
-			Parts:       parts,
-			DiffSummary: diffSummary,
-		})
-		wailsRuntime.EventsEmit(a.ctx, "agent:toolcall", map[string]interface{}{
-			"connectionId": connectionID,
-			"sessionId":    sid,
-			"toolCallId":   update.ToolCallID,
-			"title":        update.Title,
-			"kind":         update.Kind,
-			"status":       update.Status,
-			"content":      content,
-			"parts":        info.Parts,
-			"diffSummary":  info.DiffSummary,
-			"isUpdate":     true,
+		a.trackToolCallStatus(sid, update.ToolCallID, update.Status)
+		parts := session.ToolCallParts(update.ToolContent)
+		a.captureTerminalOutput(parts, update.Status)
+		record := session.ToolCallFromUpdate(update, parts)
+		a.sessions.UpdateToolCall(sid, update.ToolCallID, update.Status, record.Content, parts, record.DiffSummary)
+		info := toToolCallInfo(record)
+		events.Emit(a.bus, TopicAgentToolCall, AgentToolCallEvent{
+			ConnectionID: connectionID,
+			SessionID:    sid,
+			ToolCallID:   update.ToolCallID,
+			Title:        update.Title,
+			Kind:         update.Kind,
+			Status:       update.Status,
+			Content:      record.Content,
+			Parts:        info.Parts,
+			DiffSummary:  info.DiffSummary,
+			IsUpdate:     true,
 		})
 
 	case acp.UpdatePlan:
-		entries := make([]map[string]string, 0, len(update.Entries))
+		entries := make([]PlanEntryInfo, 0, len(update.Entries))
 		for _, e := range update.Entries {
-			entries = append(entries, map[string]string{
-				"content":  e.Content,
-				"priority": e.Priority,
-				"status":   e.Status,
+			entries = append(entries, PlanEntryInfo{
+				Content:  e.Content,
+				Priority: e.Priority,
+				Status:   e.Status,
 			})
 		}
-		wailsRuntime.EventsEmit(a.ctx, "agent:plan", map[string]interface{}{
-			"connectionId": connectionID,
-			"sessionId":    sid,
-			"entries":      entries,
+		a.sessions.AddPlan(sid, session.PlanFromEntries(update.Entries))
+		events.Emit(a.bus, TopicAgentPlan, AgentPlanEvent{
+			ConnectionID: connectionID,
+			SessionID:    sid,
+			Entries:      entries,
 		})
 
 	case acp.UpdateAvailableCommands:
-		cmds := make([]map[string]string, 0, len(update.AvailableCommands))
+		cmds := make([]AvailableCommandInfo, 0, len(update.AvailableCommands))
 		for _, c := range update.AvailableCommands {
-			entry := map[string]string{
-				"name":        c.Name,
-				"description": c.Description,
+			entry := AvailableCommandInfo{
+				Name:        c.Name,
+				Description: c.Description,
 			}
 			if c.Input != nil {
-				entry["inputHint"] = c.Input.Hint
+				entry.InputHint = c.Input.Hint
 			}
 			cmds = append(cmds, entry)
 		}
-		wailsRuntime.EventsEmit(a.ctx, "agent:commands", map[string]interface{}{
-			"connectionId": connectionID,
-			"sessionId":    sid,
-			"commands":     cmds,
+		events.Emit(a.bus, TopicAgentCommands, AgentCommandsEvent{
+			ConnectionID: connectionID,
+			SessionID:    sid,
+			Commands:     cmds,
 		})
 	}
 }
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"bytesmith/internal/controlapi"
+)
+
+// ---------------------------------------------------------------------------
+// Control API
+// ---------------------------------------------------------------------------
+
+// controlAPIExcluded lists bound methods that only make sense inside the
+// window, such as native dialogs.
+var controlAPIExcluded = []string{"SelectDirectory"}
+
+// controlAPITarget is what the control API calls: the App, except that
+// the methods which open a native dialog when given an empty path require
+// the path instead, since nobody may be at the window to answer it.
+type controlAPITarget struct {
+	*App
+}
+
+var errControlAPIPath = errors.New("path is required over the control API")
+
+func (t controlAPITarget) ExportSession(sessionID, format, path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errControlAPIPath
+	}
+	return t.App.ExportSession(sessionID, format, path)
+}
+
+func (t controlAPITarget) ImportSession(path string) (SessionListItem, error) {
+	if strings.TrimSpace(path) == "" {
+		return SessionListItem{}, errControlAPIPath
+	}
+	return t.App.ImportSession(path)
+}
+
+// startControlAPI starts the local control API when settings.controlApi
+// names an address, and writes the endpoint file clients read the address
+// and token from.
+func (a *App) startControlAPI() {
+	if a.config == nil || strings.TrimSpace(a.config.Settings.ControlAPI) == "" {
+		return
+	}
+
+	path := a.controlEndpointPath()
+	token, err := controlapi.LoadToken(path)
+	if err != nil {
+		log.Printf("bytesmith: control API disabled: %v", err)
+		return
+	}
+	srv := controlapi.New(controlAPITarget{a}, a.bus, token, controlAPIExcluded...)
+	if err := srv.Listen(a.config.Settings.ControlAPI); err != nil {
+		log.Printf("bytesmith: control API disabled: %v", err)
+		return
+	}
+	if err := controlapi.WriteEndpoint(path, controlapi.Endpoint{Address: srv.Address(), Token: token}); err != nil {
+		log.Printf("bytesmith: control API disabled: %v", err)
+		_ = srv.Close()
+		return
+	}
+	a.control = srv
+	log.Printf("bytesmith: control API listening on %s", srv.Address())
+}
+
+// stopControlAPI closes the control API and clears the address from the
+// endpoint file, keeping the token for the next run.
+func (a *App) stopControlAPI() {
+	if a.control == nil {
+		return
+	}
+	_ = a.control.Close()
+	a.control = nil
+
+	path := a.controlEndpointPath()
+	if ep, err := controlapi.ReadEndpoint(path); err == nil {
+		ep.Address = ""
+		_ = controlapi.WriteEndpoint(path, ep)
+	}
+}
+
+func (a *App) controlEndpointPath() string {
+	return filepath.Join(filepath.Dir(a.configPath), controlapi.EndpointFileName)
+}
@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"bytesmith/internal/controlapi"
+)
+
+func TestControlAPIRequiresPathsForDialogMethods(t *testing.T) {
+	a := newTestApp()
+	srv := controlapi.New(controlAPITarget{a}, a.bus, "secret", controlAPIExcluded...)
+	if err := srv.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer srv.Close()
+
+	for _, c := range []struct{ method, body string }{
+		{"ImportSession", `[""]`},
+		{"ImportSession", ``},
+		{"ExportSession", `["s1", "markdown", "  "]`},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, srv.Address()+"/call/"+c.method, strings.NewReader(c.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", c.method, err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK || !strings.Contains(string(data), errControlAPIPath.Error()) {
+			t.Fatalf("%s(%s) = %d %s, want the path error", c.method, c.body, resp.StatusCode, data)
+		}
+	}
+}
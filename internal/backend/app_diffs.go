@@ -0,0 +1,83 @@
+package backend
+
+import (
+	"fmt"
+
+	"bytesmith/internal/diff"
+)
+
+// ---------------------------------------------------------------------------
+// Tool call diffs
+// ---------------------------------------------------------------------------
+
+// GetToolCallDiff returns structured hunks for the files a tool call
+// changed. Diffs reported by the agent are used when present; otherwise the
+// file writes attributed to the tool call are diffed.
+func (a *App) GetToolCallDiff(sessionID, toolCallID string) (ToolCallDiffInfo, error) {
+	rec := a.sessions.Get(sessionID)
+	if rec == nil {
+		return ToolCallDiffInfo{}, fmt.Errorf("session %q not found", sessionID)
+	}
+
+	result := ToolCallDiffInfo{
+		SessionID:  sessionID,
+		ToolCallID: toolCallID,
+		Files:      []FileDiffInfo{},
+	}
+
+	found := false
+	for _, tc := range rec.ToolCalls {
+		if tc.ID != toolCallID {
+			continue
+		}
+		found = true
+		for _, part := range tc.Parts {
+			if part.Type == "diff" {
+				result.Files = append(result.Files, toFileDiffInfo(part.Path, part.OldText, part.NewText, part.OldText == ""))
+			}
+		}
+	}
+
+	if len(result.Files) == 0 {
+		for _, c := range a.sessions.ListFileChanges(sessionID) {
+			if c.ToolCallID == toolCallID {
+				found = true
+				result.Files = append(result.Files, toFileDiffInfo(c.Path, c.OldContent, c.NewContent, c.Created))
+			}
+		}
+	}
+
+	if !found {
+		return ToolCallDiffInfo{}, fmt.Errorf("tool call %q not found in session %q", toolCallID, sessionID)
+	}
+	return result, nil
+}
+
+func toFileDiffInfo(path, oldText, newText string, created bool) FileDiffInfo {
+	additions, deletions := diff.Stats(oldText, newText)
+	return FileDiffInfo{
+		Path:      path,
+		Created:   created,
+		Additions: additions,
+		Deletions: deletions,
+		Hunks:     toDiffHunkInfos(diff.Hunks(oldText, newText, diff.DefaultContext)),
+	}
+}
+
+func toDiffHunkInfos(hunks []diff.Hunk) []DiffHunkInfo {
+	result := make([]DiffHunkInfo, 0, len(hunks))
+	for _, h := range hunks {
+		lines := make([]DiffLineInfo, 0, len(h.Lines))
+		for _, l := range h.Lines {
+			lines = append(lines, DiffLineInfo{Op: string(l.Op), Text: l.Text, NoEOL: l.NoEOL})
+		}
+		result = append(result, DiffHunkInfo{
+			OldStart: h.OldStart,
+			OldLines: h.OldLines,
+			NewStart: h.NewStart,
+			NewLines: h.NewLines,
+			Lines:    lines,
+		})
+	}
+	return result
+}
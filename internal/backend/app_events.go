@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"bytesmith/internal/events"
+)
+
+// ---------------------------------------------------------------------------
+// Events – everything the backend pushes to the frontend goes through
+// a.bus as one of these topics. The Wails webview is one subscriber; the
+// payloads are JSON-serialised under the same field names wherever they
+// are delivered.
+// ---------------------------------------------------------------------------
+
+// Topics published by the backend.
+var (
+	TopicAgentMessage            = events.Topic[AgentMessageEvent]("agent:message")
+	TopicAgentToolCall           = events.Topic[AgentToolCallEvent]("agent:toolcall")
+	TopicAgentPlan               = events.Topic[AgentPlanEvent]("agent:plan")
+	TopicAgentCommands           = events.Topic[AgentCommandsEvent]("agent:commands")
+	TopicAgentModels             = events.Topic[AgentModelsEvent]("agent:models")
+	TopicAgentModes              = events.Topic[AgentModesEvent]("agent:modes")
+	TopicAgentAccessModes        = events.Topic[AgentModesEvent]("agent:access-modes")
+	TopicAgentStderr             = events.Topic[AgentStderrEvent]("agent:stderr")
+	TopicAgentError              = events.Topic[AgentErrorEvent]("agent:error")
+	TopicPromptDone              = events.Topic[PromptDoneEvent]("agent:prompt-done")
+	TopicPromptQueue             = events.Topic[PromptQueueEvent]("agent:prompt-queue")
+	TopicAgentPermission         = events.Topic[PermissionRequestInfo]("agent:permission")
+	TopicAgentPermissionResolved = events.Topic[PendingResolvedInfo]("agent:permission-resolved")
+	TopicAgentPermissionDecision = events.Topic[PermissionDecisionInfo]("agent:permission-decision")
+	TopicAgentQuestion           = events.Topic[QuestionRequestInfo]("agent:question")
+	TopicAgentQuestionResolved   = events.Topic[PendingResolvedInfo]("agent:question-resolved")
+	TopicAgentAuthRequired       = events.Topic[AuthRequiredInfo]("agent:auth-required")
+	TopicAgentAuthenticated      = events.Topic[AgentAuthenticatedEvent]("agent:authenticated")
+	TopicSessionUpdated          = events.Topic[SessionListItem]("session:updated")
+	TopicSessionHistory          = events.Topic[SessionHistoryEvent]("session:history")
+	TopicFileChanged             = events.Topic[FileChangedEvent]("file:changed")
+	TopicFileReverted            = events.Topic[FileRevertedEvent]("file:reverted")
+	TopicReviewMode              = events.Topic[ReviewModeEvent]("review:mode")
+	TopicReviewUpdated           = events.Topic[ReviewUpdatedEvent]("review:updated")
+	TopicTerminalOutput          = events.Topic[TerminalOutputEvent]("terminal:output")
+	TopicUITerminalOutput        = events.Topic[TerminalOutputEvent]("ui:terminal-output")
+	TopicUITerminalExit          = events.Topic[UITerminalExitEvent]("ui:terminal-exit")
+	TopicUIOpenFile              = events.Topic[OpenFileInfo]("ui:open-file")
+	TopicUINotification          = events.Topic[NotificationInfo]("ui:notification")
+)
+
+// AgentMessageEvent is a streamed chunk of an agent message. The final
+// event of a message carries its whole content and no text.
+type AgentMessageEvent struct {
+	ConnectionID string `json:"connectionId"`
+	SessionID    string `json:"sessionId"`
+	MessageID    string `json:"messageId"`
+	Text         string `json:"text"`
+	Type         string `json:"type"`
+	IsFinal      bool   `json:"isFinal"`
+	Content      string `json:"content,omitempty"`
+}
+
+// AgentToolCallEvent reports a new tool call, or an update to one when
+// IsUpdate is set.
+type AgentToolCallEvent struct {
+	ConnectionID string                   `json:"connectionId"`
+	SessionID    string                   `json:"sessionId"`
+	ToolCallID   string                   `json:"toolCallId"`
+	Title        string                   `json:"title"`
+	Kind         string                   `json:"kind"`
+	Status       string                   `json:"status"`
+	Content      string                   `json:"content"`
+	Parts        []ToolCallPartInfo       `json:"parts"`
+	DiffSummary  *ToolCallDiffSummaryInfo `json:"diffSummary"`
+	IsUpdate     bool                     `json:"isUpdate"`
+}
+
+// AgentPlanEvent carries the agent's current plan, replacing any earlier one.
+type AgentPlanEvent struct {
+	ConnectionID string          `json:"connectionId"`
+	SessionID    string          `json:"sessionId"`
+	Entries      []PlanEntryInfo `json:"entries"`
+}
+
+// PlanEntryInfo is one step of an agent plan.
+type PlanEntryInfo struct {
+	Content  string `json:"content"`
+	Priority string `json:"priority"`
+	Status   string `json:"status"`
+}
+
+// AgentCommandsEvent lists the slash commands a session offers.
+type AgentCommandsEvent struct {
+	ConnectionID string                 `json:"connectionId"`
+	SessionID    string                 `json:"sessionId"`
+	Commands     []AvailableCommandInfo `json:"commands"`
+}
+
+// AvailableCommandInfo is one slash command.
+type AvailableCommandInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	InputHint   string `json:"inputHint,omitempty"`
+}
+
+// AgentModelsEvent reports the models of a session and the selected one.
+type AgentModelsEvent struct {
+	ConnectionID   string             `json:"connectionId"`
+	SessionID      string             `json:"sessionId"`
+	CurrentModelID string             `json:"currentModelId"`
+	Models         []SessionModelInfo `json:"models"`
+}
+
+// AgentModesEvent reports the modes, or access modes, of a session and the
+// selected one.
+type AgentModesEvent struct {
+	ConnectionID  string            `json:"connectionId"`
+	SessionID     string            `json:"sessionId"`
+	CurrentModeID string            `json:"currentModeId"`
+	Modes         []SessionModeInfo `json:"modes"`
+}
+
+// AgentStderrEvent is one line the agent process wrote to stderr.
+type AgentStderrEvent struct {
+	ConnectionID string `json:"connectionId"`
+	Line         string `json:"line"`
+}
+
+// AgentErrorEvent reports a prompt that failed.
+type AgentErrorEvent struct {
+	ConnectionID string `json:"connectionId"`
+	SessionID    string `json:"sessionId"`
+	Error        string `json:"error"`
+}
+
+// PromptDoneEvent reports a prompt turn that ended.
+type PromptDoneEvent struct {
+	ConnectionID string `json:"connectionId"`
+	SessionID    string `json:"sessionId"`
+	StopReason   string `json:"stopReason"`
+}
+
+// PromptQueueEvent carries a session's prompt queue whenever it changes.
+// Started is set when the change is a queued prompt leaving the queue to
+// run.
+type PromptQueueEvent struct {
+	SessionID string             `json:"sessionId"`
+	Prompts   []QueuedPromptInfo `json:"prompts"`
+	Started   *QueuedPromptInfo  `json:"started,omitempty"`
+}
+
+// AgentAuthenticatedEvent reports a successful authentication.
+type AgentAuthenticatedEvent struct {
+	ConnectionID string `json:"connectionId"`
+	MethodID     string `json:"methodId"`
+}
+
+// SessionHistoryEvent delivers entries recovered from the agent after a
+// session was opened.
+type SessionHistoryEvent struct {
+	ConnectionID string         `json:"connectionId"`
+	SessionID    string         `json:"sessionId"`
+	Messages     []MessageInfo  `json:"messages"`
+	ToolCalls    []ToolCallInfo `json:"toolCalls"`
+}
+
+// FileChangedEvent reports a file write by an agent.
+type FileChangedEvent struct {
+	Path       string `json:"path"`
+	SessionID  string `json:"sessionId"`
+	AgentName  string `json:"agentName"`
+	ChangeID   string `json:"changeId"`
+	ToolCallID string `json:"toolCallId"`
+}
+
+// FileRevertedEvent reports a file change that was reverted.
+type FileRevertedEvent struct {
+	ChangeID  string `json:"changeId"`
+	Path      string `json:"path"`
+	SessionID string `json:"sessionId"`
+}
+
+// ReviewModeEvent reports review mode being switched for a session.
+type ReviewModeEvent struct {
+	SessionID string `json:"sessionId"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// ReviewUpdatedEvent reports a staged file that changed.
+type ReviewUpdatedEvent struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+}
+
+// TerminalOutputEvent is output from an agent or embedded terminal.
+type TerminalOutputEvent struct {
+	TerminalID string `json:"terminalId"`
+	Data       string `json:"data"`
+}
+
+// UITerminalExitEvent reports an embedded terminal that exited.
+type UITerminalExitEvent struct {
+	TerminalID string `json:"terminalId"`
+	ExitCode   int    `json:"exitCode"`
+}
@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bytesmith/internal/session"
+	"bytesmith/internal/transcript"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ---------------------------------------------------------------------------
+// Session export and import
+// ---------------------------------------------------------------------------
+
+// ExportSession writes a stored session to path as "markdown", "json" or
+// "html". With an empty path a save dialog asks for one. It returns the
+// written path, or an empty string if the dialog was cancelled.
+func (a *App) ExportSession(sessionID, format, path string) (string, error) {
+	format, err := transcript.ParseFormat(format)
+	if err != nil {
+		return "", err
+	}
+	rec := a.sessions.Get(sessionID)
+	if rec == nil {
+		return "", fmt.Errorf("session %q not found", sessionID)
+	}
+
+	if strings.TrimSpace(path) == "" {
+		path, err = wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+			Title:           "Export Session",
+			DefaultFilename: exportFileName(rec, format),
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := transcript.Write(f, rec, format); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to export session: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// ImportSession reads a session exported as JSON and stores it. With an
+// empty path an open dialog asks for the file. Imported sessions are read
+// only until they are resumed.
+func (a *App) ImportSession(path string) (SessionListItem, error) {
+	if strings.TrimSpace(path) == "" {
+		var err error
+		path, err = wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+			Title:   "Import Session",
+			Filters: []wailsRuntime.FileFilter{{DisplayName: "ByteSmith session (*.json)", Pattern: "*.json"}},
+		})
+		if err != nil || path == "" {
+			return SessionListItem{}, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return SessionListItem{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	rec, err := transcript.ReadJSON(f)
+	if err != nil {
+		return SessionListItem{}, err
+	}
+	// The connection that recorded the session belongs to another run.
+	rec.ConnectionID = ""
+	if err := a.sessions.Import(rec); err != nil {
+		if errors.Is(err, session.ErrSessionExists) {
+			return SessionListItem{}, fmt.Errorf("session %q already exists", rec.ID)
+		}
+		return SessionListItem{}, err
+	}
+
+	summary := a.sessions.GetSummary(rec.ID)
+	if summary == nil {
+		return SessionListItem{}, fmt.Errorf("session %q was not imported", rec.ID)
+	}
+	return toSessionListItem(*summary), nil
+}
+
+// exportFileName suggests a file name for an exported session.
+func exportFileName(rec *session.SessionRecord, format string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == ':' || r < ' ':
+			return '-'
+		default:
+			return r
+		}
+	}, strings.TrimSpace(rec.Title))
+	if name == "" {
+		name = "session-" + rec.ID
+	}
+	return filepath.Base(name) + transcript.Extension(format)
+}
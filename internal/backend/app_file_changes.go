@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"bytesmith/internal/events"
+	bfs "bytesmith/internal/fs"
+	"bytesmith/internal/session"
+)
+
+// ---------------------------------------------------------------------------
+// Agent file changes and revert
+// ---------------------------------------------------------------------------
+
+// ListFileChanges returns the file writes an agent made in a session,
+// oldest first.
+func (a *App) ListFileChanges(sessionID string) []FileChangeInfo {
+	changes := a.sessions.ListFileChanges(sessionID)
+	result := make([]FileChangeInfo, 0, len(changes))
+	for _, c := range changes {
+		result = append(result, toFileChangeInfo(c))
+	}
+	return result
+}
+
+// RevertFileChange restores the file touched by one change to its previous
+// content. If the file was modified after the agent wrote it, nothing is
+// written and the result reports a conflict.
+func (a *App) RevertFileChange(changeID string) (RevertResultInfo, error) {
+	change := a.sessions.GetFileChange(changeID)
+	if change == nil {
+		return RevertResultInfo{}, fmt.Errorf("file change %q not found", changeID)
+	}
+	return a.revertFileChange(*change), nil
+}
+
+// RevertSession reverts every applied change of a session, newest first, so
+// files written several times end up with their content from before the
+// session. Conflicting changes are skipped and reported.
+func (a *App) RevertSession(sessionID string) ([]RevertResultInfo, error) {
+	if a.sessions.GetSummary(sessionID) == nil {
+		return nil, fmt.Errorf("session %q not found", sessionID)
+	}
+
+	changes := a.sessions.ListFileChanges(sessionID)
+	results := make([]RevertResultInfo, 0, len(changes))
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Status != session.FileChangeApplied {
+			continue
+		}
+		results = append(results, a.revertFileChange(changes[i]))
+	}
+	return results, nil
+}
+
+func (a *App) revertFileChange(change session.FileChangeRecord) RevertResultInfo {
+	result := RevertResultInfo{
+		ChangeID: change.ID,
+		Path:     change.Path,
+	}
+
+	if change.Status == session.FileChangeReverted {
+		result.Reason = "already reverted"
+		return result
+	}
+
+	err := bfs.RevertChange(change.Path, change.OldContent, change.NewContent, change.Created)
+	if err != nil {
+		result.Conflict = errors.Is(err, bfs.ErrConflict)
+		result.Reason = err.Error()
+		return result
+	}
+
+	a.sessions.MarkFileChangeReverted(change.ID, time.Now())
+	result.Reverted = true
+
+	events.Emit(a.bus, TopicFileReverted, FileRevertedEvent{
+		ChangeID:  change.ID,
+		Path:      change.Path,
+		SessionID: change.SessionID,
+	})
+	return result
+}
+
+// recordFileChange persists a write reported by the fs provider and links
+// it to the tool call running in the session, if any.
+func (a *App) recordFileChange(change bfs.FileChange) string {
+	toolCallID := a.stagedToolCall(change.SessionID, change.Path)
+	if toolCallID == "" {
+		toolCallID = a.openToolCall(change.SessionID)
+	}
+	a.sessions.AddFileChange(session.FileChangeRecord{
+		ID:         change.ID,
+		SessionID:  change.SessionID,
+		ToolCallID: toolCallID,
+		AgentName:  change.AgentName,
+		Path:       change.Path,
+		OldContent: change.OldContent,
+		NewContent: change.NewContent,
+		Created:    change.Created,
+		Status:     session.FileChangeApplied,
+		Timestamp:  change.Timestamp,
+	})
+	return toolCallID
+}
+
+// trackToolCallStatus remembers the latest unfinished tool call per session
+// so file writes can be attributed to it.
+func (a *App) trackToolCallStatus(sessionID, toolCallID, status string) {
+	if toolCallID == "" {
+		return
+	}
+
+	a.openToolCallsMu.Lock()
+	defer a.openToolCallsMu.Unlock()
+
+	switch status {
+	case "completed", "failed", "cancelled":
+		if a.openToolCalls[sessionID] == toolCallID {
+			delete(a.openToolCalls, sessionID)
+		}
+	default:
+		a.openToolCalls[sessionID] = toolCallID
+	}
+}
+
+func (a *App) openToolCall(sessionID string) string {
+	a.openToolCallsMu.Lock()
+	defer a.openToolCallsMu.Unlock()
+	return a.openToolCalls[sessionID]
+}
+
+func toFileChangeInfo(c session.FileChangeRecord) FileChangeInfo {
+	info := FileChangeInfo{
+		ID:         c.ID,
+		SessionID:  c.SessionID,
+		ToolCallID: c.ToolCallID,
+		AgentName:  c.AgentName,
+		Path:       c.Path,
+		OldContent: c.OldContent,
+		NewContent: c.NewContent,
+		Created:    c.Created,
+		Status:     c.Status,
+		Timestamp:  c.Timestamp.Format(time.RFC3339),
+	}
+	if !c.RevertedAt.IsZero() {
+		info.RevertedAt = c.RevertedAt.Format(time.RFC3339)
+	}
+	return info
+}
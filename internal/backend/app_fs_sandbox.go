@@ -0,0 +1,63 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"bytesmith/internal/acp"
+	bfs "bytesmith/internal/fs"
+
+	"github.com/google/uuid"
+)
+
+// ---------------------------------------------------------------------------
+// Workspace sandbox approvals
+// ---------------------------------------------------------------------------
+
+const (
+	fsAccessAllowOption  = "allow"
+	fsAccessRejectOption = "reject"
+)
+
+// approveFileAccess is the fs provider's approval callback. Accesses outside
+// the workspace and to sensitive files are turned into permission requests
+// so they go through the policy and, when undecided, the user.
+func (a *App) approveFileAccess(req bfs.AccessRequest) bool {
+	connectionID := ""
+	if rec := a.sessions.GetSummary(req.SessionID); rec != nil {
+		connectionID = rec.ConnectionID
+	}
+
+	kind, verb := "read", "Read"
+	if req.Operation == bfs.OpWrite {
+		kind, verb = "edit", "Write"
+	}
+
+	title := fmt.Sprintf("%s %s outside the workspace", verb, req.Path)
+	if req.Reason == bfs.ReasonSensitive {
+		title = fmt.Sprintf("%s sensitive file %s", verb, req.Path)
+	}
+
+	rawInput, _ := json.Marshal(map[string]string{
+		"path":   req.Path,
+		"reason": req.Reason,
+	})
+
+	result := a.requestPermission(connectionID, acp.RequestPermissionParams{
+		SessionID: req.SessionID,
+		ToolCall: acp.ToolCallUpdate{
+			ToolCallID: "fs-access-" + uuid.NewString(),
+			Title:      title,
+			Kind:       kind,
+			Status:     "pending",
+			Locations:  []acp.ToolCallLocation{{Path: req.Path}},
+			RawInput:   rawInput,
+		},
+		Options: []acp.PermissionOption{
+			{OptionID: fsAccessAllowOption, Name: "Allow", Kind: "allow_once"},
+			{OptionID: fsAccessRejectOption, Name: "Deny", Kind: "reject_once"},
+		},
+	}, req.NeedsExplicitApproval())
+
+	return result.Outcome.Outcome == "selected" && result.Outcome.OptionID == fsAccessAllowOption
+}
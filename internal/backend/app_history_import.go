@@ -0,0 +1,182 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"bytesmith/internal/agent"
+	"bytesmith/internal/agentclient"
+	"bytesmith/internal/events"
+	"bytesmith/internal/importer"
+	"bytesmith/internal/session"
+)
+
+// ---------------------------------------------------------------------------
+// History import and backfill from Codex and OpenCode
+// ---------------------------------------------------------------------------
+
+// ImportCodexHistory merges the Codex rollout files under dir into the
+// session store. An empty dir means Codex's own sessions directory.
+// Importing again only adds what is new.
+func (a *App) ImportCodexHistory(dir string) (HistoryImportInfo, error) {
+	result, err := importer.ImportCodex(a.sessions, dir)
+	return toHistoryImportInfo(result), err
+}
+
+// ImportOpenCodeHistory merges the sessions an OpenCode server keeps for
+// cwd into the session store. An empty cwd means the server's current
+// project.
+func (a *App) ImportOpenCodeHistory(connectionID, cwd string) (HistoryImportInfo, error) {
+	conn := a.manager.GetConnection(connectionID)
+	if conn == nil {
+		return HistoryImportInfo{}, fmt.Errorf("connection %q not found", connectionID)
+	}
+	client, ok := conn.Client.(*agentclient.OpenCodeClient)
+	if !ok {
+		return HistoryImportInfo{}, fmt.Errorf("connection %q is not an OpenCode server", connectionID)
+	}
+
+	ctx := context.Background()
+	sessions, err := client.StoredSessions(ctx, cwd)
+	if err != nil {
+		return HistoryImportInfo{}, fmt.Errorf("failed to list OpenCode sessions: %w", err)
+	}
+
+	var result importer.Result
+	for _, s := range sessions {
+		messages, err := client.SessionMessages(ctx, s.ID, s.Directory)
+		if err != nil {
+			result.Skip(s.ID, err)
+			continue
+		}
+		rec := openCodeRecord(conn.Agent.Name, s, messages)
+		if len(rec.Messages) == 0 && len(rec.ToolCalls) == 0 {
+			continue
+		}
+		if err := result.Add(a.sessions, rec); err != nil {
+			return toHistoryImportInfo(result), err
+		}
+	}
+	return toHistoryImportInfo(result), nil
+}
+
+// backfillRemoteHistory copies the transcript an OpenCode server keeps for
+// a session into the local store when the session is loaded or resumed, so
+// it opens with its past turns. Entries already stored are skipped; the
+// added ones are emitted as "session:history".
+func (a *App) backfillRemoteHistory(conn *agent.Connection, sessionID string) {
+	client, ok := conn.Client.(*agentclient.OpenCodeClient)
+	if !ok {
+		return
+	}
+	cwd := a.sessionCWD(sessionID)
+	messages, err := client.SessionMessages(context.Background(), sessionID, cwd)
+	if err != nil {
+		log.Printf("bytesmith: failed to fetch history of session %s: %v", sessionID, err)
+		return
+	}
+
+	rec := openCodeRecord(conn.Agent.Name, agentclient.OpenCodeSession{ID: sessionID, Directory: cwd}, messages)
+	rec.ConnectionID = conn.ID
+	if stored := a.sessions.Get(sessionID); stored != nil {
+		rec.Messages = session.UnrecordedMessages(stored.Messages, rec.Messages)
+		rec.ToolCalls = session.UnrecordedToolCalls(stored.ToolCalls, rec.ToolCalls)
+	}
+	if len(rec.Messages) == 0 && len(rec.ToolCalls) == 0 {
+		return
+	}
+	if _, err := a.sessions.Merge(rec); err != nil {
+		log.Printf("bytesmith: failed to store history of session %s: %v", sessionID, err)
+		return
+	}
+
+	msgInfos := make([]MessageInfo, 0, len(rec.Messages))
+	for _, m := range rec.Messages {
+		msgInfos = append(msgInfos, toMessageInfo(m))
+	}
+	tcInfos := make([]ToolCallInfo, 0, len(rec.ToolCalls))
+	for _, tc := range rec.ToolCalls {
+		tcInfos = append(tcInfos, toToolCallInfo(tc))
+	}
+	events.Emit(a.bus, TopicSessionHistory, SessionHistoryEvent{
+		ConnectionID: conn.ID,
+		SessionID:    sessionID,
+		Messages:     msgInfos,
+		ToolCalls:    tcInfos,
+	})
+}
+
+// openCodeRecord converts a stored OpenCode session. A user message is kept
+// whole under its message ID; assistant text and reasoning become one
+// message per part, so replies interleaved with tool calls keep their
+// order.
+func openCodeRecord(agentName string, s agentclient.OpenCodeSession, messages []agentclient.OpenCodeMessage) *session.SessionRecord {
+	rec := &session.SessionRecord{
+		ID:        s.ID,
+		AgentName: agentName,
+		CWD:       s.Directory,
+		Title:     s.Title,
+		CreatedAt: s.Created,
+		UpdatedAt: s.Updated,
+	}
+
+	for _, m := range messages {
+		if m.Role == "user" {
+			var texts []string
+			for _, p := range m.Parts {
+				if p.Type == "text" {
+					texts = append(texts, strings.TrimSpace(p.Text))
+				}
+			}
+			if len(texts) == 0 {
+				continue
+			}
+			content := strings.Join(texts, "\n\n")
+			rec.Messages = append(rec.Messages, session.Message{
+				ID:        m.ID,
+				Role:      "user",
+				Kind:      session.MessageText,
+				Content:   content,
+				Timestamp: m.Created,
+			})
+			if rec.Title == "" {
+				rec.Title = session.TitleFromPrompt(content)
+			}
+			continue
+		}
+
+		for _, p := range m.Parts {
+			switch {
+			case p.ToolCall != nil:
+				tc := session.ToolCallFromUpdate(*p.ToolCall, session.ToolCallParts(p.ToolCall.ToolContent))
+				tc.Timestamp = p.Time
+				rec.ToolCalls = append(rec.ToolCalls, tc)
+			case p.Type == "text" || p.Type == "reasoning":
+				kind := session.MessageText
+				if p.Type == "reasoning" {
+					kind = session.MessageThought
+				}
+				rec.Messages = append(rec.Messages, session.Message{
+					ID:        p.ID,
+					Role:      "agent",
+					Kind:      kind,
+					Content:   p.Text,
+					Timestamp: p.Time,
+				})
+			}
+		}
+	}
+	return rec
+}
+
+func toHistoryImportInfo(r importer.Result) HistoryImportInfo {
+	return HistoryImportInfo{
+		Sessions:  r.Sessions,
+		Created:   r.Created,
+		Messages:  r.Messages,
+		ToolCalls: r.ToolCalls,
+		Skipped:   r.Skipped,
+	}
+}
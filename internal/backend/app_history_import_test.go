@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agentclient"
+	"bytesmith/internal/session"
+)
+
+func TestOpenCodeRecord(t *testing.T) {
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	messages := []agentclient.OpenCodeMessage{
+		{
+			ID:      "m1",
+			Role:    "user",
+			Created: created,
+			Parts: []agentclient.OpenCodeMessagePart{
+				{Type: "text", Text: " Fix the build \n"},
+				{Type: "file", Text: "ignored"},
+				{Type: "text", Text: "Then run the tests"},
+			},
+		},
+		{
+			ID:   "m2",
+			Role: "assistant",
+			Parts: []agentclient.OpenCodeMessagePart{
+				{ID: "p1", Type: "reasoning", Text: "Look at go.mod", Time: created.Add(time.Second)},
+				{ID: "p2", Type: "text", Text: "Running make.", Time: created.Add(2 * time.Second)},
+				{ID: "p3", Type: "tool", Time: created.Add(3 * time.Second), ToolCall: &acp.SessionUpdate{
+					Type:       acp.UpdateToolCall,
+					ToolCallID: "call-1",
+					Title:      "make",
+					Status:     "completed",
+				}},
+				{ID: "p4", Type: "text", Text: "Done."},
+			},
+		},
+		{ID: "m3", Role: "user", Parts: []agentclient.OpenCodeMessagePart{{Type: "file"}}},
+	}
+
+	rec := openCodeRecord("opencode", agentclient.OpenCodeSession{ID: "s1", Directory: "/work"}, messages)
+	if rec.ID != "s1" || rec.AgentName != "opencode" || rec.CWD != "/work" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if rec.Title == "" {
+		t.Fatalf("title not derived from the first prompt")
+	}
+
+	if len(rec.Messages) != 4 {
+		t.Fatalf("messages = %+v, want 4", rec.Messages)
+	}
+	user := rec.Messages[0]
+	if user.ID != "m1" || user.Role != "user" || user.Content != "Fix the build\n\nThen run the tests" || !user.Timestamp.Equal(created) {
+		t.Fatalf("user message = %+v", user)
+	}
+	if m := rec.Messages[1]; m.ID != "p1" || m.Role != "agent" || m.Kind != session.MessageThought {
+		t.Fatalf("reasoning message = %+v", m)
+	}
+	if m := rec.Messages[2]; m.ID != "p2" || m.Kind != session.MessageText || m.Content != "Running make." {
+		t.Fatalf("text message = %+v", m)
+	}
+	if m := rec.Messages[3]; m.ID != "p4" || m.Content != "Done." {
+		t.Fatalf("text message = %+v", m)
+	}
+
+	if len(rec.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v, want 1", rec.ToolCalls)
+	}
+	if tc := rec.ToolCalls[0]; tc.ID != "call-1" || tc.Title != "make" || !tc.Timestamp.Equal(created.Add(3*time.Second)) {
+		t.Fatalf("tool call = %+v", tc)
+	}
+}
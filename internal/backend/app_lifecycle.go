@@ -4,27 +4,33 @@ import (
 	"context"
 	"log"
 
-	"bytesmith/internal/acp"
 	"bytesmith/internal/agent"
+	"bytesmith/internal/events"
 	bfs "bytesmith/internal/fs"
 	"bytesmith/internal/session"
 	"bytesmith/internal/terminal"
 	"bytesmith/internal/uixterm"
-
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // NewApp creates a new App application struct.
 func NewApp() *App {
 	return &App{
-		pendingPermissions:     make(map[string]chan string),
+		bus:                    events.NewBus(),
+		pendingPermissions:     make(map[string]*pendingPermission),
 		pendingPermissionOrder: make(map[string][]string),
-		pendingQuestions:       make(map[string]chan acp.ToolRequestUserInputResponse),
-		activePrompts:          make(map[string]context.CancelFunc),
+		pendingQuestions:       make(map[string]*pendingQuestion),
+		pendingAuth:            make(map[string]func() (string, error)),
+		activePrompts:          make(map[string]*activePrompt),
+		promptQueues:           make(map[string][]*queuedPrompt),
 		sessionModels:          make(map[string]SessionModelsInfo),
 		sessionModes:           make(map[string]SessionModesInfo),
 		sessionAccessModes:     make(map[string]SessionModesInfo),
 		streamMessages:         make(map[string]*streamMessage),
+		sessionCWDs:            make(map[string]string),
+		mcpDirTokens:           make(map[string]string),
+		remoteTitles:           make(map[string]string),
+		openToolCalls:          make(map[string]string),
+		stagedToolCalls:        make(map[string]string),
 	}
 }
 
@@ -32,6 +38,7 @@ func NewApp() *App {
 // It initialises configuration, the agent manager, and all providers.
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	a.bus.Subscribe(events.WailsSink(ctx))
 	a.loadConfig()
 	a.initSubsystems()
 	a.wireRuntimeEvents()
@@ -45,43 +52,55 @@ func (a *App) loadConfig() {
 		cfg = agent.DefaultConfig()
 	}
 	a.config = cfg
+	a.reloadPolicy()
 }
 
 func (a *App) initSubsystems() {
 	a.manager = agent.NewManager(a.config)
 	a.fs = bfs.NewProvider()
+	a.fs.SetExtraRoots(a.config.Sandbox.ExtraRoots)
+	if len(a.config.Sandbox.SensitivePatterns) > 0 {
+		a.fs.SetSensitivePatterns(a.config.Sandbox.SensitivePatterns)
+	}
+	a.fs.OnAccessRequest(a.approveFileAccess)
+	a.fs.OnStagedChanged(a.handleStagedChanged)
 	a.terminal = terminal.NewProvider()
 	a.uiTerm = uixterm.NewManager()
 	a.sessions = session.NewStore()
+	a.startBuiltinMCP()
+	a.startControlAPI()
 }
 
 func (a *App) wireRuntimeEvents() {
 	a.fs.OnFileChanged(func(change bfs.FileChange) {
-		wailsRuntime.EventsEmit(a.ctx, "file:changed", map[string]string{
-			"path":      change.Path,
-			"sessionId": change.SessionID,
-			"agentName": change.AgentName,
+		toolCallID := a.recordFileChange(change)
+		events.Emit(a.bus, TopicFileChanged, FileChangedEvent{
+			Path:       change.Path,
+			SessionID:  change.SessionID,
+			AgentName:  change.AgentName,
+			ChangeID:   change.ID,
+			ToolCallID: toolCallID,
 		})
 	})
 
 	a.terminal.OnOutput(func(terminalID string, data string) {
-		wailsRuntime.EventsEmit(a.ctx, "terminal:output", map[string]string{
-			"terminalId": terminalID,
-			"data":       data,
+		events.Emit(a.bus, TopicTerminalOutput, TerminalOutputEvent{
+			TerminalID: terminalID,
+			Data:       data,
 		})
 	})
 
 	a.uiTerm.OnOutput(func(terminalID string, data string) {
-		wailsRuntime.EventsEmit(a.ctx, "ui:terminal-output", map[string]string{
-			"terminalId": terminalID,
-			"data":       data,
+		events.Emit(a.bus, TopicUITerminalOutput, TerminalOutputEvent{
+			TerminalID: terminalID,
+			Data:       data,
 		})
 	})
 
 	a.uiTerm.OnExit(func(terminalID string, exitCode int) {
-		wailsRuntime.EventsEmit(a.ctx, "ui:terminal-exit", map[string]interface{}{
-			"terminalId": terminalID,
-			"exitCode":   exitCode,
+		events.Emit(a.bus, TopicUITerminalExit, UITerminalExitEvent{
+			TerminalID: terminalID,
+			ExitCode:   exitCode,
 		})
 	})
 }
@@ -90,11 +109,20 @@ func (a *App) wireRuntimeEvents() {
 // It tears down all terminals and agent connections.
 func (a *App) Shutdown(ctx context.Context) {
 	_ = ctx
+	a.stopControlAPI()
+	a.cancelAllRequests()
 	a.terminal.CloseAll()
 	if a.uiTerm != nil {
 		a.uiTerm.CloseAll()
 	}
+	for _, conn := range a.manager.ListConnections() {
+		a.dropPendingAuth(conn.ID)
+		a.revokeBuiltinMCP(conn)
+	}
 	a.manager.DisconnectAll()
+	if a.mcp != nil {
+		_ = a.mcp.Close()
+	}
 	if a.sessions != nil {
 		_ = a.sessions.Close()
 	}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/mcpserver"
+)
+
+// ---------------------------------------------------------------------------
+// MCP servers
+// ---------------------------------------------------------------------------
+
+// sessionMCPServers returns the MCP servers to attach to a session of conn
+// in cwd: the configured ones, skipping those whose transport the agent does
+// not support, and the built-in server. token identifies the built-in
+// server's connection and must be passed to bindBuiltinMCP once the session
+// is open.
+func (a *App) sessionMCPServers(conn *agent.Connection, cwd string) (servers []acp.MCPServer, token string) {
+	if a.config == nil {
+		return nil, ""
+	}
+
+	caps := conn.Capabilities()
+	names := make(map[string]bool)
+	for _, sc := range a.config.MCPServersFor(conn.Agent.Name, cwd) {
+		server, ok := toACPMCPServer(sc)
+		if !ok {
+			log.Printf("bytesmith: skipping MCP server %q: invalid %s configuration", sc.Name, sc.Transport())
+			continue
+		}
+		switch {
+		case server.Type == acp.MCPServerTypeHTTP && !caps.MCPHTTP,
+			server.Type == acp.MCPServerTypeSSE && !caps.MCPSSE:
+			log.Printf("bytesmith: skipping MCP server %q: %s does not support %s transport",
+				sc.Name, conn.Agent.Name, server.Type)
+			continue
+		}
+		servers = append(servers, server)
+		names[server.Name] = true
+	}
+
+	if !names[mcpserver.Name] {
+		if builtin, t, ok := a.builtinMCPServer(conn, cwd); ok {
+			servers = append(servers, builtin)
+			token = t
+		}
+	}
+	return servers, token
+}
+
+func toACPMCPServer(sc agent.MCPServerConfig) (acp.MCPServer, bool) {
+	server := acp.MCPServer{Name: sc.Name}
+	switch sc.Transport() {
+	case agent.MCPTransportStdio:
+		if strings.TrimSpace(sc.Command) == "" {
+			return server, false
+		}
+		server.Command = sc.Command
+		server.Args = append([]string{}, sc.Args...)
+		for _, name := range sortedKeys(sc.Env) {
+			server.Env = append(server.Env, acp.EnvVariable{Name: name, Value: sc.Env[name]})
+		}
+	case agent.MCPTransportHTTP, agent.MCPTransportSSE:
+		if strings.TrimSpace(sc.URL) == "" {
+			return server, false
+		}
+		server.Type = sc.Transport()
+		server.URL = sc.URL
+		for _, name := range sortedKeys(sc.Headers) {
+			server.Headers = append(server.Headers, acp.HTTPHeader{Name: name, Value: sc.Headers[name]})
+		}
+	default:
+		return server, false
+	}
+	return server, true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/events"
+	"bytesmith/internal/policy"
+)
+
+// ---------------------------------------------------------------------------
+// Pending agent requests (permissions and questions)
+// ---------------------------------------------------------------------------
+
+// Outcomes reported in "agent:permission-resolved" and
+// "agent:question-resolved".
+const (
+	outcomeAnswered  = "answered"
+	outcomeCancelled = "cancelled"
+	outcomeTimeout   = "timeout"
+)
+
+// Values accepted by AppSettings.TimeoutDecision.
+const (
+	timeoutDecisionDeny   = "deny"
+	timeoutDecisionAllow  = "allow"
+	timeoutDecisionCancel = "cancel"
+)
+
+// pendingRequest is the part of a pending permission or question that is
+// needed to cancel it.
+type pendingRequest struct {
+	requestID    string
+	connectionID string
+	sessionID    string
+	createdAt    time.Time
+
+	cancelled  chan struct{}
+	cancelOnce sync.Once
+}
+
+func newPendingRequest(requestID, connectionID, sessionID string) pendingRequest {
+	return pendingRequest{
+		requestID:    requestID,
+		connectionID: connectionID,
+		sessionID:    sessionID,
+		createdAt:    time.Now(),
+		cancelled:    make(chan struct{}),
+	}
+}
+
+func (p *pendingRequest) cancel() {
+	p.cancelOnce.Do(func() { close(p.cancelled) })
+}
+
+// pendingPermission is a permission request waiting for RespondPermission.
+type pendingPermission struct {
+	pendingRequest
+	info PermissionRequestInfo
+	ch   chan string
+}
+
+// pendingQuestion is a question request waiting for RespondQuestion or
+// RejectQuestion.
+type pendingQuestion struct {
+	pendingRequest
+	info QuestionRequestInfo
+	ch   chan acp.ToolRequestUserInputResponse
+}
+
+// activePrompt is a running SendPrompt call.
+type activePrompt struct {
+	connectionID string
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+// PendingResolvedInfo is emitted when a pending permission or question
+// leaves the pending state, so every open dialog for it can be closed.
+type PendingResolvedInfo struct {
+	RequestID    string `json:"requestId"`
+	ConnectionID string `json:"connectionId"`
+	SessionID    string `json:"sessionId"`
+	ToolCallID   string `json:"toolCallId"`
+	Outcome      string `json:"outcome"`
+	OptionID     string `json:"optionId,omitempty"`
+}
+
+// awaitPending blocks until ch delivers a value, the request is cancelled,
+// ctx ends, or timeout elapses. A timeout of zero waits indefinitely.
+func awaitPending[T any](ctx context.Context, req *pendingRequest, ch <-chan T, timeout time.Duration) (T, string) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var zero T
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			return zero, outcomeCancelled
+		}
+		return v, outcomeAnswered
+	case <-req.cancelled:
+		return zero, outcomeCancelled
+	case <-ctx.Done():
+		return zero, outcomeCancelled
+	case <-expired:
+		return zero, outcomeTimeout
+	}
+}
+
+// promptContext returns the context of the prompt running in sessionID, or
+// a background context when the agent asks outside of a prompt.
+func (a *App) promptContext(sessionID string) context.Context {
+	a.activePromptsMu.Lock()
+	defer a.activePromptsMu.Unlock()
+	if p, ok := a.activePrompts[sessionID]; ok {
+		return p.ctx
+	}
+	return context.Background()
+}
+
+// requestTimeout is how long a permission or question may stay unanswered.
+func (a *App) requestTimeout() time.Duration {
+	if a.config == nil || a.config.Settings.RequestTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(a.config.Settings.RequestTimeoutSeconds) * time.Second
+}
+
+// timeoutPermissionOption picks the option applied to a permission request
+// that timed out. An empty result resolves it as cancelled. A request that
+// needs an explicit answer is never allowed on timeout, whatever the
+// setting says.
+func (a *App) timeoutPermissionOption(options []acp.PermissionOption, explicit bool) string {
+	decision := timeoutDecisionDeny
+	if a.config != nil && strings.TrimSpace(a.config.Settings.TimeoutDecision) != "" {
+		decision = strings.ToLower(strings.TrimSpace(a.config.Settings.TimeoutDecision))
+	}
+	if explicit && decision == timeoutDecisionAllow {
+		decision = timeoutDecisionDeny
+	}
+
+	switch decision {
+	case timeoutDecisionAllow:
+		return policy.OptionFor(policy.Allow, options)
+	case timeoutDecisionCancel:
+		return ""
+	default:
+		return policy.OptionFor(policy.Deny, options)
+	}
+}
+
+// cancelPendingRequests resolves every pending permission and question for
+// which match returns true as cancelled.
+func (a *App) cancelPendingRequests(match func(req *pendingRequest) bool) {
+	a.pendingPermissionsMu.Lock()
+	for _, p := range a.pendingPermissions {
+		if match(&p.pendingRequest) {
+			p.cancel()
+		}
+	}
+	a.pendingPermissionsMu.Unlock()
+
+	a.pendingQuestionsMu.Lock()
+	for _, q := range a.pendingQuestions {
+		if match(&q.pendingRequest) {
+			q.cancel()
+		}
+	}
+	a.pendingQuestionsMu.Unlock()
+}
+
+func (a *App) cancelSessionRequests(sessionID string) {
+	a.cancelPendingRequests(func(req *pendingRequest) bool {
+		return req.sessionID == sessionID
+	})
+}
+
+func (a *App) cancelConnectionRequests(connectionID string) {
+	a.cancelPendingRequests(func(req *pendingRequest) bool {
+		return req.connectionID == connectionID
+	})
+}
+
+func (a *App) cancelAllRequests() {
+	a.cancelPendingRequests(func(*pendingRequest) bool { return true })
+
+	a.activePromptsMu.Lock()
+	clear(a.promptQueues)
+	for _, p := range a.activePrompts {
+		p.cancel()
+	}
+	a.activePromptsMu.Unlock()
+}
+
+func (a *App) emitPendingResolved(topic events.Topic[PendingResolvedInfo], req *pendingRequest, toolCallID, outcome, optionID string) {
+	events.Emit(a.bus, topic, PendingResolvedInfo{
+		RequestID:    req.requestID,
+		ConnectionID: req.connectionID,
+		SessionID:    req.sessionID,
+		ToolCallID:   toolCallID,
+		Outcome:      outcome,
+		OptionID:     optionID,
+	})
+}
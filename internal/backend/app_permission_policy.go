@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"log"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/events"
+	"bytesmith/internal/policy"
+)
+
+// ---------------------------------------------------------------------------
+// Permission policy
+// ---------------------------------------------------------------------------
+
+// reloadPolicy rebuilds the permission policy from the current config. An
+// invalid rule set is logged and replaced by one that asks for everything,
+// so a broken deny rule can never turn into a silent allow.
+func (a *App) reloadPolicy() {
+	engine, err := policy.New(a.config.PermissionRules, a.config.Settings.AutoApprove)
+	if err != nil {
+		log.Printf("bytesmith: invalid permission rules, asking for every request: %v", err)
+		engine, _ = policy.New(nil, false)
+	}
+
+	a.policyMu.Lock()
+	a.policy = engine
+	a.policyMu.Unlock()
+}
+
+// evaluatePermissionPolicy runs the policy for a permission request. When
+// the policy allows or denies, it returns the option to select and true.
+func (a *App) evaluatePermissionPolicy(conn *agent.Connection, params acp.RequestPermissionParams) (policy.Result, string, bool) {
+	a.policyMu.RLock()
+	engine := a.policy
+	a.policyMu.RUnlock()
+
+	agentName := ""
+	if conn != nil {
+		agentName = conn.Agent.Name
+	}
+
+	result := engine.Evaluate(policy.RequestFor(agentName, a.sessionCWD(params.SessionID), params.ToolCall))
+	if optionID := policy.OptionFor(result.Decision, params.Options); optionID != "" {
+		return result, optionID, true
+	}
+	return result, "", false
+}
+
+// emitPermissionDecision records a decision the policy made on the user's
+// behalf.
+func (a *App) emitPermissionDecision(connectionID string, params acp.RequestPermissionParams, result policy.Result, optionID string) {
+	log.Printf("bytesmith: permission %s for %q (%s) by %s",
+		result.Decision, params.ToolCall.Title, params.ToolCall.Kind, result.RuleID)
+
+	events.Emit(a.bus, TopicAgentPermissionDecision, PermissionDecisionInfo{
+		ConnectionID: connectionID,
+		SessionID:    params.SessionID,
+		ToolCallID:   params.ToolCall.ToolCallID,
+		Title:        params.ToolCall.Title,
+		Kind:         params.ToolCall.Kind,
+		Decision:     string(result.Decision),
+		OptionID:     optionID,
+		RuleID:       result.RuleID,
+		Reason:       result.Reason,
+	})
+}
@@ -2,9 +2,10 @@ package backend
 
 import (
 	"bytesmith/internal/acp"
+	"bytesmith/internal/events"
+	"bytesmith/internal/policy"
 
 	"github.com/google/uuid"
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // ---------------------------------------------------------------------------
@@ -32,27 +33,48 @@ func (a *App) RespondPermission(sessionID, toolCallID, optionID string) {
 		a.pendingPermissionOrder[key] = next
 	}
 
-	ch, ok := a.pendingPermissions[requestID]
+	pending, ok := a.pendingPermissions[requestID]
 	a.pendingPermissionsMu.Unlock()
 
 	if ok {
-		ch <- optionID
+		select {
+		case pending.ch <- optionID:
+		default:
+		}
 	}
 }
 
 // handlePermissionRequest is called synchronously by the ACP client when the
-// agent asks for user permission. It emits an event to the UI and blocks
-// until RespondPermission is called.
+// agent asks for user permission. Requests covered by the permission policy
+// are answered immediately; the rest are emitted to the UI and block until
+// RespondPermission is called, the session's prompt is cancelled or the
+// configured request timeout expires.
 func (a *App) handlePermissionRequest(connectionID string, params acp.RequestPermissionParams) acp.RequestPermissionResult {
-	ch := make(chan string, 1)
+	return a.requestPermission(connectionID, params, false)
+}
+
+// requestPermission implements handlePermissionRequest. When explicit is
+// set, only an explicit policy rule may answer for the user; neither the
+// auto-approve default nor a timeout can allow the request.
+func (a *App) requestPermission(connectionID string, params acp.RequestPermissionParams, explicit bool) acp.RequestPermissionResult {
+	decision, optionID, decided := a.evaluatePermissionPolicy(a.manager.GetConnection(connectionID), params)
+	if decided && explicit && decision.RuleID == policy.AutoApproveRuleID {
+		decision = policy.Result{Decision: policy.Ask}
+		decided = false
+	}
+	if decided {
+		a.emitPermissionDecision(connectionID, params, decision, optionID)
+		return acp.RequestPermissionResult{
+			Outcome: acp.PermissionOutcome{
+				Outcome:  "selected",
+				OptionID: optionID,
+			},
+		}
+	}
+
 	requestID := uuid.NewString()
 	orderKey := sessionPermissionKey(params.SessionID, params.ToolCall.ToolCallID)
 
-	a.pendingPermissionsMu.Lock()
-	a.pendingPermissions[requestID] = ch
-	a.pendingPermissionOrder[orderKey] = append(a.pendingPermissionOrder[orderKey], requestID)
-	a.pendingPermissionsMu.Unlock()
-
 	// Build options for the frontend.
 	options := make([]PermissionOptionInfo, 0, len(params.Options))
 	for _, opt := range params.Options {
@@ -63,25 +85,46 @@ func (a *App) handlePermissionRequest(connectionID string, params acp.RequestPer
 		})
 	}
 
-	wailsRuntime.EventsEmit(a.ctx, "agent:permission", PermissionRequestInfo{
-		RequestID:    requestID,
-		ConnectionID: connectionID,
-		SessionID:    params.SessionID,
-		ToolCallID:   params.ToolCall.ToolCallID,
-		Title:        params.ToolCall.Title,
-		Kind:         params.ToolCall.Kind,
-		Options:      options,
-	})
+	pending := &pendingPermission{
+		pendingRequest: newPendingRequest(requestID, connectionID, params.SessionID),
+		ch:             make(chan string, 1),
+		info: PermissionRequestInfo{
+			RequestID:    requestID,
+			ConnectionID: connectionID,
+			SessionID:    params.SessionID,
+			ToolCallID:   params.ToolCall.ToolCallID,
+			Title:        params.ToolCall.Title,
+			Kind:         params.ToolCall.Kind,
+			Options:      options,
+			PolicyRuleID: decision.RuleID,
+		},
+	}
+
+	a.pendingPermissionsMu.Lock()
+	a.pendingPermissions[requestID] = pending
+	a.pendingPermissionOrder[orderKey] = append(a.pendingPermissionOrder[orderKey], requestID)
+	a.pendingPermissionsMu.Unlock()
+
+	events.Emit(a.bus, TopicAgentPermission, pending.info)
 
-	// Block until the UI responds.
-	optionID, ok := <-ch
+	// Block until the UI responds or the request is abandoned.
+	optionID, outcome := awaitPending(a.promptContext(params.SessionID), &pending.pendingRequest, pending.ch, a.requestTimeout())
+	if outcome == outcomeTimeout {
+		optionID = a.timeoutPermissionOption(params.Options, explicit)
+	}
 
 	// Clean up.
 	a.pendingPermissionsMu.Lock()
 	delete(a.pendingPermissions, requestID)
+	a.removePermissionOrder(orderKey, requestID)
 	a.pendingPermissionsMu.Unlock()
 
-	if !ok || optionID == "" {
+	if outcome == outcomeAnswered && optionID == "" {
+		outcome = outcomeCancelled
+	}
+	a.emitPendingResolved(TopicAgentPermissionResolved, &pending.pendingRequest, params.ToolCall.ToolCallID, outcome, optionID)
+
+	if optionID == "" {
 		return acp.RequestPermissionResult{
 			Outcome: acp.PermissionOutcome{
 				Outcome: "cancelled",
@@ -97,6 +140,24 @@ func (a *App) handlePermissionRequest(connectionID string, params acp.RequestPer
 	}
 }
 
+// removePermissionOrder drops requestID from the FIFO for orderKey. It must
+// be called with pendingPermissionsMu held.
+func (a *App) removePermissionOrder(orderKey, requestID string) {
+	queue := a.pendingPermissionOrder[orderKey]
+	for i, id := range queue {
+		if id != requestID {
+			continue
+		}
+		queue = append(queue[:i:i], queue[i+1:]...)
+		if len(queue) == 0 {
+			delete(a.pendingPermissionOrder, orderKey)
+		} else {
+			a.pendingPermissionOrder[orderKey] = queue
+		}
+		return
+	}
+}
+
 func sessionPermissionKey(sessionID, toolCallID string) string {
 	return sessionID + "::" + toolCallID
 }
@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"bytesmith/internal/acp"
+)
+
+func TestPermissionTimeoutNeverAllowsExplicitRequests(t *testing.T) {
+	a := newTestApp()
+	a.config.Settings.RequestTimeoutSeconds = 1
+	a.config.Settings.TimeoutDecision = timeoutDecisionAllow
+	a.reloadPolicy()
+
+	params := acp.RequestPermissionParams{
+		SessionID: "s1",
+		ToolCall:  acp.ToolCallUpdate{Title: "Read sensitive file .env", Kind: "read"},
+		Options: []acp.PermissionOption{
+			{OptionID: "allow", Name: "Allow", Kind: "allow_once"},
+			{OptionID: "reject", Name: "Deny", Kind: "reject_once"},
+		},
+	}
+
+	// Both requests wait for the timeout, so run them side by side.
+	var wg sync.WaitGroup
+	results := make([]acp.RequestPermissionResult, 2)
+	for i, explicit := range []bool{true, false} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := params
+			p.ToolCall.ToolCallID = fmt.Sprintf("t%d", i)
+			results[i] = a.requestPermission("c1", p, explicit)
+		}()
+	}
+	wg.Wait()
+
+	if got := results[0].Outcome; got.Outcome != "selected" || got.OptionID != "reject" {
+		t.Fatalf("explicit request on timeout = %+v, want reject", got)
+	}
+	if got := results[1].Outcome; got.Outcome != "selected" || got.OptionID != "allow" {
+		t.Fatalf("plain request on timeout = %+v, want the configured allow", got)
+	}
+}
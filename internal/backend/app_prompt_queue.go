@@ -0,0 +1,190 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bytesmith/internal/events"
+)
+
+// ---------------------------------------------------------------------------
+// Prompt queue – a session runs one turn at a time. Prompts sent while a
+// turn is running wait in the session's queue and run in order once it
+// ends; until then they can be reordered, edited or removed.
+// ---------------------------------------------------------------------------
+
+// queuedPrompt is a prompt waiting in a session's queue.
+type queuedPrompt struct {
+	id           string
+	connectionID string
+	text         string
+	queuedAt     time.Time
+}
+
+func (q *queuedPrompt) info() QueuedPromptInfo {
+	return QueuedPromptInfo{
+		ID:           q.id,
+		ConnectionID: q.connectionID,
+		Text:         q.text,
+		QueuedAt:     q.queuedAt.Format(time.RFC3339),
+	}
+}
+
+// GetPromptQueue returns the prompts waiting in a session, in the order
+// they will run.
+func (a *App) GetPromptQueue(sessionID string) []QueuedPromptInfo {
+	a.activePromptsMu.Lock()
+	defer a.activePromptsMu.Unlock()
+	return a.promptQueueLocked(sessionID)
+}
+
+// MoveQueuedPrompt moves a queued prompt to position index, where 0 runs
+// next. Indexes past either end move it to that end.
+func (a *App) MoveQueuedPrompt(sessionID, promptID string, index int) error {
+	return a.updatePromptQueue(sessionID, func(queue []*queuedPrompt) ([]*queuedPrompt, error) {
+		i := queuedPromptIndex(queue, promptID)
+		if i < 0 {
+			return nil, fmt.Errorf("prompt %q is not queued", promptID)
+		}
+		q := queue[i]
+		queue = append(queue[:i:i], queue[i+1:]...)
+		index = max(0, min(index, len(queue)))
+		queue = append(queue[:index:index], append([]*queuedPrompt{q}, queue[index:]...)...)
+		return queue, nil
+	})
+}
+
+// EditQueuedPrompt replaces the text of a queued prompt.
+func (a *App) EditQueuedPrompt(sessionID, promptID, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("prompt is empty")
+	}
+	return a.updatePromptQueue(sessionID, func(queue []*queuedPrompt) ([]*queuedPrompt, error) {
+		i := queuedPromptIndex(queue, promptID)
+		if i < 0 {
+			return nil, fmt.Errorf("prompt %q is not queued", promptID)
+		}
+		edited := *queue[i]
+		edited.text = text
+		queue = append([]*queuedPrompt(nil), queue...)
+		queue[i] = &edited
+		return queue, nil
+	})
+}
+
+// RemoveQueuedPrompt drops a queued prompt.
+func (a *App) RemoveQueuedPrompt(sessionID, promptID string) error {
+	return a.updatePromptQueue(sessionID, func(queue []*queuedPrompt) ([]*queuedPrompt, error) {
+		i := queuedPromptIndex(queue, promptID)
+		if i < 0 {
+			return nil, fmt.Errorf("prompt %q is not queued", promptID)
+		}
+		return append(queue[:i:i], queue[i+1:]...), nil
+	})
+}
+
+// updatePromptQueue replaces a session's queue with what fn makes of it
+// and emits the result. fn must not modify the slice it is given.
+func (a *App) updatePromptQueue(sessionID string, fn func([]*queuedPrompt) ([]*queuedPrompt, error)) error {
+	a.activePromptsMu.Lock()
+	queue, err := fn(a.promptQueues[sessionID])
+	if err != nil {
+		a.activePromptsMu.Unlock()
+		return err
+	}
+	a.setPromptQueueLocked(sessionID, queue)
+	infos := a.promptQueueLocked(sessionID)
+	a.activePromptsMu.Unlock()
+
+	a.emitPromptQueue(sessionID, infos, nil)
+	return nil
+}
+
+// enqueuePromptLocked adds a prompt to the end of a session's queue.
+func (a *App) enqueuePromptLocked(sessionID string, q *queuedPrompt) {
+	a.promptQueues[sessionID] = append(a.promptQueues[sessionID], q)
+}
+
+// popQueuedPromptLocked takes the next prompt off a session's queue, or
+// returns nil when it is empty.
+func (a *App) popQueuedPromptLocked(sessionID string) *queuedPrompt {
+	queue := a.promptQueues[sessionID]
+	if len(queue) == 0 {
+		return nil
+	}
+	a.setPromptQueueLocked(sessionID, queue[1:])
+	return queue[0]
+}
+
+func (a *App) setPromptQueueLocked(sessionID string, queue []*queuedPrompt) {
+	if len(queue) == 0 {
+		delete(a.promptQueues, sessionID)
+		return
+	}
+	a.promptQueues[sessionID] = queue
+}
+
+func (a *App) promptQueueLocked(sessionID string) []QueuedPromptInfo {
+	queue := a.promptQueues[sessionID]
+	infos := make([]QueuedPromptInfo, 0, len(queue))
+	for _, q := range queue {
+		infos = append(infos, q.info())
+	}
+	return infos
+}
+
+// flushPromptQueue drops every prompt queued in a session.
+func (a *App) flushPromptQueue(sessionID string) {
+	a.activePromptsMu.Lock()
+	_, queued := a.promptQueues[sessionID]
+	delete(a.promptQueues, sessionID)
+	a.activePromptsMu.Unlock()
+
+	if queued {
+		a.emitPromptQueue(sessionID, []QueuedPromptInfo{}, nil)
+	}
+}
+
+// dropQueuedPrompts drops the prompts queued on a connection that is going
+// away.
+func (a *App) dropQueuedPrompts(connectionID string) {
+	changed := make(map[string][]QueuedPromptInfo)
+
+	a.activePromptsMu.Lock()
+	for sessionID, queue := range a.promptQueues {
+		kept := make([]*queuedPrompt, 0, len(queue))
+		for _, q := range queue {
+			if q.connectionID != connectionID {
+				kept = append(kept, q)
+			}
+		}
+		if len(kept) != len(queue) {
+			a.setPromptQueueLocked(sessionID, kept)
+			changed[sessionID] = a.promptQueueLocked(sessionID)
+		}
+	}
+	a.activePromptsMu.Unlock()
+
+	for sessionID, infos := range changed {
+		a.emitPromptQueue(sessionID, infos, nil)
+	}
+}
+
+func queuedPromptIndex(queue []*queuedPrompt, promptID string) int {
+	for i, q := range queue {
+		if q.id == promptID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *App) emitPromptQueue(sessionID string, prompts []QueuedPromptInfo, started *QueuedPromptInfo) {
+	events.Emit(a.bus, TopicPromptQueue, PromptQueueEvent{
+		SessionID: sessionID,
+		Prompts:   prompts,
+		Started:   started,
+	})
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bytesmith/internal/agent"
+)
+
+func newTestApp() *App {
+	a := NewApp()
+	a.config = agent.DefaultConfig()
+	a.manager = agent.NewManager(a.config)
+	return a
+}
+
+func TestCancelPromptKeepsQueueAndCancelsRequestsFirst(t *testing.T) {
+	a := newTestApp()
+
+	pending := &pendingPermission{pendingRequest: newPendingRequest("r1", "c1", "s1")}
+	a.pendingPermissions["r1"] = pending
+
+	// The running turn checks, as it is released, that its pending
+	// requests were already resolved; otherwise they could outlive it and
+	// be cancelled after the next queued turn has started.
+	released := make(chan bool, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.activePrompts["s1"] = &activePrompt{
+		connectionID: "c1",
+		ctx:          ctx,
+		cancel: func() {
+			select {
+			case <-pending.cancelled:
+				released <- true
+			default:
+				released <- false
+			}
+			cancel()
+		},
+	}
+	a.enqueuePromptLocked("s1", &queuedPrompt{id: "q1", connectionID: "c1", text: "next", queuedAt: time.Now()})
+
+	if err := a.CancelPrompt("c1", "s1", false); err == nil {
+		t.Fatalf("CancelPrompt on unknown connection returned nil error")
+	}
+	select {
+	case ok := <-released:
+		if !ok {
+			t.Fatalf("turn released before its pending requests were cancelled")
+		}
+	default:
+		t.Fatalf("turn was not released")
+	}
+	if queue := a.GetPromptQueue("s1"); len(queue) != 1 || queue[0].ID != "q1" {
+		t.Fatalf("queue after cancel = %+v, want [q1]", queue)
+	}
+
+	_ = a.CancelPrompt("c1", "s1", true)
+	if queue := a.GetPromptQueue("s1"); len(queue) != 0 {
+		t.Fatalf("queue after flushing cancel = %+v, want empty", queue)
+	}
+}
+
+func TestMoveQueuedPrompt(t *testing.T) {
+	a := newTestApp()
+	for _, id := range []string{"a", "b", "c"} {
+		a.enqueuePromptLocked("s1", &queuedPrompt{id: id, text: id, queuedAt: time.Now()})
+	}
+
+	if err := a.MoveQueuedPrompt("s1", "c", 0); err != nil {
+		t.Fatalf("MoveQueuedPrompt: %v", err)
+	}
+	if err := a.MoveQueuedPrompt("s1", "a", 99); err != nil {
+		t.Fatalf("MoveQueuedPrompt: %v", err)
+	}
+	var got []string
+	for _, q := range a.GetPromptQueue("s1") {
+		got = append(got, q.ID)
+	}
+	if len(got) != 3 || got[0] != "c" || got[1] != "b" || got[2] != "a" {
+		t.Fatalf("queue = %v, want [c b a]", got)
+	}
+	if err := a.MoveQueuedPrompt("s1", "missing", 0); err == nil {
+		t.Fatalf("moving an unknown prompt returned nil error")
+	}
+}
@@ -1,12 +1,13 @@
 package backend
 
 import (
+	"context"
 	"strings"
 
 	"bytesmith/internal/acp"
+	"bytesmith/internal/events"
 
 	"github.com/google/uuid"
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // ---------------------------------------------------------------------------
@@ -52,12 +53,15 @@ func (a *App) RejectQuestion(requestID string) {
 }
 
 func (a *App) handleQuestionRequest(connectionID string, params acp.ToolRequestUserInputParams) acp.ToolRequestUserInputResponse {
-	requestID := uuid.NewString()
-	ch := make(chan acp.ToolRequestUserInputResponse, 1)
+	response, _ := a.askQuestions(a.promptContext(params.ThreadID), connectionID, params)
+	return response
+}
 
-	a.pendingQuestionsMu.Lock()
-	a.pendingQuestions[requestID] = ch
-	a.pendingQuestionsMu.Unlock()
+// askQuestions shows a question request and waits until it is answered,
+// dismissed, cancelled with ctx, or times out. answered is false unless the
+// user submitted answers.
+func (a *App) askQuestions(ctx context.Context, connectionID string, params acp.ToolRequestUserInputParams) (response acp.ToolRequestUserInputResponse, answered bool) {
+	requestID := uuid.NewString()
 
 	questions := make([]QuestionInfo, 0, len(params.Questions))
 	for _, q := range params.Questions {
@@ -80,39 +84,51 @@ func (a *App) handleQuestionRequest(connectionID string, params acp.ToolRequestU
 		})
 	}
 
-	wailsRuntime.EventsEmit(a.ctx, "agent:question", QuestionRequestInfo{
-		RequestID:    requestID,
-		ConnectionID: connectionID,
-		SessionID:    params.ThreadID,
-		ToolCallID:   params.ItemID,
-		Questions:    questions,
-	})
+	pending := &pendingQuestion{
+		pendingRequest: newPendingRequest(requestID, connectionID, params.ThreadID),
+		ch:             make(chan acp.ToolRequestUserInputResponse, 1),
+		info: QuestionRequestInfo{
+			RequestID:    requestID,
+			ConnectionID: connectionID,
+			SessionID:    params.ThreadID,
+			ToolCallID:   params.ItemID,
+			Questions:    questions,
+		},
+	}
+
+	a.pendingQuestionsMu.Lock()
+	a.pendingQuestions[requestID] = pending
+	a.pendingQuestionsMu.Unlock()
+
+	events.Emit(a.bus, TopicAgentQuestion, pending.info)
 
-	response, ok := <-ch
+	response, outcome := awaitPending(ctx, &pending.pendingRequest, pending.ch, a.requestTimeout())
 
 	a.pendingQuestionsMu.Lock()
 	delete(a.pendingQuestions, requestID)
 	a.pendingQuestionsMu.Unlock()
 
-	if !ok {
-		return emptyQuestionResponse()
+	a.emitPendingResolved(TopicAgentQuestionResolved, &pending.pendingRequest, params.ItemID, outcome, "")
+
+	if outcome != outcomeAnswered {
+		return emptyQuestionResponse(), false
 	}
 	if response.Answers == nil {
 		response.Answers = map[string]acp.ToolRequestUserInputAnswer{}
 	}
-	return response
+	return response, true
 }
 
 func (a *App) answerQuestionRequest(requestID string, response acp.ToolRequestUserInputResponse) {
 	a.pendingQuestionsMu.Lock()
-	ch, ok := a.pendingQuestions[requestID]
+	pending, ok := a.pendingQuestions[requestID]
 	a.pendingQuestionsMu.Unlock()
 	if !ok {
 		return
 	}
 
 	select {
-	case ch <- response:
+	case pending.ch <- response:
 	default:
 	}
 }
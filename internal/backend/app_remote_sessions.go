@@ -3,8 +3,8 @@ package backend
 import (
 	"context"
 	"fmt"
-	"strings"
 
+	"bytesmith/internal/acp"
 	"bytesmith/internal/integrator"
 )
 
@@ -15,13 +15,14 @@ func (a *App) ListRemoteSessions(connectionID, cwd, cursor string) (SessionListP
 		return SessionListPage{}, fmt.Errorf("connection %q not found", connectionID)
 	}
 
-	if !integrator.ForAgent(conn.Agent.Name).Capabilities().ListSessions {
+	if !conn.Capabilities().ListSessions {
 		return SessionListPage{Unsupported: true}, nil
 	}
 
 	list, err := conn.Client.ListSessions(context.Background(), cwd, cursor)
 	if err != nil {
-		if strings.Contains(err.Error(), "method not found") || strings.Contains(err.Error(), "unknown variant") {
+		if acp.IsMethodNotFound(err) {
+			conn.UpdateCapabilities(func(c *integrator.Capabilities) { c.ListSessions = false })
 			return SessionListPage{Unsupported: true}, nil
 		}
 		return SessionListPage{}, err
@@ -29,11 +30,13 @@ func (a *App) ListRemoteSessions(connectionID, cwd, cursor string) (SessionListP
 
 	sessions := make([]SessionListItem, 0, len(list.Sessions))
 	for _, s := range list.Sessions {
+		a.rememberRemoteTitle(s.SessionID, s.Title)
 		sessions = append(sessions, SessionListItem{
 			ID:           s.SessionID,
 			AgentName:    conn.Agent.Name,
 			ConnectionID: connectionID,
 			CWD:          s.CWD,
+			Title:        s.Title,
 			CreatedAt:    "",
 			UpdatedAt:    s.UpdatedAt,
 		})
@@ -52,16 +55,24 @@ func (a *App) LoadRemoteSession(connectionID, sessionID, cwd string) error {
 		return fmt.Errorf("connection %q not found", connectionID)
 	}
 
-	if !integrator.ForAgent(conn.Agent.Name).Capabilities().LoadSession {
+	if !conn.Capabilities().LoadSession {
 		return fmt.Errorf("integrator %q does not support session load", conn.Agent.Name)
 	}
 
-	if err := conn.Client.LoadSession(context.Background(), sessionID, cwd, nil); err != nil {
-		return err
+	mcpServers, mcpToken := a.sessionMCPServers(conn, cwd)
+	err := conn.Client.LoadSession(context.Background(), sessionID, cwd, mcpServers)
+	a.bindBuiltinMCP(mcpToken, sessionID, err)
+	if err != nil {
+		if acp.IsMethodNotFound(err) {
+			conn.UpdateCapabilities(func(c *integrator.Capabilities) { c.LoadSession = false })
+		}
+		return a.handleAuthRequired(conn, err, func() (string, error) {
+			return sessionID, a.LoadRemoteSession(connectionID, sessionID, cwd)
+		})
 	}
 
-	a.sessions.Create(sessionID, conn.Agent.Name, connectionID, cwd)
-	appendSessionIfMissing(conn, sessionID)
+	a.trackSession(conn, sessionID, cwd)
+	a.backfillRemoteHistory(conn, sessionID)
 
 	if modes, ok := resolveSessionModes(conn.IntegratorID, nil); ok {
 		a.sessionModesMu.Lock()
@@ -87,7 +98,7 @@ func (a *App) ResumeSession(connectionID, sessionID, cwd string) error {
 		return fmt.Errorf("connection %q not found", connectionID)
 	}
 
-	caps := integrator.ForAgent(conn.Agent.Name).Capabilities()
+	caps := conn.Capabilities()
 	if !caps.ResumeSession {
 		if !caps.LoadSession {
 			return fmt.Errorf("integrator %q does not support session resume", conn.Agent.Name)
@@ -95,13 +106,23 @@ func (a *App) ResumeSession(connectionID, sessionID, cwd string) error {
 		return a.LoadRemoteSession(connectionID, sessionID, cwd)
 	}
 
-	result, err := conn.Client.ResumeSession(context.Background(), sessionID, cwd, nil)
+	mcpServers, mcpToken := a.sessionMCPServers(conn, cwd)
+	result, err := conn.Client.ResumeSession(context.Background(), sessionID, cwd, mcpServers)
+	a.bindBuiltinMCP(mcpToken, sessionID, err)
 	if err != nil {
-		return err
+		if acp.IsMethodNotFound(err) {
+			conn.UpdateCapabilities(func(c *integrator.Capabilities) { c.ResumeSession = false })
+			if caps.LoadSession {
+				return a.LoadRemoteSession(connectionID, sessionID, cwd)
+			}
+		}
+		return a.handleAuthRequired(conn, err, func() (string, error) {
+			return sessionID, a.ResumeSession(connectionID, sessionID, cwd)
+		})
 	}
 
-	a.sessions.Create(sessionID, conn.Agent.Name, connectionID, cwd)
-	appendSessionIfMissing(conn, sessionID)
+	a.trackSession(conn, sessionID, cwd)
+	a.backfillRemoteHistory(conn, sessionID)
 
 	if result != nil && result.Models != nil {
 		models := make([]SessionModelInfo, 0, len(result.Models.AvailableModels))
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"bytesmith/internal/diff"
+	"bytesmith/internal/events"
+)
+
+// ---------------------------------------------------------------------------
+// Review mode (staged agent writes)
+// ---------------------------------------------------------------------------
+
+// SetReviewMode turns review mode on or off for a session. In review mode
+// agent writes are held in an overlay until accepted or rejected.
+func (a *App) SetReviewMode(sessionID string, enabled bool) error {
+	if a.sessions.GetSummary(sessionID) == nil {
+		return fmt.Errorf("session %q not found", sessionID)
+	}
+	a.fs.SetReviewMode(sessionID, enabled)
+	events.Emit(a.bus, TopicReviewMode, ReviewModeEvent{
+		SessionID: sessionID,
+		Enabled:   enabled,
+	})
+	return nil
+}
+
+// GetReviewMode reports whether review mode is on for a session.
+func (a *App) GetReviewMode(sessionID string) bool {
+	return a.fs.ReviewMode(sessionID)
+}
+
+// ListStagedChanges returns the files staged in a session with their hunks.
+func (a *App) ListStagedChanges(sessionID string) []StagedFileInfo {
+	files := a.fs.StagedFiles(sessionID)
+	result := make([]StagedFileInfo, 0, len(files))
+	for _, f := range files {
+		result = append(result, StagedFileInfo{
+			Path:      f.Path,
+			Created:   !f.BaseExists,
+			Hunks:     toDiffHunkInfos(diff.Hunks(f.Base, f.Content, diff.DefaultContext)),
+			UpdatedAt: f.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return result
+}
+
+// AcceptStagedFile writes a staged file to disk.
+func (a *App) AcceptStagedFile(sessionID, path string) error {
+	return a.fs.AcceptFile(sessionID, path)
+}
+
+// RejectStagedFile discards a staged file.
+func (a *App) RejectStagedFile(sessionID, path string) error {
+	return a.fs.RejectFile(sessionID, path)
+}
+
+// AcceptStagedHunk writes one hunk of a staged file to disk. Hunk indexes
+// refer to the list returned by ListStagedChanges.
+func (a *App) AcceptStagedHunk(sessionID, path string, hunkIndex int) error {
+	return a.fs.AcceptHunk(sessionID, path, hunkIndex)
+}
+
+// RejectStagedHunk discards one hunk of a staged file.
+func (a *App) RejectStagedHunk(sessionID, path string, hunkIndex int) error {
+	return a.fs.RejectHunk(sessionID, path, hunkIndex)
+}
+
+// handleStagedChanged keeps the tool call that produced a staged file so the
+// accepted change is attributed to it, and notifies the UI.
+func (a *App) handleStagedChanged(sessionID, path string) {
+	key := sessionPathKey(sessionID, path)
+	stillStaged := false
+	for _, f := range a.fs.StagedFiles(sessionID) {
+		if f.Path == path {
+			stillStaged = true
+			break
+		}
+	}
+
+	a.stagedToolCallsMu.Lock()
+	if !stillStaged {
+		delete(a.stagedToolCalls, key)
+	} else if toolCallID := a.openToolCall(sessionID); toolCallID != "" {
+		a.stagedToolCalls[key] = toolCallID
+	}
+	a.stagedToolCallsMu.Unlock()
+
+	events.Emit(a.bus, TopicReviewUpdated, ReviewUpdatedEvent{
+		SessionID: sessionID,
+		Path:      path,
+	})
+}
+
+// stagedToolCall returns the tool call recorded for a staged file.
+func (a *App) stagedToolCall(sessionID, path string) string {
+	a.stagedToolCallsMu.Lock()
+	defer a.stagedToolCallsMu.Unlock()
+	return a.stagedToolCalls[sessionPathKey(sessionID, path)]
+}
+
+func sessionPathKey(sessionID, path string) string {
+	return sessionID + "::" + path
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bytesmith/internal/session"
+)
+
+// ---------------------------------------------------------------------------
+// Session search
+// ---------------------------------------------------------------------------
+
+// SearchSessions runs a full-text search over the messages and tool calls of
+// every stored session and returns the best hits first. Snippets are HTML
+// with the matched terms wrapped in <mark>.
+func (a *App) SearchSessions(query string, filters SearchFiltersInfo) ([]SearchHitInfo, error) {
+	filter := session.SearchFilter{
+		AgentName: strings.TrimSpace(filters.AgentName),
+		CWD:       strings.TrimSpace(filters.CWD),
+		Limit:     filters.Limit,
+	}
+
+	var err error
+	if filter.From, err = parseFilterTime(filters.From, false); err != nil {
+		return nil, fmt.Errorf("invalid from date: %w", err)
+	}
+	if filter.To, err = parseFilterTime(filters.To, true); err != nil {
+		return nil, fmt.Errorf("invalid to date: %w", err)
+	}
+
+	hits, err := a.sessions.Search(query, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SearchHitInfo, 0, len(hits))
+	for _, h := range hits {
+		result = append(result, SearchHitInfo{
+			Kind:       h.Kind,
+			SessionID:  h.SessionID,
+			AgentName:  h.AgentName,
+			CWD:        h.CWD,
+			MessageID:  h.MessageID,
+			ToolCallID: h.ToolCallID,
+			Role:       h.Role,
+			Snippet:    h.Snippet,
+			Timestamp:  h.Timestamp.Format(time.RFC3339),
+			Score:      h.Score,
+		})
+	}
+	return result, nil
+}
+
+// parseFilterTime accepts RFC 3339 timestamps and plain dates. A plain date
+// stands for the start of the day, or its end when endOfDay is set.
+func parseFilterTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
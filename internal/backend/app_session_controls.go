@@ -6,8 +6,7 @@ import (
 	"strings"
 
 	"bytesmith/internal/acp"
-
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"bytesmith/internal/events"
 )
 
 // ---------------------------------------------------------------------------
@@ -55,11 +54,11 @@ func (a *App) SetSessionModel(connectionID, sessionID, modelID string) error {
 	a.sessionModelsMu.Unlock()
 
 	if ok {
-		wailsRuntime.EventsEmit(a.ctx, "agent:models", map[string]interface{}{
-			"connectionId":   connectionID,
-			"sessionId":      sessionID,
-			"currentModelId": resolvedModelID,
-			"models":         info.Models,
+		events.Emit(a.bus, TopicAgentModels, AgentModelsEvent{
+			ConnectionID:   connectionID,
+			SessionID:      sessionID,
+			CurrentModelID: resolvedModelID,
+			Models:         info.Models,
 		})
 	}
 
@@ -285,19 +284,19 @@ func resolveCanonicalModelID(models []SessionModelInfo, requested string) string
 }
 
 func (a *App) emitSessionModes(connectionID, sessionID string, info SessionModesInfo) {
-	wailsRuntime.EventsEmit(a.ctx, "agent:modes", map[string]interface{}{
-		"connectionId":  connectionID,
-		"sessionId":     sessionID,
-		"currentModeId": info.CurrentModeID,
-		"modes":         info.Modes,
+	events.Emit(a.bus, TopicAgentModes, AgentModesEvent{
+		ConnectionID:  connectionID,
+		SessionID:     sessionID,
+		CurrentModeID: info.CurrentModeID,
+		Modes:         info.Modes,
 	})
 }
 
 func (a *App) emitSessionAccessModes(connectionID, sessionID string, info SessionModesInfo) {
-	wailsRuntime.EventsEmit(a.ctx, "agent:access-modes", map[string]interface{}{
-		"connectionId":  connectionID,
-		"sessionId":     sessionID,
-		"currentModeId": info.CurrentModeID,
-		"modes":         info.Modes,
+	events.Emit(a.bus, TopicAgentAccessModes, AgentModesEvent{
+		ConnectionID:  connectionID,
+		SessionID:     sessionID,
+		CurrentModeID: info.CurrentModeID,
+		Modes:         info.Modes,
 	})
 }
@@ -3,13 +3,20 @@ package backend
 import (
 	"fmt"
 	"time"
+
+	"bytesmith/internal/session"
 )
 
 // ---------------------------------------------------------------------------
 // Session history and reattach
 // ---------------------------------------------------------------------------
 
+// defaultHistoryPageSize is the page size of GetSessionHistoryPage when the
+// caller does not pick one.
+const defaultHistoryPageSize = 100
+
 // GetSessionHistory returns the full conversation history for a session.
+// Long sessions should be read with GetSessionHistoryPage instead.
 func (a *App) GetSessionHistory(sessionID string) *SessionHistoryInfo {
 	rec := a.sessions.Get(sessionID)
 	if rec == nil {
@@ -18,12 +25,7 @@ func (a *App) GetSessionHistory(sessionID string) *SessionHistoryInfo {
 
 	messages := make([]MessageInfo, 0, len(rec.Messages))
 	for _, m := range rec.Messages {
-		messages = append(messages, MessageInfo{
-			ID:        m.ID,
-			Role:      m.Role,
-			Content:   m.Content,
-			Timestamp: m.Timestamp.Format(time.RFC3339),
-		})
+		messages = append(messages, toMessageInfo(m))
 	}
 
 	toolCalls := make([]ToolCallInfo, 0, len(rec.ToolCalls))
@@ -36,6 +38,8 @@ func (a *App) GetSessionHistory(sessionID string) *SessionHistoryInfo {
 		AgentName:    rec.AgentName,
 		ConnectionID: rec.ConnectionID,
 		CWD:          rec.CWD,
+		Title:        rec.Title,
+		Tags:         rec.Tags,
 		Messages:     messages,
 		ToolCalls:    toolCalls,
 		CreatedAt:    rec.CreatedAt.Format(time.RFC3339),
@@ -43,28 +47,80 @@ func (a *App) GetSessionHistory(sessionID string) *SessionHistoryInfo {
 	}
 }
 
-// ListSessions returns lightweight summaries for all sessions.
-func (a *App) ListSessions() []SessionListItem {
-	records := a.sessions.List()
-	result := make([]SessionListItem, 0, len(records))
-	for _, r := range records {
-		result = append(result, SessionListItem{
-			ID:           r.ID,
-			AgentName:    r.AgentName,
-			ConnectionID: r.ConnectionID,
-			CWD:          r.CWD,
-			MessageCount: len(r.Messages),
-			CreatedAt:    r.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:    r.UpdatedAt.Format(time.RFC3339),
-		})
+// GetSessionHistoryPage returns at most limit messages of a session,
+// skipping the offset newest ones, with the tool calls made alongside them.
+// Offset zero is the end of the conversation; older pages are loaded by
+// raising the offset while HasMore is set.
+func (a *App) GetSessionHistoryPage(sessionID string, offset, limit int) (*SessionHistoryPageInfo, error) {
+	summary := a.sessions.GetSummary(sessionID)
+	if summary == nil {
+		return nil, fmt.Errorf("session %q not found", sessionID)
+	}
+	if limit <= 0 {
+		limit = defaultHistoryPageSize
+	}
+
+	page := a.sessions.ListMessages(sessionID, offset, limit)
+	messages := make([]MessageInfo, 0, len(page.Messages))
+	for _, m := range page.Messages {
+		messages = append(messages, toMessageInfo(m))
+	}
+	toolCalls := make([]ToolCallInfo, 0, len(page.ToolCalls))
+	for _, tc := range page.ToolCalls {
+		toolCalls = append(toolCalls, toToolCallInfo(tc))
+	}
+
+	return &SessionHistoryPageInfo{
+		Session:       toSessionListItem(*summary),
+		Messages:      messages,
+		ToolCalls:     toolCalls,
+		Offset:        page.Offset,
+		TotalMessages: page.Total,
+		HasMore:       page.HasMore(),
+	}, nil
+}
+
+// ListSessions returns lightweight summaries for the sessions matching
+// filters, pinned first and then most recently updated.
+func (a *App) ListSessions(filters SessionListFiltersInfo) []SessionListItem {
+	summaries, _ := a.sessions.ListSummaries(session.ListOptions{
+		Tags:     filters.Tags,
+		Archived: filters.Archived,
+	})
+	result := make([]SessionListItem, 0, len(summaries))
+	for _, s := range summaries {
+		result = append(result, toSessionListItem(s))
 	}
 	return result
 }
 
+// ListSessionsPage returns one page of persisted session summaries, pinned
+// first and then newest.
+func (a *App) ListSessionsPage(opts SessionListOptionsInfo) StoredSessionPage {
+	summaries, total := a.sessions.ListSummaries(session.ListOptions{
+		SortBy:       opts.SortBy,
+		ConnectionID: opts.ConnectionID,
+		Tags:         opts.Tags,
+		Archived:     opts.Archived,
+		Offset:       max(opts.Offset, 0),
+		Limit:        max(opts.Limit, 0),
+	})
+
+	items := make([]SessionListItem, 0, len(summaries))
+	for _, s := range summaries {
+		items = append(items, toSessionListItem(s))
+	}
+	return StoredSessionPage{
+		Sessions: items,
+		Total:    total,
+		HasMore:  max(opts.Offset, 0)+len(items) < total,
+	}
+}
+
 // ResumeHistoricalSession opens a previously persisted session and tries to
 // reattach it to a live connection for continued chat.
 func (a *App) ResumeHistoricalSession(sessionID string) (ResumeHistoricalResult, error) {
-	rec := a.sessions.Get(sessionID)
+	rec := a.sessions.GetSummary(sessionID)
 	if rec == nil {
 		return ResumeHistoricalResult{}, fmt.Errorf("session %q not found", sessionID)
 	}
@@ -100,3 +156,30 @@ func (a *App) ResumeHistoricalSession(sessionID string) (ResumeHistoricalResult,
 	result.Resumed = true
 	return result, nil
 }
+
+func toMessageInfo(m session.Message) MessageInfo {
+	return MessageInfo{
+		ID:        m.ID,
+		Role:      m.Role,
+		Kind:      m.Kind,
+		Content:   m.Content,
+		Timestamp: m.Timestamp.Format(time.RFC3339),
+	}
+}
+
+func toSessionListItem(s session.SessionSummary) SessionListItem {
+	return SessionListItem{
+		ID:            s.ID,
+		AgentName:     s.AgentName,
+		ConnectionID:  s.ConnectionID,
+		CWD:           s.CWD,
+		Title:         s.Title,
+		Tags:          s.Tags,
+		Pinned:        s.Pinned,
+		Archived:      s.Archived,
+		MessageCount:  s.MessageCount,
+		ToolCallCount: s.ToolCallCount,
+		CreatedAt:     s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     s.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"bytesmith/internal/events"
+	"bytesmith/internal/session"
+)
+
+// ---------------------------------------------------------------------------
+// Session metadata
+// ---------------------------------------------------------------------------
+
+// RenameSession sets the title of a stored session. An empty title clears
+// it, and the next user prompt names the session again.
+func (a *App) RenameSession(sessionID, title string) (SessionListItem, error) {
+	title = strings.TrimSpace(title)
+	return a.updateSessionMetadata(sessionID, func() { a.sessions.SetTitle(sessionID, title) })
+}
+
+// SetSessionTags replaces the tags of a stored session.
+func (a *App) SetSessionTags(sessionID string, tags []string) (SessionListItem, error) {
+	return a.updateSessionMetadata(sessionID, func() { a.sessions.SetTags(sessionID, tags) })
+}
+
+// SetSessionPinned pins a stored session to the top of the list, or unpins
+// it.
+func (a *App) SetSessionPinned(sessionID string, pinned bool) (SessionListItem, error) {
+	return a.updateSessionMetadata(sessionID, func() { a.sessions.SetPinned(sessionID, pinned) })
+}
+
+// SetSessionArchived hides a stored session from the default list, or
+// restores it.
+func (a *App) SetSessionArchived(sessionID string, archived bool) (SessionListItem, error) {
+	return a.updateSessionMetadata(sessionID, func() { a.sessions.SetArchived(sessionID, archived) })
+}
+
+// updateSessionMetadata applies update to an existing session and emits
+// "session:updated" with the new summary.
+func (a *App) updateSessionMetadata(sessionID string, update func()) (SessionListItem, error) {
+	if a.sessions.GetSummary(sessionID) == nil {
+		return SessionListItem{}, fmt.Errorf("session %q not found", sessionID)
+	}
+	update()
+
+	summary := a.sessions.GetSummary(sessionID)
+	if summary == nil {
+		return SessionListItem{}, fmt.Errorf("session %q not found", sessionID)
+	}
+	item := toSessionListItem(*summary)
+	events.Emit(a.bus, TopicSessionUpdated, item)
+	return item, nil
+}
+
+// autoTitleSession names an untitled session after a user prompt.
+func (a *App) autoTitleSession(sessionID, prompt string) {
+	a.titleSessionIfUntitled(sessionID, session.TitleFromPrompt(prompt))
+}
+
+// rememberRemoteTitle keeps the title an agent listed for a session. The
+// title is applied now if the session is stored, or when it is opened.
+func (a *App) rememberRemoteTitle(sessionID, title string) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return
+	}
+	a.remoteTitlesMu.Lock()
+	a.remoteTitles[sessionID] = title
+	a.remoteTitlesMu.Unlock()
+	a.applyRemoteTitle(sessionID)
+}
+
+// applyRemoteTitle names an untitled stored session after the title its
+// agent listed for it.
+func (a *App) applyRemoteTitle(sessionID string) {
+	a.remoteTitlesMu.Lock()
+	title := a.remoteTitles[sessionID]
+	a.remoteTitlesMu.Unlock()
+	a.titleSessionIfUntitled(sessionID, title)
+}
+
+func (a *App) titleSessionIfUntitled(sessionID, title string) {
+	if title == "" {
+		return
+	}
+	summary := a.sessions.GetSummary(sessionID)
+	if summary == nil || summary.Title != "" {
+		return
+	}
+	_, _ = a.updateSessionMetadata(sessionID, func() { a.sessions.SetTitle(sessionID, title) })
+}
@@ -6,10 +6,10 @@ import (
 	"time"
 
 	"bytesmith/internal/acp"
+	"bytesmith/internal/events"
 	"bytesmith/internal/session"
 
 	"github.com/google/uuid"
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // ---------------------------------------------------------------------------
@@ -24,15 +24,19 @@ func (a *App) NewSession(connectionID, cwd string) (string, error) {
 		return "", fmt.Errorf("connection %q not found", connectionID)
 	}
 
-	result, err := conn.Client.NewSession(context.Background(), cwd, nil)
+	mcpServers, mcpToken := a.sessionMCPServers(conn, cwd)
+	result, err := conn.Client.NewSession(context.Background(), cwd, mcpServers)
 	if err != nil {
-		return "", err
+		a.bindBuiltinMCP(mcpToken, "", err)
+		return "", a.handleAuthRequired(conn, err, func() (string, error) {
+			return a.NewSession(connectionID, cwd)
+		})
 	}
 	sessionID := result.SessionID
+	a.bindBuiltinMCP(mcpToken, sessionID, nil)
 
 	// Track session locally.
-	a.sessions.Create(sessionID, conn.Agent.Name, connectionID, cwd)
-	appendSessionIfMissing(conn, sessionID)
+	a.trackSession(conn, sessionID, cwd)
 
 	if result.Models != nil {
 		models := make([]SessionModelInfo, 0, len(result.Models.AvailableModels))
@@ -52,11 +56,11 @@ func (a *App) NewSession(connectionID, cwd string) (string, error) {
 		a.sessionModels[sessionID] = info
 		a.sessionModelsMu.Unlock()
 
-		wailsRuntime.EventsEmit(a.ctx, "agent:models", map[string]interface{}{
-			"connectionId":   connectionID,
-			"sessionId":      sessionID,
-			"currentModelId": info.CurrentModelID,
-			"models":         info.Models,
+		events.Emit(a.bus, TopicAgentModels, AgentModelsEvent{
+			ConnectionID:   connectionID,
+			SessionID:      sessionID,
+			CurrentModelID: info.CurrentModelID,
+			Models:         info.Models,
 		})
 	}
 
@@ -80,75 +84,145 @@ func (a *App) NewSession(connectionID, cwd string) (string, error) {
 // SendPrompt sends a user prompt to the agent asynchronously. Real-time
 // updates arrive via Wails events ("agent:message", "agent:toolcall", etc.).
 // When the agent finishes, an "agent:prompt-done" event is emitted.
+//
+// A session runs one turn at a time: a prompt sent while a turn is running
+// is queued and starts when the turns before it have ended. Changes to the
+// queue are emitted as "agent:prompt-queue".
 func (a *App) SendPrompt(connectionID, sessionID, text string) error {
 	conn := a.manager.GetConnection(connectionID)
 	if conn == nil {
 		return fmt.Errorf("connection %q not found", connectionID)
 	}
 
-	// Record the user message.
+	a.activePromptsMu.Lock()
+	if _, running := a.activePrompts[sessionID]; running {
+		a.enqueuePromptLocked(sessionID, &queuedPrompt{
+			id:           uuid.NewString(),
+			connectionID: connectionID,
+			text:         text,
+			queuedAt:     time.Now(),
+		})
+		queue := a.promptQueueLocked(sessionID)
+		a.activePromptsMu.Unlock()
+		a.emitPromptQueue(sessionID, queue, nil)
+		return nil
+	}
+	prompt := a.startPromptLocked(connectionID, sessionID)
+	a.activePromptsMu.Unlock()
+
+	a.recordUserPrompt(sessionID, uuid.NewString(), text)
+	go a.runPrompts(prompt, sessionID, text)
+	return nil
+}
+
+// startPromptLocked registers a turn as running in a session.
+func (a *App) startPromptLocked(connectionID, sessionID string) *activePrompt {
+	// A prompt can take a very long time; use a generous timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
+	prompt := &activePrompt{connectionID: connectionID, ctx: ctx, cancel: cancel}
+	a.activePrompts[sessionID] = prompt
+	return prompt
+}
+
+// recordUserPrompt records the user message of a turn that starts.
+func (a *App) recordUserPrompt(sessionID, messageID, text string) {
 	a.sessions.AddMessage(sessionID, session.Message{
-		ID:      uuid.NewString(),
+		ID:      messageID,
 		Role:    "user",
 		Content: text,
 	})
+	a.autoTitleSession(sessionID, text)
+}
 
-	go func() {
-		// A prompt can take a very long time; use a generous timeout.
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
-		defer cancel()
+// runPrompts runs a turn, then the prompts queued in the session one after
+// another until the queue is empty.
+func (a *App) runPrompts(prompt *activePrompt, sessionID, text string) {
+	for {
+		a.runPrompt(prompt, sessionID, text)
 
 		a.activePromptsMu.Lock()
-		a.activePrompts[sessionID] = cancel
-		a.activePromptsMu.Unlock()
-
-		defer func() {
-			a.activePromptsMu.Lock()
+		prompt.cancel()
+		next := a.popQueuedPromptLocked(sessionID)
+		if next == nil {
 			delete(a.activePrompts, sessionID)
 			a.activePromptsMu.Unlock()
-		}()
-
-		prompt := []acp.ContentBlock{
-			{Type: "text", Text: text},
-		}
-
-		result, err := conn.Client.Prompt(ctx, sessionID, prompt)
-		if err != nil {
-			a.finalizeStreamMessage(connectionID, sessionID)
-			wailsRuntime.EventsEmit(a.ctx, "agent:error", map[string]string{
-				"connectionId": connectionID,
-				"sessionId":    sessionID,
-				"error":        err.Error(),
-			})
 			return
 		}
+		prompt = a.startPromptLocked(next.connectionID, sessionID)
+		queue := a.promptQueueLocked(sessionID)
+		a.activePromptsMu.Unlock()
 
-		a.finalizeStreamMessage(connectionID, sessionID)
-		wailsRuntime.EventsEmit(a.ctx, "agent:prompt-done", map[string]string{
-			"connectionId": connectionID,
-			"sessionId":    sessionID,
-			"stopReason":   result.StopReason,
+		started := next.info()
+		a.emitPromptQueue(sessionID, queue, &started)
+		a.recordUserPrompt(sessionID, next.id, next.text)
+		text = next.text
+	}
+}
+
+// runPrompt runs one turn and reports how it ended.
+func (a *App) runPrompt(prompt *activePrompt, sessionID, text string) {
+	connectionID := prompt.connectionID
+	conn := a.manager.GetConnection(connectionID)
+	if conn == nil {
+		events.Emit(a.bus, TopicAgentError, AgentErrorEvent{
+			ConnectionID: connectionID,
+			SessionID:    sessionID,
+			Error:        fmt.Sprintf("connection %q not found", connectionID),
 		})
-	}()
+		return
+	}
 
-	return nil
+	result, err := conn.Client.Prompt(prompt.ctx, sessionID, []acp.ContentBlock{
+		{Type: "text", Text: text},
+	})
+	a.finalizeStreamMessage(connectionID, sessionID)
+	if err != nil {
+		events.Emit(a.bus, TopicAgentError, AgentErrorEvent{
+			ConnectionID: connectionID,
+			SessionID:    sessionID,
+			Error:        err.Error(),
+		})
+		return
+	}
+
+	events.Emit(a.bus, TopicPromptDone, PromptDoneEvent{
+		ConnectionID: connectionID,
+		SessionID:    sessionID,
+		StopReason:   result.StopReason,
+	})
 }
 
 // CancelPrompt cancels an in-progress prompt by sending the ACP cancel
-// notification and aborting the local context.
-func (a *App) CancelPrompt(connectionID, sessionID string) error {
-	// Cancel the local context so the Prompt call unblocks.
+// notification and aborting the local context. The next queued prompt
+// then starts, unless flushQueue is set, in which case the session's
+// queue is dropped first.
+func (a *App) CancelPrompt(connectionID, sessionID string, flushQueue bool) error {
+	if flushQueue {
+		a.flushPromptQueue(sessionID)
+	}
+
+	// Take the running turn first: once the agent acknowledges the cancel,
+	// the next queued prompt may start, and it must not be cancelled too.
 	a.activePromptsMu.Lock()
-	cancel, ok := a.activePrompts[sessionID]
+	prompt, ok := a.activePrompts[sessionID]
 	a.activePromptsMu.Unlock()
-	if ok {
-		cancel()
+
+	// Resolve the turn's pending requests while it is still the running
+	// one, so a request of the next turn cannot be caught by this cancel.
+	a.cancelSessionRequests(sessionID)
+
+	// Tell the agent to stop before unblocking the Prompt call, so the
+	// next queued prompt cannot reach it ahead of the cancel.
+	var err error
+	if conn := a.manager.GetConnection(connectionID); conn == nil {
+		err = fmt.Errorf("connection %q not found", connectionID)
+	} else {
+		err = conn.Client.Cancel(sessionID)
 	}
 
-	// Also tell the agent to stop.
-	conn := a.manager.GetConnection(connectionID)
-	if conn == nil {
-		return fmt.Errorf("connection %q not found", connectionID)
+	// Cancel the local context so the Prompt call unblocks.
+	if ok {
+		prompt.cancel()
 	}
-	return conn.Client.Cancel(sessionID)
+	return err
 }
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"sort"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Session state rehydration
+// ---------------------------------------------------------------------------
+
+// GetSessionState returns everything the UI needs to rebuild a session view
+// after a reload: model and mode selections, the permission and question
+// requests the agent is still waiting on, the message being streamed and
+// whether a prompt is running.
+func (a *App) GetSessionState(sessionID string) SessionStateInfo {
+	state := SessionStateInfo{
+		SessionID:          sessionID,
+		Models:             a.GetSessionModels(sessionID),
+		Modes:              a.GetSessionModes(sessionID),
+		AccessModes:        a.GetSessionAccessModes(sessionID),
+		PendingPermissions: a.pendingPermissionInfos(sessionID),
+		PendingQuestions:   a.pendingQuestionInfos(sessionID),
+		StreamingMessage:   a.streamingMessageInfo(sessionID),
+		ReviewMode:         a.fs.ReviewMode(sessionID),
+		StagedFiles:        len(a.fs.StagedFiles(sessionID)),
+	}
+
+	a.activePromptsMu.Lock()
+	if p, ok := a.activePrompts[sessionID]; ok {
+		state.PromptActive = true
+		state.ConnectionID = p.connectionID
+	}
+	state.PromptQueue = a.promptQueueLocked(sessionID)
+	a.activePromptsMu.Unlock()
+
+	if state.ConnectionID == "" && a.sessions != nil {
+		if rec := a.sessions.GetSummary(sessionID); rec != nil {
+			state.ConnectionID = rec.ConnectionID
+		}
+	}
+
+	return state
+}
+
+func (a *App) pendingPermissionInfos(sessionID string) []PermissionRequestInfo {
+	a.pendingPermissionsMu.Lock()
+	pending := make([]*pendingPermission, 0, len(a.pendingPermissions))
+	for _, p := range a.pendingPermissions {
+		if p.sessionID == sessionID {
+			pending = append(pending, p)
+		}
+	}
+	a.pendingPermissionsMu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].createdAt.Before(pending[j].createdAt)
+	})
+
+	result := make([]PermissionRequestInfo, 0, len(pending))
+	for _, p := range pending {
+		result = append(result, p.info)
+	}
+	return result
+}
+
+func (a *App) pendingQuestionInfos(sessionID string) []QuestionRequestInfo {
+	a.pendingQuestionsMu.Lock()
+	pending := make([]*pendingQuestion, 0, len(a.pendingQuestions))
+	for _, q := range a.pendingQuestions {
+		if q.sessionID == sessionID {
+			pending = append(pending, q)
+		}
+	}
+	a.pendingQuestionsMu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].createdAt.Before(pending[j].createdAt)
+	})
+
+	result := make([]QuestionRequestInfo, 0, len(pending))
+	for _, q := range pending {
+		result = append(result, q.info)
+	}
+	return result
+}
+
+func (a *App) streamingMessageInfo(sessionID string) *StreamingMessageInfo {
+	a.streamMessagesMu.Lock()
+	defer a.streamMessagesMu.Unlock()
+
+	stream := a.streamMessages[sessionID]
+	if stream == nil {
+		return nil
+	}
+	return &StreamingMessageInfo{
+		MessageID: stream.MessageID,
+		Type:      normalizeMessageType(stream.ContentType),
+		Content:   stream.Content.String(),
+		StartedAt: stream.StartedAt.Format(time.RFC3339),
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"strings"
 	"time"
 
+	"bytesmith/internal/events"
 	"bytesmith/internal/session"
 
 	"github.com/google/uuid"
-	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 func (a *App) appendStreamChunk(connectionID, sessionID, text, contentType string) {
@@ -41,13 +41,12 @@ func (a *App) appendStreamChunk(connectionID, sessionID, text, contentType strin
 		a.flushStreamMessage(connectionID, sessionID, previous)
 	}
 
-	wailsRuntime.EventsEmit(a.ctx, "agent:message", map[string]interface{}{
-		"connectionId": connectionID,
-		"sessionId":    sessionID,
-		"messageId":    messageID,
-		"text":         text,
-		"type":         chunkType,
-		"isFinal":      false,
+	events.Emit(a.bus, TopicAgentMessage, AgentMessageEvent{
+		ConnectionID: connectionID,
+		SessionID:    sessionID,
+		MessageID:    messageID,
+		Text:         text,
+		Type:         chunkType,
 	})
 }
 
@@ -80,17 +79,17 @@ func (a *App) flushStreamMessage(connectionID, sessionID string, stream *streamM
 	a.sessions.AddMessage(sessionID, session.Message{
 		ID:        stream.MessageID,
 		Role:      "agent",
+		Kind:      normalizeMessageType(stream.ContentType),
 		Content:   content,
 		Timestamp: stream.StartedAt,
 	})
 
-	wailsRuntime.EventsEmit(a.ctx, "agent:message", map[string]interface{}{
-		"connectionId": connectionID,
-		"sessionId":    sessionID,
-		"messageId":    stream.MessageID,
-		"text":         "",
-		"type":         normalizeMessageType(stream.ContentType),
-		"isFinal":      true,
-		"content":      content,
+	events.Emit(a.bus, TopicAgentMessage, AgentMessageEvent{
+		ConnectionID: connectionID,
+		SessionID:    sessionID,
+		MessageID:    stream.MessageID,
+		Type:         normalizeMessageType(stream.ContentType),
+		IsFinal:      true,
+		Content:      content,
 	})
 }
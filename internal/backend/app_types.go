@@ -6,9 +6,12 @@ import (
 	"sync"
 	"time"
 
-	"bytesmith/internal/acp"
 	"bytesmith/internal/agent"
+	"bytesmith/internal/controlapi"
+	"bytesmith/internal/events"
 	bfs "bytesmith/internal/fs"
+	"bytesmith/internal/mcpserver"
+	"bytesmith/internal/policy"
 	"bytesmith/internal/session"
 	"bytesmith/internal/terminal"
 	"bytesmith/internal/uixterm"
@@ -36,6 +39,41 @@ type ConnectionInfo struct {
 	DisplayName string   `json:"displayName"`
 	Sessions    []string `json:"sessions"`
 	Integrator  string   `json:"integrator"`
+
+	Capabilities ConnectionCapabilitiesInfo `json:"capabilities"`
+	AuthMethods  []AuthMethodInfo           `json:"authMethods"`
+}
+
+// AuthMethodInfo is one way of logging in that an agent offers.
+type AuthMethodInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// AuthRequiredInfo is emitted when an agent refuses a session operation
+// until the user authenticates.
+type AuthRequiredInfo struct {
+	ConnectionID string           `json:"connectionId"`
+	AgentName    string           `json:"agentName"`
+	Methods      []AuthMethodInfo `json:"methods"`
+	Error        string           `json:"error"`
+}
+
+// ConnectionCapabilitiesInfo is what a connection negotiated with its agent,
+// so the UI can hide controls the agent cannot serve.
+type ConnectionCapabilitiesInfo struct {
+	ListSessions    bool `json:"listSessions"`
+	LoadSession     bool `json:"loadSession"`
+	ResumeSession   bool `json:"resumeSession"`
+	SetMode         bool `json:"setMode"`
+	SetModel        bool `json:"setModel"`
+	SetConfigOption bool `json:"setConfigOption"`
+	ImageInput      bool `json:"imageInput"`
+	AudioInput      bool `json:"audioInput"`
+	EmbeddedContext bool `json:"embeddedContext"`
+	MCPHTTP         bool `json:"mcpHttp"`
+	MCPSSE          bool `json:"mcpSse"`
 }
 
 // SessionHistoryInfo carries the full conversation history for one session.
@@ -44,16 +82,39 @@ type SessionHistoryInfo struct {
 	AgentName    string         `json:"agentName"`
 	ConnectionID string         `json:"connectionId"`
 	CWD          string         `json:"cwd"`
+	Title        string         `json:"title,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
 	Messages     []MessageInfo  `json:"messages"`
 	ToolCalls    []ToolCallInfo `json:"toolCalls"`
 	CreatedAt    string         `json:"createdAt"`
 	UpdatedAt    string         `json:"updatedAt"`
 }
 
+// HistoryImportInfo summarizes an import of history recorded outside
+// ByteSmith.
+type HistoryImportInfo struct {
+	Sessions  int      `json:"sessions"`
+	Created   int      `json:"created"`
+	Messages  int      `json:"messages"`
+	ToolCalls int      `json:"toolCalls"`
+	Skipped   []string `json:"skipped,omitempty"`
+}
+
+// SessionHistoryPageInfo carries one page of a session's history.
+type SessionHistoryPageInfo struct {
+	Session       SessionListItem `json:"session"`
+	Messages      []MessageInfo   `json:"messages"`
+	ToolCalls     []ToolCallInfo  `json:"toolCalls"`
+	Offset        int             `json:"offset"`
+	TotalMessages int             `json:"totalMessages"`
+	HasMore       bool            `json:"hasMore"`
+}
+
 // MessageInfo is a single message in a session's conversation.
 type MessageInfo struct {
 	ID        string `json:"id"`
 	Role      string `json:"role"`
+	Kind      string `json:"kind,omitempty"` // "text" or "thought"
 	Content   string `json:"content"`
 	Timestamp string `json:"timestamp"`
 }
@@ -89,13 +150,41 @@ type ToolCallDiffSummaryInfo struct {
 
 // SessionListItem is a lightweight summary for the session list view.
 type SessionListItem struct {
-	ID           string `json:"id"`
-	AgentName    string `json:"agentName"`
-	ConnectionID string `json:"connectionId"`
-	CWD          string `json:"cwd"`
-	MessageCount int    `json:"messageCount"`
-	CreatedAt    string `json:"createdAt"`
-	UpdatedAt    string `json:"updatedAt"`
+	ID            string   `json:"id"`
+	AgentName     string   `json:"agentName"`
+	ConnectionID  string   `json:"connectionId"`
+	CWD           string   `json:"cwd"`
+	Title         string   `json:"title,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Pinned        bool     `json:"pinned,omitempty"`
+	Archived      bool     `json:"archived,omitempty"`
+	MessageCount  int      `json:"messageCount"`
+	ToolCallCount int      `json:"toolCallCount"`
+	CreatedAt     string   `json:"createdAt"`
+	UpdatedAt     string   `json:"updatedAt"`
+}
+
+// SessionListFiltersInfo narrows the persisted session list.
+type SessionListFiltersInfo struct {
+	Tags     []string `json:"tags,omitempty"`     // sessions carrying every tag
+	Archived string   `json:"archived,omitempty"` // "" hides archived sessions, "only" or "include"
+}
+
+// SessionListOptionsInfo selects a page of the persisted session list.
+type SessionListOptionsInfo struct {
+	SortBy       string   `json:"sortBy,omitempty"` // "updated" (default) or "created"
+	ConnectionID string   `json:"connectionId,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Archived     string   `json:"archived,omitempty"`
+	Offset       int      `json:"offset,omitempty"`
+	Limit        int      `json:"limit,omitempty"`
+}
+
+// StoredSessionPage is one page of the persisted session list.
+type StoredSessionPage struct {
+	Sessions []SessionListItem `json:"sessions"`
+	Total    int               `json:"total"`
+	HasMore  bool              `json:"hasMore"`
 }
 
 // AppSettingsInfo mirrors agent.AppSettings for frontend consumption.
@@ -104,6 +193,11 @@ type AppSettingsInfo struct {
 	DefaultAgent string `json:"defaultAgent"`
 	DefaultCWD   string `json:"defaultCwd"`
 	AutoApprove  bool   `json:"autoApprove"`
+
+	RequestTimeoutSeconds int    `json:"requestTimeoutSeconds"`
+	TimeoutDecision       string `json:"timeoutDecision"`
+	DisableBuiltinMCP     bool   `json:"disableBuiltinMcp"`
+	ControlAPI            string `json:"controlApi"`
 }
 
 // FileEntry represents a single file or directory for the file explorer.
@@ -124,6 +218,24 @@ type PermissionRequestInfo struct {
 	Title        string                 `json:"title"`
 	Kind         string                 `json:"kind"`
 	Options      []PermissionOptionInfo `json:"options"`
+
+	// PolicyRuleID is set when a policy rule explicitly asked for this
+	// request to be shown.
+	PolicyRuleID string `json:"policyRuleId,omitempty"`
+}
+
+// PermissionDecisionInfo is emitted when the permission policy answers a
+// request without asking the user.
+type PermissionDecisionInfo struct {
+	ConnectionID string `json:"connectionId"`
+	SessionID    string `json:"sessionId"`
+	ToolCallID   string `json:"toolCallId"`
+	Title        string `json:"title"`
+	Kind         string `json:"kind"`
+	Decision     string `json:"decision"`
+	OptionID     string `json:"optionId"`
+	RuleID       string `json:"ruleId"`
+	Reason       string `json:"reason"`
 }
 
 // PermissionOptionInfo is one choice in a permission dialog.
@@ -160,6 +272,30 @@ type QuestionOptionInfo struct {
 	Description string `json:"description"`
 }
 
+// SearchFiltersInfo narrows SearchSessions. Dates are RFC 3339 timestamps
+// or YYYY-MM-DD; empty fields do not filter.
+type SearchFiltersInfo struct {
+	AgentName string `json:"agentName"`
+	CWD       string `json:"cwd"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Limit     int    `json:"limit"`
+}
+
+// SearchHitInfo is one message or tool call matching a search.
+type SearchHitInfo struct {
+	Kind       string  `json:"kind"` // "message" or "tool_call"
+	SessionID  string  `json:"sessionId"`
+	AgentName  string  `json:"agentName"`
+	CWD        string  `json:"cwd"`
+	MessageID  string  `json:"messageId,omitempty"`
+	ToolCallID string  `json:"toolCallId,omitempty"`
+	Role       string  `json:"role"`
+	Snippet    string  `json:"snippet"`
+	Timestamp  string  `json:"timestamp"`
+	Score      float64 `json:"score"`
+}
+
 // SessionModelInfo is one available model option for a session.
 type SessionModelInfo struct {
 	ModelID string `json:"modelId"`
@@ -184,6 +320,105 @@ type SessionModesInfo struct {
 	Modes         []SessionModeInfo `json:"modes"`
 }
 
+// FileChangeInfo is one file write made by an agent.
+type FileChangeInfo struct {
+	ID         string `json:"id"`
+	SessionID  string `json:"sessionId"`
+	ToolCallID string `json:"toolCallId"`
+	AgentName  string `json:"agentName"`
+	Path       string `json:"path"`
+	OldContent string `json:"oldContent"`
+	NewContent string `json:"newContent"`
+	Created    bool   `json:"created"`
+	Status     string `json:"status"`
+	Timestamp  string `json:"timestamp"`
+	RevertedAt string `json:"revertedAt,omitempty"`
+}
+
+// RevertResultInfo reports the outcome of reverting one file change.
+type RevertResultInfo struct {
+	ChangeID string `json:"changeId"`
+	Path     string `json:"path"`
+	Reverted bool   `json:"reverted"`
+	Conflict bool   `json:"conflict"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// StagedFileInfo is a file held in a session's review overlay.
+type StagedFileInfo struct {
+	Path      string         `json:"path"`
+	Created   bool           `json:"created"`
+	Hunks     []DiffHunkInfo `json:"hunks"`
+	UpdatedAt string         `json:"updatedAt"`
+}
+
+// DiffHunkInfo is one hunk of a line diff.
+type DiffHunkInfo struct {
+	OldStart int            `json:"oldStart"`
+	OldLines int            `json:"oldLines"`
+	NewStart int            `json:"newStart"`
+	NewLines int            `json:"newLines"`
+	Lines    []DiffLineInfo `json:"lines"`
+}
+
+// DiffLineInfo is one line of a hunk. Op is " ", "+" or "-". NoEOL marks
+// the last line of a file without a trailing newline.
+type DiffLineInfo struct {
+	Op    string `json:"op"`
+	Text  string `json:"text"`
+	NoEOL bool   `json:"noEol,omitempty"`
+}
+
+// FileDiffInfo is the diff of one file.
+type FileDiffInfo struct {
+	Path      string         `json:"path"`
+	Created   bool           `json:"created"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Hunks     []DiffHunkInfo `json:"hunks"`
+}
+
+// ToolCallDiffInfo holds the file diffs of one tool call.
+type ToolCallDiffInfo struct {
+	SessionID  string         `json:"sessionId"`
+	ToolCallID string         `json:"toolCallId"`
+	Files      []FileDiffInfo `json:"files"`
+}
+
+// SessionStateInfo is the live state of a session, returned by
+// GetSessionState so the UI can reconstruct itself after a reload.
+type SessionStateInfo struct {
+	SessionID          string                  `json:"sessionId"`
+	ConnectionID       string                  `json:"connectionId"`
+	Models             *SessionModelsInfo      `json:"models"`
+	Modes              *SessionModesInfo       `json:"modes"`
+	AccessModes        *SessionModesInfo       `json:"accessModes"`
+	PendingPermissions []PermissionRequestInfo `json:"pendingPermissions"`
+	PendingQuestions   []QuestionRequestInfo   `json:"pendingQuestions"`
+	StreamingMessage   *StreamingMessageInfo   `json:"streamingMessage"`
+	PromptActive       bool                    `json:"promptActive"`
+	PromptQueue        []QueuedPromptInfo      `json:"promptQueue"`
+	ReviewMode         bool                    `json:"reviewMode"`
+	StagedFiles        int                     `json:"stagedFiles"`
+}
+
+// QueuedPromptInfo is a prompt waiting for the running turn of its session
+// to end.
+type QueuedPromptInfo struct {
+	ID           string `json:"id"`
+	ConnectionID string `json:"connectionId"`
+	Text         string `json:"text"`
+	QueuedAt     string `json:"queuedAt"`
+}
+
+// StreamingMessageInfo is the partially streamed agent message of a session.
+type StreamingMessageInfo struct {
+	MessageID string `json:"messageId"`
+	Type      string `json:"type"`
+	Content   string `json:"content"`
+	StartedAt string `json:"startedAt"`
+}
+
 // SessionListPage is a page of remote sessions queried from an integrator.
 type SessionListPage struct {
 	Sessions    []SessionListItem `json:"sessions"`
@@ -217,11 +452,17 @@ type EmbeddedTerminalInfo struct {
 type App struct {
 	ctx context.Context
 
+	// bus carries every event the backend publishes; the webview is one
+	// of its subscribers.
+	bus *events.Bus
+
 	manager  *agent.Manager
 	config   *agent.Config
 	fs       *bfs.Provider
 	terminal *terminal.Provider
 	uiTerm   *uixterm.Manager
+	mcp      *mcpserver.Server
+	control  *controlapi.Server
 	sessions session.Store
 
 	// sessionModels stores model options returned by session/new per session.
@@ -237,22 +478,61 @@ type App struct {
 	sessionAccessModes   map[string]SessionModesInfo
 	sessionAccessModesMu sync.RWMutex
 
-	// pendingPermissions stores channels keyed by requestID.
+	// policy decides permission requests covered by configured rules.
+	policy   *policy.Engine
+	policyMu sync.RWMutex
+
+	// sessionCWDs caches the working directory of sessions opened in this
+	// run, for policy evaluation.
+	sessionCWDs   map[string]string
+	sessionCWDsMu sync.RWMutex
+
+	// mcpDirTokens holds the built-in MCP token the sessions of an
+	// OpenCode connection share in a directory, keyed by connection ID
+	// and directory.
+	mcpDirTokens   map[string]string
+	mcpDirTokensMu sync.Mutex
+
+	// remoteTitles remembers the titles agents reported in session
+	// listings until the sessions are opened and stored.
+	remoteTitles   map[string]string
+	remoteTitlesMu sync.Mutex
+
+	// pendingPermissions stores waiting requests keyed by requestID.
 	// pendingPermissionOrder stores request IDs FIFO by session+toolCall.
-	pendingPermissions     map[string]chan string
+	pendingPermissions     map[string]*pendingPermission
 	pendingPermissionOrder map[string][]string
 	pendingPermissionsMu   sync.Mutex
 
-	// pendingQuestions stores channels keyed by requestID for
+	// pendingQuestions stores waiting requests keyed by requestID for
 	// item/tool/requestUserInput interactions.
-	pendingQuestions   map[string]chan acp.ToolRequestUserInputResponse
+	pendingQuestions   map[string]*pendingQuestion
 	pendingQuestionsMu sync.Mutex
 
+	// pendingAuth stores, per connection, the session operation to retry
+	// once AuthenticateAgent succeeds.
+	pendingAuth   map[string]func() (string, error)
+	pendingAuthMu sync.Mutex
+
 	// activePrompts tracks running prompt goroutines so CancelPrompt can
-	// both cancel the context and send the ACP cancel notification.
-	activePrompts   map[string]context.CancelFunc
+	// both cancel the context and send the ACP cancel notification. Pending
+	// permissions and questions of a session are bound to its context.
+	// promptQueues holds, per session, the prompts sent while a turn was
+	// running, in the order they will run; activePromptsMu guards both.
+	activePrompts   map[string]*activePrompt
+	promptQueues    map[string][]*queuedPrompt
 	activePromptsMu sync.Mutex
 
+	// openToolCalls holds the latest unfinished tool call per session, used
+	// to attribute agent file writes.
+	openToolCalls   map[string]string
+	openToolCallsMu sync.Mutex
+
+	// stagedToolCalls remembers, per session and path, the tool call that
+	// staged a file in review mode.
+	stagedToolCalls   map[string]string
+	stagedToolCallsMu sync.Mutex
+
 	// streamMessages aggregates streaming chunks so each turn is stored as a
 	// single final agent message.
 	streamMessages   map[string]*streamMessage
@@ -0,0 +1,564 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	bfs "bytesmith/internal/fs"
+	"bytesmith/internal/policy"
+	"bytesmith/internal/session"
+	"bytesmith/internal/terminal"
+)
+
+// AcpProxyCommand serves a configured agent over ACP on stdio, so editors
+// that speak ACP can drive any backend ByteSmith can, including the ones
+// it reaches through their own dialects.
+const AcpProxyCommand = "acp-proxy"
+
+type proxyOptions struct {
+	agent   string
+	cwd     string
+	verbose bool
+}
+
+// parseProxyArgs parses the arguments of the acp-proxy command.
+func parseProxyArgs(args []string, cfg *agent.Config, stderr io.Writer) (proxyOptions, error) {
+	opts := proxyOptions{}
+	flags := flag.NewFlagSet(AcpProxyCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.agent, "agent", cfg.Settings.DefaultAgent, "agent to serve, by config name")
+	flags.StringVar(&opts.cwd, "cwd", ".", "working directory the agent is started in")
+	flags.BoolVar(&opts.verbose, "verbose", false, "copy the agent's stderr to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: bytesmith acp-proxy [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Speaks ACP on stdin/stdout and forwards to the agent.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if flags.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+	if strings.TrimSpace(opts.agent) == "" {
+		return opts, errors.New("no agent given and no default agent configured")
+	}
+	cwd, err := filepath.Abs(opts.cwd)
+	if err != nil {
+		return opts, fmt.Errorf("cwd: %w", err)
+	}
+	opts.cwd = cwd
+	return opts, nil
+}
+
+// runACPProxy implements "bytesmith acp-proxy": it connects to an agent and
+// serves it to the editor on the other end of stdio until the editor goes
+// away. Sessions are recorded in the session store like ones started from
+// the window. Stdout carries the protocol only; diagnostics go to stderr.
+func runACPProxy(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := agent.LoadConfig(agent.ConfigPath())
+	if err != nil {
+		fmt.Fprintln(stderr, "bytesmith: failed to load config, using defaults:", err)
+		cfg = agent.DefaultConfig()
+	}
+
+	opts, err := parseProxyArgs(args, cfg, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintln(stderr, "bytesmith acp-proxy:", err)
+		return exitUsage
+	}
+
+	engine, err := policy.New(cfg.PermissionRules, cfg.Settings.AutoApprove)
+	if err != nil {
+		fmt.Fprintln(stderr, "bytesmith acp-proxy:", err)
+		return exitUsage
+	}
+
+	p := newProxy(opts, cfg, engine, session.NewStore(), stderr)
+	defer p.close()
+
+	conn, err := p.manager.Connect(opts.agent, opts.cwd)
+	if err != nil {
+		fmt.Fprintln(stderr, "bytesmith acp-proxy:", err)
+		return exitFailure
+	}
+	if err := p.serve(ctx, conn, stdioStream{stdin, stdout}); err != nil {
+		fmt.Fprintln(stderr, "bytesmith acp-proxy:", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// stdioStream joins stdin and stdout into the stream the editor connection
+// runs on.
+type stdioStream struct {
+	io.Reader
+	io.Writer
+}
+
+func (s stdioStream) Close() error {
+	if c, ok := s.Reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// proxy relays one editor connection to one agent connection.
+type proxy struct {
+	opts     proxyOptions
+	cfg      *agent.Config
+	stderr   io.Writer
+	policy   *policy.Engine
+	manager  *agent.Manager
+	fs       *bfs.Provider
+	terminal *terminal.Provider
+	sessions session.Store
+	recorder *recorder
+
+	editor *acp.AgentConn
+	conn   *agent.Connection
+
+	mu         sync.Mutex
+	editorCaps acp.ClientCapabilities
+	cwds       map[string]string // session ID -> working directory
+}
+
+func newProxy(opts proxyOptions, cfg *agent.Config, engine *policy.Engine, sessions session.Store, stderr io.Writer) *proxy {
+	p := &proxy{
+		opts:     opts,
+		cfg:      cfg,
+		stderr:   stderr,
+		policy:   engine,
+		manager:  agent.NewManager(cfg),
+		fs:       bfs.NewProvider(),
+		terminal: terminal.NewProvider(),
+		sessions: sessions,
+		cwds:     make(map[string]string),
+	}
+	p.recorder = newRecorder(p.sessions, p.terminal)
+
+	p.fs.SetExtraRoots(cfg.Sandbox.ExtraRoots)
+	if len(cfg.Sandbox.SensitivePatterns) > 0 {
+		p.fs.SetSensitivePatterns(cfg.Sandbox.SensitivePatterns)
+	}
+	p.fs.OnAccessRequest(p.approveFileAccess)
+	return p
+}
+
+// serve relays between the editor on stream and conn until the editor
+// disconnects or ctx is done.
+func (p *proxy) serve(ctx context.Context, conn *agent.Connection, stream io.ReadWriteCloser) error {
+	p.conn = conn
+	p.editor = acp.NewAgentConn(acp.NewStreamTransport(stream))
+	p.wireEditor()
+	p.wireAgent()
+	if err := p.editor.Start(); err != nil {
+		return err
+	}
+
+	select {
+	case <-p.editor.Done():
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (p *proxy) close() {
+	if p.editor != nil {
+		_ = p.editor.Close()
+	}
+	p.terminal.CloseAll()
+	p.manager.DisconnectAll()
+	_ = p.sessions.Close()
+}
+
+func (p *proxy) logf(format string, args ...any) {
+	fmt.Fprintf(p.stderr, "bytesmith acp-proxy: "+format+"\n", args...)
+}
+
+// ---------------------------------------------------------------------------
+// Editor -> agent
+// ---------------------------------------------------------------------------
+
+// wireEditor serves the editor's requests by forwarding them to the agent.
+func (p *proxy) wireEditor() {
+	client := p.conn.Client
+
+	p.editor.HandleRequest(acp.MethodInitialize, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.InitializeParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.editorCaps = params.ClientCapabilities
+		p.mu.Unlock()
+		return p.initializeResult(), nil
+	})
+
+	p.editor.HandleRequest(acp.MethodAuthenticate, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.AuthenticateParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		return struct{}{}, client.Authenticate(ctx, params.MethodID)
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionNew, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionNewParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		result, err := client.NewSession(ctx, p.sessionDir(params.CWD), params.MCPServers)
+		if err != nil {
+			return nil, err
+		}
+		p.track(result.SessionID, p.sessionDir(params.CWD))
+		return result, nil
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionLoad, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionLoadParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		cwd := p.sessionDir(params.CWD)
+		p.track(params.SessionID, cwd)
+
+		// The agent replays the session's history as updates before it
+		// replies. The editor needs all of them; the store only what it
+		// has not recorded, as the session may have run elsewhere.
+		p.recorder.startReplay(params.SessionID, p.conn.Agent.Name, cwd)
+		err = client.LoadSession(ctx, params.SessionID, cwd, params.MCPServers)
+		if mergeErr := p.recorder.finishReplay(params.SessionID, err == nil); mergeErr != nil {
+			p.logf("record replayed history: %v", mergeErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return struct{}{}, nil
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionResume, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionResumeParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		cwd := p.sessionDir(params.CWD)
+		result, err := client.ResumeSession(ctx, params.SessionID, cwd, params.MCPServers)
+		if err != nil {
+			return nil, err
+		}
+		p.track(params.SessionID, cwd)
+		return result, nil
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionList, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionListParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		return client.ListSessions(ctx, params.CWD, params.Cursor)
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionPrompt, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionPromptParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		if text := promptText(params.Prompt); text != "" {
+			p.recorder.userMessage(params.SessionID, text)
+		}
+		result, err := client.Prompt(ctx, params.SessionID, params.Prompt)
+		p.recorder.flush(params.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+
+	p.editor.HandleNotification(acp.MethodSessionCancel, func(raw json.RawMessage) {
+		params, err := decodeParams[acp.SessionCancelParams](raw)
+		if err != nil {
+			return
+		}
+		if err := client.Cancel(params.SessionID); err != nil {
+			p.logf("cancel %s: %v", params.SessionID, err)
+		}
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionSetMode, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionSetModeParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		return struct{}{}, client.SetMode(ctx, params.SessionID, params.ModeID)
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionSetModel, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionSetModelParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		return struct{}{}, client.SetModel(ctx, params.SessionID, params.ModelID)
+	})
+
+	p.editor.HandleRequest(acp.MethodSessionSetConfig, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[acp.SessionSetConfigOptionParams](raw)
+		if err != nil {
+			return nil, err
+		}
+		return struct{}{}, client.SetConfigOption(ctx, params.SessionID, params.ConfigID, params.Value)
+	})
+}
+
+// initializeResult describes the agent to the editor. Capabilities come
+// from what was negotiated with the agent, which also covers agents that
+// ByteSmith drives through their own dialects and that never sent an ACP
+// handshake.
+func (p *proxy) initializeResult() acp.InitializeResult {
+	caps := p.conn.Capabilities()
+	result := acp.InitializeResult{
+		ProtocolVersion: 1,
+		AgentCapabilities: acp.AgentCapabilities{
+			LoadSession: caps.LoadSession,
+			PromptCapabilities: &acp.PromptCapabilities{
+				Image:           caps.ImageInput,
+				Audio:           caps.AudioInput,
+				EmbeddedContext: caps.EmbeddedContext,
+			},
+			MCPCapabilities: &acp.MCPCapabilities{HTTP: caps.MCPHTTP, SSE: caps.MCPSSE},
+		},
+		AgentInfo: acp.ImplementationInfo{
+			Name:    "bytesmith",
+			Title:   fmt.Sprintf("ByteSmith (%s)", p.conn.Agent.Name),
+			Version: "0.1.0",
+		},
+	}
+	if caps.ListSessions || caps.ResumeSession {
+		sc := &acp.SessionCapabilities{}
+		if caps.ListSessions {
+			sc.List = &acp.SessionListCapabilities{}
+		}
+		if caps.ResumeSession {
+			sc.Resume = &acp.SessionResumeCapabilities{}
+		}
+		result.AgentCapabilities.SessionCapabilities = sc
+	}
+	if init := p.conn.Client.InitializeResult(); init != nil {
+		result.AuthMethods = init.AuthMethods
+	}
+	return result
+}
+
+// sessionDir returns the working directory for a session the editor opens,
+// defaulting to the proxy's.
+func (p *proxy) sessionDir(cwd string) string {
+	if strings.TrimSpace(cwd) == "" {
+		return p.opts.cwd
+	}
+	return cwd
+}
+
+// track records a session the editor opened. The connection ends with this
+// process, so the stored session is not tied to it.
+func (p *proxy) track(sessionID, cwd string) {
+	p.sessions.Create(sessionID, p.conn.Agent.Name, "", cwd)
+	p.fs.RegisterSession(sessionID, cwd, p.conn.Agent.Name)
+
+	p.mu.Lock()
+	p.cwds[sessionID] = cwd
+	p.mu.Unlock()
+}
+
+func (p *proxy) sessionCWD(sessionID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cwd, ok := p.cwds[sessionID]; ok {
+		return cwd
+	}
+	return p.opts.cwd
+}
+
+func (p *proxy) editorCapabilities() acp.ClientCapabilities {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.editorCaps
+}
+
+// ---------------------------------------------------------------------------
+// Agent -> editor
+// ---------------------------------------------------------------------------
+
+// wireAgent routes the agent's updates and requests to the editor. File
+// and terminal requests go to the editor when it offers them, as an ACP
+// agent would send them, and are served locally otherwise.
+func (p *proxy) wireAgent() {
+	client := p.conn.Client
+
+	client.OnSessionUpdate(p.handleSessionUpdate)
+	client.OnRequestPermission(p.requestPermission)
+	client.OnRequestUserInput(func(acp.ToolRequestUserInputParams) acp.ToolRequestUserInputResponse {
+		// ACP has no way to ask the editor; the agent proceeds without answers.
+		return acp.ToolRequestUserInputResponse{Answers: map[string]acp.ToolRequestUserInputAnswer{}}
+	})
+
+	client.OnFSReadTextFile(func(params acp.FSReadTextFileParams) (*acp.FSReadTextFileResult, error) {
+		if fs := p.editorCapabilities().FS; fs != nil && fs.ReadTextFile {
+			return forward[acp.FSReadTextFileResult](p.editor, acp.MethodFSReadTextFile, params)
+		}
+		return p.fs.HandleReadTextFile(params)
+	})
+	client.OnFSWriteTextFile(func(params acp.FSWriteTextFileParams) error {
+		if fs := p.editorCapabilities().FS; fs != nil && fs.WriteTextFile {
+			_, err := forward[struct{}](p.editor, acp.MethodFSWriteTextFile, params)
+			return err
+		}
+		return p.fs.HandleWriteTextFile(params)
+	})
+
+	client.OnTerminalCreate(func(params acp.TerminalCreateParams) (*acp.TerminalCreateResult, error) {
+		if p.editorCapabilities().Terminal {
+			return forward[acp.TerminalCreateResult](p.editor, acp.MethodTerminalCreate, params)
+		}
+		return p.terminal.HandleCreate(params)
+	})
+	client.OnTerminalOutput(func(params acp.TerminalOutputParams) (*acp.TerminalOutputResult, error) {
+		if p.editorCapabilities().Terminal {
+			return forward[acp.TerminalOutputResult](p.editor, acp.MethodTerminalOutput, params)
+		}
+		return p.terminal.HandleOutput(params)
+	})
+	client.OnTerminalWait(func(params acp.TerminalWaitParams) (*acp.TerminalWaitResult, error) {
+		if p.editorCapabilities().Terminal {
+			return forward[acp.TerminalWaitResult](p.editor, acp.MethodTerminalWait, params)
+		}
+		return p.terminal.HandleWaitForExit(params)
+	})
+	client.OnTerminalKill(func(params acp.TerminalKillParams) error {
+		if p.editorCapabilities().Terminal {
+			_, err := forward[struct{}](p.editor, acp.MethodTerminalKill, params)
+			return err
+		}
+		return p.terminal.HandleKill(params)
+	})
+	client.OnTerminalRelease(func(params acp.TerminalReleaseParams) error {
+		if p.editorCapabilities().Terminal {
+			_, err := forward[struct{}](p.editor, acp.MethodTerminalRelease, params)
+			return err
+		}
+		return p.terminal.HandleRelease(params)
+	})
+
+	go func() {
+		for line := range client.StderrCh() {
+			if p.opts.verbose {
+				fmt.Fprintln(p.stderr, line)
+			}
+		}
+	}()
+}
+
+// handleSessionUpdate records an update and passes it on to the editor.
+func (p *proxy) handleSessionUpdate(params acp.SessionUpdateParams) {
+	p.recorder.record(params)
+	if err := p.editor.Notify(acp.MethodSessionUpdate, params); err != nil {
+		p.logf("forward update: %v", err)
+	}
+}
+
+// requestPermission answers a permission request from the policy, and
+// leaves the requests no rule decides to the user in the editor.
+func (p *proxy) requestPermission(params acp.RequestPermissionParams) acp.RequestPermissionResult {
+	p.recorder.flush(params.SessionID)
+
+	result := p.policy.Evaluate(policy.RequestFor(p.conn.Agent.Name, p.sessionCWD(params.SessionID), params.ToolCall))
+	if optionID := policy.OptionFor(result.Decision, params.Options); optionID != "" {
+		return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "selected", OptionID: optionID}}
+	}
+
+	res, err := forward[acp.RequestPermissionResult](p.editor, acp.MethodRequestPermission, params)
+	if err != nil {
+		p.logf("permission request: %v", err)
+		return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "cancelled"}}
+	}
+	return *res
+}
+
+// approveFileAccess decides accesses the sandbox holds back from the
+// policy, as the window does, and asks the user in the editor about the
+// ones no rule decides.
+func (p *proxy) approveFileAccess(req bfs.AccessRequest) bool {
+	tc := fileAccessToolCall(req)
+	switch evaluateFileAccess(p.policy, req, p.sessionCWD(req.SessionID), tc).Decision {
+	case policy.Allow:
+		return true
+	case policy.Deny:
+		return false
+	}
+
+	p.recorder.flush(req.SessionID)
+	res, err := forward[acp.RequestPermissionResult](p.editor, acp.MethodRequestPermission, acp.RequestPermissionParams{
+		SessionID: req.SessionID,
+		ToolCall:  tc,
+		Options:   fileAccessOptions,
+	})
+	if err != nil {
+		p.logf("file access request: %v", err)
+		return false
+	}
+	return res.Outcome.Outcome == "selected" && res.Outcome.OptionID == fileAccessAllowOption
+}
+
+// forward sends an agent request on to the editor and decodes its result.
+func forward[T any](editor *acp.AgentConn, method string, params any) (*T, error) {
+	raw, err := editor.Call(context.Background(), method, params)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("%s: decode result: %w", method, err)
+		}
+	}
+	return &result, nil
+}
+
+// decodeParams decodes the params of an editor request, reporting failures
+// as invalid params.
+func decodeParams[T any](raw json.RawMessage) (T, error) {
+	var params T
+	if len(raw) == 0 {
+		return params, nil
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return params, &acp.JSONRPCError{Code: acp.ErrCodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return params, nil
+}
+
+// promptText returns the text of a prompt, as stored for the user's
+// message.
+func promptText(blocks []acp.ContentBlock) string {
+	var texts []string
+	for _, b := range blocks {
+		if b.Type == "text" && strings.TrimSpace(b.Text) != "" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/agentclient"
+	bfs "bytesmith/internal/fs"
+	"bytesmith/internal/policy"
+	"bytesmith/internal/session"
+)
+
+// startFakeBackend serves an agent that asks for two permissions, replies
+// and ends the turn for every prompt, and replays that turn, with a tool
+// call and a plan, when the session is loaded.
+func startFakeBackend(t *testing.T) agentclient.Client {
+	t.Helper()
+	clientEnd, agentEnd := acp.NewPipeTransports()
+	backend := acp.NewAgentConn(agentEnd)
+	t.Cleanup(func() { backend.Close() })
+
+	backend.HandleRequest(acp.MethodInitialize, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		return acp.InitializeResult{ProtocolVersion: 1, AgentInfo: acp.ImplementationInfo{Name: "fake"}}, nil
+	})
+	backend.HandleRequest(acp.MethodSessionNew, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		return acp.SessionNewResult{SessionID: "s1"}, nil
+	})
+	backend.HandleRequest(acp.MethodSessionLoad, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		for _, update := range []acp.SessionUpdate{
+			{Type: acp.UpdateUserMessageChunk, MessageContent: &acp.ContentBlock{Type: "text", Text: "clean and build"}},
+			{Type: acp.UpdateToolCall, ToolCallID: "make", Title: "make", Kind: "execute", Status: "pending"},
+			{Type: acp.UpdateToolCallUpdate, ToolCallID: "make", Status: "completed"},
+			{Type: acp.UpdateAgentMessageChunk, MessageContent: &acp.ContentBlock{Type: "text", Text: "picked "}},
+			{Type: acp.UpdateAgentMessageChunk, MessageContent: &acp.ContentBlock{Type: "text", Text: "no,yes"}},
+			{Type: acp.UpdatePlan, Entries: []acp.PlanEntry{{Content: "build", Priority: "high", Status: "completed"}}},
+		} {
+			if err := backend.Notify(acp.MethodSessionUpdate, acp.SessionUpdateParams{SessionID: "s1", Update: update}); err != nil {
+				return nil, err
+			}
+		}
+		return struct{}{}, nil
+	})
+	backend.HandleRequest(acp.MethodSessionPrompt, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		options := []acp.PermissionOption{
+			{OptionID: "yes", Name: "Allow", Kind: "allow_once"},
+			{OptionID: "no", Name: "Reject", Kind: "reject_once"},
+		}
+		var picked []string
+		for _, command := range []string{"rm -rf build", "make"} {
+			rawInput, _ := json.Marshal(map[string]string{"command": command})
+			raw, err := backend.Call(ctx, acp.MethodRequestPermission, acp.RequestPermissionParams{
+				SessionID: "s1",
+				ToolCall:  acp.ToolCallUpdate{ToolCallID: command, Title: command, Kind: "execute", RawInput: rawInput},
+				Options:   options,
+			})
+			if err != nil {
+				return nil, err
+			}
+			var res acp.RequestPermissionResult
+			_ = json.Unmarshal(raw, &res)
+			picked = append(picked, res.Outcome.OptionID)
+		}
+		err := backend.Notify(acp.MethodSessionUpdate, acp.SessionUpdateParams{
+			SessionID: "s1",
+			Update: acp.SessionUpdate{
+				Type:           acp.UpdateAgentMessageChunk,
+				MessageContent: &acp.ContentBlock{Type: "text", Text: "picked " + picked[0] + "," + picked[1]},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return acp.SessionPromptResult{StopReason: "end_turn"}, nil
+	})
+	if err := backend.Start(); err != nil {
+		t.Fatalf("start backend: %v", err)
+	}
+
+	client, err := agentclient.NewACPWithTransport(clientEnd)
+	if err != nil {
+		t.Fatalf("connect backend: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestProxyRelaysAndRecords(t *testing.T) {
+	engine, err := policy.New([]policy.Rule{{ID: "no-rm", Decision: policy.Deny, Command: `^rm\b`}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := session.NewMemoryStore()
+	p := newProxy(proxyOptions{agent: "fake", cwd: t.TempDir()}, agent.DefaultConfig(), engine, store, io.Discard)
+	conn := &agent.Connection{ID: "c1", Agent: agent.AgentConfig{Name: "fake"}, Client: startFakeBackend(t)}
+
+	proxyEnd, editorEnd := net.Pipe()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		_ = p.serve(context.Background(), conn, proxyEnd)
+	}()
+
+	editor := acp.NewClient(acp.NewStreamTransport(editorEnd))
+	var mu sync.Mutex
+	var asked []string
+	var chunks []string
+	editor.OnRequestPermission(func(params acp.RequestPermissionParams) acp.RequestPermissionResult {
+		mu.Lock()
+		asked = append(asked, params.ToolCall.Title)
+		mu.Unlock()
+		return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "selected", OptionID: "yes"}}
+	})
+	editor.OnSessionUpdate(func(params acp.SessionUpdateParams) {
+		mu.Lock()
+		defer mu.Unlock()
+		if params.Update.MessageContent != nil {
+			chunks = append(chunks, params.Update.MessageContent.Text)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	init, err := editor.Initialize(ctx)
+	if err != nil || init.AgentInfo.Title != "ByteSmith (fake)" {
+		t.Fatalf("initialize = %+v, %v", init, err)
+	}
+	if _, err := editor.NewSession(ctx, "", nil); err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	res, err := editor.Prompt(ctx, "s1", []acp.ContentBlock{{Type: "text", Text: "clean and build"}})
+	if err != nil || res.StopReason != "end_turn" {
+		t.Fatalf("prompt = %+v, %v", res, err)
+	}
+
+	mu.Lock()
+	if len(asked) != 1 || asked[0] != "make" {
+		t.Fatalf("editor was asked %v, want [make]", asked)
+	}
+	if len(chunks) != 1 || chunks[0] != "picked no,yes" {
+		t.Fatalf("chunks = %v, want [picked no,yes]", chunks)
+	}
+	mu.Unlock()
+
+	rec := store.Get("s1")
+	if rec == nil || rec.AgentName != "fake" || rec.Title != "clean and build" {
+		t.Fatalf("stored session = %+v", rec)
+	}
+	if len(rec.Messages) != 2 || rec.Messages[0].Content != "clean and build" || rec.Messages[1].Content != "picked no,yes" {
+		t.Fatalf("stored messages = %+v", rec.Messages)
+	}
+
+	editor.Close()
+	select {
+	case <-served:
+	case <-time.After(2 * time.Second):
+		t.Fatal("proxy did not stop after the editor went away")
+	}
+	p.close()
+}
+
+func TestProxyAsksEditorAboutFileAccess(t *testing.T) {
+	engine, err := policy.New(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd := t.TempDir()
+	p := newProxy(proxyOptions{agent: "fake", cwd: cwd}, agent.DefaultConfig(), engine, session.NewMemoryStore(), io.Discard)
+	conn := &agent.Connection{ID: "c1", Agent: agent.AgentConfig{Name: "fake"}, Client: startFakeBackend(t)}
+
+	proxyEnd, editorEnd := net.Pipe()
+	go func() { _ = p.serve(context.Background(), conn, proxyEnd) }()
+	defer p.close()
+
+	editor := acp.NewClient(acp.NewStreamTransport(editorEnd))
+	defer editor.Close()
+	var mu sync.Mutex
+	var asked []string
+	editor.OnRequestPermission(func(params acp.RequestPermissionParams) acp.RequestPermissionResult {
+		mu.Lock()
+		asked = append(asked, params.ToolCall.Locations[0].Path)
+		mu.Unlock()
+		return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "selected", OptionID: fileAccessAllowOption}}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := editor.Initialize(ctx); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	if _, err := editor.NewSession(ctx, cwd, nil); err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+
+	// Auto-approve covers an access outside the workspace, as in the
+	// window, but a sensitive file goes to the user in the editor.
+	outside := bfs.AccessRequest{SessionID: "s1", AgentName: "fake", Path: "/opt/notes.txt", Operation: bfs.OpRead, Reason: bfs.ReasonOutsideWorkspace}
+	if !p.approveFileAccess(outside) {
+		t.Fatalf("access outside the workspace was not auto-approved")
+	}
+	sensitive := bfs.AccessRequest{SessionID: "s1", AgentName: "fake", Path: cwd + "/.env", Operation: bfs.OpRead, Reason: bfs.ReasonSensitive}
+	if !p.approveFileAccess(sensitive) {
+		t.Fatalf("sensitive access allowed in the editor was denied")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(asked) != 1 || asked[0] != sensitive.Path {
+		t.Fatalf("editor was asked about %v, want [%s]", asked, sensitive.Path)
+	}
+}
+
+func TestProxyRecordsLoadReplayOnce(t *testing.T) {
+	engine, err := policy.New([]policy.Rule{{ID: "no-rm", Decision: policy.Deny, Command: `^rm\b`}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := session.NewMemoryStore()
+	p := newProxy(proxyOptions{agent: "fake", cwd: t.TempDir()}, agent.DefaultConfig(), engine, store, io.Discard)
+	conn := &agent.Connection{ID: "c1", Agent: agent.AgentConfig{Name: "fake"}, Client: startFakeBackend(t)}
+
+	proxyEnd, editorEnd := net.Pipe()
+	go func() { _ = p.serve(context.Background(), conn, proxyEnd) }()
+	defer p.close()
+
+	editor := acp.NewClient(acp.NewStreamTransport(editorEnd))
+	defer editor.Close()
+	var mu sync.Mutex
+	var replayed int
+	editor.OnRequestPermission(func(params acp.RequestPermissionParams) acp.RequestPermissionResult {
+		return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "selected", OptionID: "yes"}}
+	})
+	editor.OnSessionUpdate(func(params acp.SessionUpdateParams) {
+		mu.Lock()
+		replayed++
+		mu.Unlock()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := editor.Initialize(ctx); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	if _, err := editor.NewSession(ctx, "", nil); err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	if res, err := editor.Prompt(ctx, "s1", []acp.ContentBlock{{Type: "text", Text: "clean and build"}}); err != nil || res.StopReason != "end_turn" {
+		t.Fatalf("prompt = %+v, %v", res, err)
+	}
+
+	// The replay repeats the turn recorded live, under other message IDs,
+	// and adds a tool call and a plan the store lacks. Only those are
+	// recorded, and only once however often the session is loaded.
+	for i := 0; i < 2; i++ {
+		if err := editor.LoadSession(ctx, "s1", "", nil); err != nil {
+			t.Fatalf("load session: %v", err)
+		}
+	}
+	rec := store.Get("s1")
+	if rec == nil || messageContents(rec.Messages) != "clean and build|picked no,yes" {
+		t.Fatalf("stored session = %+v", rec)
+	}
+	if len(rec.ToolCalls) != 1 || rec.ToolCalls[0].ID != "make" || rec.ToolCalls[0].Status != "completed" {
+		t.Fatalf("stored tool calls = %+v", rec.ToolCalls)
+	}
+	if len(rec.Plans) != 1 || len(rec.Plans[0].Entries) != 1 || rec.Plans[0].Entries[0].Content != "build" {
+		t.Fatalf("stored plans = %+v", rec.Plans)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := 1 + 2*6; replayed != want {
+		t.Fatalf("editor got %d updates, want %d", replayed, want)
+	}
+}
+
+func messageContents(messages []session.Message) string {
+	out := make([]string, len(messages))
+	for i, m := range messages {
+		out[i] = m.Content
+	}
+	return strings.Join(out, "|")
+}
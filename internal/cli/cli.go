@@ -0,0 +1,47 @@
+// Package cli implements the subcommands of the ByteSmith binary that run
+// without the desktop window.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"bytesmith/internal/mcpserver"
+)
+
+// Run executes the subcommand named by args[0]. It reports false when args
+// do not name a subcommand, in which case the desktop app should start.
+func Run(args []string) (exitCode int, handled bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch args[0] {
+	case mcpserver.BridgeCommand:
+		return runMCPBridge(ctx, os.Stdin, os.Stdout, os.Stderr), true
+	case RunCommand:
+		return runHeadless(ctx, args[1:], os.Stdin, os.Stdout, os.Stderr), true
+	case AcpProxyCommand:
+		return runACPProxy(ctx, args[1:], os.Stdin, os.Stdout, os.Stderr), true
+	default:
+		return 0, false
+	}
+}
+
+// runMCPBridge connects an agent speaking stdio MCP to the built-in MCP
+// server of the running app. The app passes the endpoint in the
+// environment when it attaches the bridge to a session.
+func runMCPBridge(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) int {
+	err := mcpserver.Bridge(ctx, os.Getenv(mcpserver.EnvURL), os.Getenv(mcpserver.EnvToken), stdin, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, "bytesmith:", err)
+		return 1
+	}
+	return 0
+}
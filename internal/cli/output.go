@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/policy"
+	"bytesmith/internal/session"
+)
+
+// Output formats of the run command.
+const (
+	formatText  = "text"
+	formatJSONL = "jsonl"
+)
+
+// output renders a headless session as it streams.
+type output interface {
+	session(sessionID, agentName, cwd string)
+	message(kind, text string)
+	toolCall(tc session.ToolCallRecord, update bool)
+	permission(tc acp.ToolCallUpdate, result policy.Result, optionID string)
+	plan(plan session.PlanRecord)
+	done(stopReason string, exitCode int)
+	failure(err error)
+}
+
+func newOutput(format string, w io.Writer) output {
+	if format == formatJSONL {
+		return &jsonlOutput{enc: json.NewEncoder(w)}
+	}
+	return &textOutput{w: w}
+}
+
+// textOutput writes the agent's reply as it streams, with one line for
+// each tool call start and finish, permission decision and plan. Thoughts
+// are left out.
+type textOutput struct {
+	w         io.Writer
+	midLine   bool
+	sessionID string
+}
+
+func (o *textOutput) session(sessionID, _, _ string) {
+	o.sessionID = sessionID
+}
+
+func (o *textOutput) message(kind, text string) {
+	if kind == session.MessageThought {
+		return
+	}
+	io.WriteString(o.w, text)
+	o.midLine = !strings.HasSuffix(text, "\n")
+}
+
+func (o *textOutput) toolCall(tc session.ToolCallRecord, update bool) {
+	switch {
+	case !update:
+		o.line("[tool] %s", toolTitle(tc))
+	case tc.Status == "completed" || tc.Status == "failed":
+		o.line("[tool] %s: %s", toolTitle(tc), tc.Status)
+	}
+}
+
+func (o *textOutput) permission(tc acp.ToolCallUpdate, result policy.Result, _ string) {
+	verb := "allowed"
+	if result.Decision != policy.Allow {
+		verb = "denied"
+	}
+	reason := result.Reason
+	if reason == "" {
+		reason = result.RuleID
+	}
+	o.line("[permission] %s %s (%s)", verb, nonEmpty(tc.Title, tc.Kind, tc.ToolCallID), reason)
+}
+
+func (o *textOutput) plan(plan session.PlanRecord) {
+	o.line("[plan]")
+	for _, e := range plan.Entries {
+		o.line("  %s %s", planMark(e.Status), e.Content)
+	}
+}
+
+func (o *textOutput) done(stopReason string, exitCode int) {
+	o.endLine()
+	if exitCode != exitOK {
+		o.line("[stopped] %s (session %s)", stopReason, o.sessionID)
+	}
+}
+
+func (o *textOutput) failure(err error) {
+	o.line("[error] %v", err)
+}
+
+func (o *textOutput) line(format string, args ...any) {
+	o.endLine()
+	fmt.Fprintf(o.w, format+"\n", args...)
+}
+
+func (o *textOutput) endLine() {
+	if o.midLine {
+		io.WriteString(o.w, "\n")
+		o.midLine = false
+	}
+}
+
+// jsonlOutput writes one JSON object per event. Every object has a "type":
+// session, message, tool_call, permission, plan, done or error.
+type jsonlOutput struct {
+	enc *json.Encoder
+}
+
+func (o *jsonlOutput) session(sessionID, agentName, cwd string) {
+	o.enc.Encode(map[string]any{
+		"type":      "session",
+		"sessionId": sessionID,
+		"agent":     agentName,
+		"cwd":       cwd,
+	})
+}
+
+func (o *jsonlOutput) message(kind, text string) {
+	o.enc.Encode(map[string]any{
+		"type": "message",
+		"kind": kind,
+		"text": text,
+	})
+}
+
+func (o *jsonlOutput) toolCall(tc session.ToolCallRecord, update bool) {
+	o.enc.Encode(map[string]any{
+		"type":       "tool_call",
+		"toolCallId": tc.ID,
+		"title":      tc.Title,
+		"kind":       tc.Kind,
+		"status":     tc.Status,
+		"content":    tc.Content,
+		"parts":      jsonParts(tc.Parts),
+		"isUpdate":   update,
+	})
+}
+
+func (o *jsonlOutput) permission(tc acp.ToolCallUpdate, result policy.Result, optionID string) {
+	o.enc.Encode(map[string]any{
+		"type":       "permission",
+		"toolCallId": tc.ToolCallID,
+		"title":      tc.Title,
+		"kind":       tc.Kind,
+		"decision":   result.Decision,
+		"optionId":   optionID,
+		"ruleId":     result.RuleID,
+		"reason":     result.Reason,
+	})
+}
+
+func (o *jsonlOutput) plan(plan session.PlanRecord) {
+	o.enc.Encode(map[string]any{
+		"type":    "plan",
+		"entries": jsonPlanEntries(plan.Entries),
+	})
+}
+
+func (o *jsonlOutput) done(stopReason string, exitCode int) {
+	o.enc.Encode(map[string]any{
+		"type":       "done",
+		"stopReason": stopReason,
+		"exitCode":   exitCode,
+	})
+}
+
+func (o *jsonlOutput) failure(err error) {
+	o.enc.Encode(map[string]any{
+		"type":  "error",
+		"error": err.Error(),
+	})
+}
+
+type partJSON struct {
+	Type       string `json:"type"`
+	Text       string `json:"text,omitempty"`
+	Path       string `json:"path,omitempty"`
+	OldText    string `json:"oldText,omitempty"`
+	NewText    string `json:"newText,omitempty"`
+	TerminalID string `json:"terminalId,omitempty"`
+}
+
+type planEntryJSON struct {
+	Content  string `json:"content"`
+	Priority string `json:"priority,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+func jsonParts(parts []session.ToolCallPart) []partJSON {
+	out := make([]partJSON, 0, len(parts))
+	for _, p := range parts {
+		out = append(out, partJSON(p))
+	}
+	return out
+}
+
+func jsonPlanEntries(entries []session.PlanEntry) []planEntryJSON {
+	out := make([]planEntryJSON, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, planEntryJSON(e))
+	}
+	return out
+}
+
+func toolTitle(tc session.ToolCallRecord) string {
+	return nonEmpty(tc.Title, tc.Kind, tc.ID)
+}
+
+func planMark(status string) string {
+	switch status {
+	case "completed":
+		return "[x]"
+	case "in_progress":
+		return "[~]"
+	default:
+		return "[ ]"
+	}
+}
+
+func nonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/session"
+	"bytesmith/internal/terminal"
+
+	"github.com/google/uuid"
+)
+
+// recorder stores the sessions driven by a subcommand in the session store,
+// as the window does: streamed agent text becomes one message per run of
+// the same kind, and tool calls and plans are stored as they arrive.
+type recorder struct {
+	sessions session.Store
+	terminal *terminal.Provider
+
+	mu      sync.Mutex
+	streams map[string]*pendingStream // session ID -> text not stored yet
+	titles  map[string]string         // tool call ID -> title
+	replays map[string]*replay        // session ID -> history being replayed
+}
+
+// pendingStream is agent text streamed since the last flush.
+type pendingStream struct {
+	kind string
+	text strings.Builder
+	at   time.Time
+}
+
+// replay collects the history an agent replays while loading a session,
+// so that only what the store lacks is added once the load finishes.
+type replay struct {
+	rec       session.SessionRecord
+	streaming bool           // the last message is still being streamed
+	toolCalls map[string]int // tool call ID -> index in rec.ToolCalls
+}
+
+// recorded describes what one session update added, for callers that
+// also show it.
+type recorded struct {
+	kind     string // message kind, for text chunks
+	text     string
+	toolCall *session.ToolCallRecord
+	isUpdate bool
+	plan     *session.PlanRecord
+}
+
+func newRecorder(sessions session.Store, term *terminal.Provider) *recorder {
+	return &recorder{
+		sessions: sessions,
+		terminal: term,
+		streams:  make(map[string]*pendingStream),
+		titles:   make(map[string]string),
+		replays:  make(map[string]*replay),
+	}
+}
+
+// userMessage stores a prompt and names the session after it if it has
+// no title yet.
+func (r *recorder) userMessage(sessionID, text string) {
+	r.flush(sessionID)
+	r.sessions.AddMessage(sessionID, session.Message{
+		ID:        uuid.NewString(),
+		Role:      "user",
+		Content:   text,
+		Timestamp: time.Now(),
+	})
+	if summary := r.sessions.GetSummary(sessionID); summary != nil && summary.Title == "" {
+		r.sessions.SetTitle(sessionID, session.TitleFromPrompt(text))
+	}
+}
+
+// record stores one session update.
+func (r *recorder) record(params acp.SessionUpdateParams) recorded {
+	sessionID, update := params.SessionID, params.Update
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rp := r.replays[sessionID]; rp != nil {
+		rp.add(update)
+		return recorded{}
+	}
+
+	switch update.Type {
+	case acp.UpdateAgentMessageChunk, acp.UpdateAgentThoughtChunk:
+		if update.MessageContent == nil || update.MessageContent.Text == "" {
+			return recorded{}
+		}
+		kind := session.MessageText
+		if update.Type == acp.UpdateAgentThoughtChunk || update.MessageContent.Type == "thought" {
+			kind = session.MessageThought
+		}
+		stream := r.streams[sessionID]
+		if stream == nil || stream.kind != kind {
+			r.flushLocked(sessionID)
+			stream = &pendingStream{kind: kind, at: time.Now()}
+			r.streams[sessionID] = stream
+		}
+		stream.text.WriteString(update.MessageContent.Text)
+		return recorded{kind: kind, text: update.MessageContent.Text}
+
+	case acp.UpdateToolCall, acp.UpdateToolCallUpdate:
+		r.flushLocked(sessionID)
+		parts := session.ToolCallParts(update.ToolContent)
+		r.captureTerminalOutput(parts, update.Status)
+		if update.Title != "" {
+			r.titles[update.ToolCallID] = update.Title
+		} else {
+			update.Title = r.titles[update.ToolCallID]
+		}
+		record := session.ToolCallFromUpdate(update, parts)
+		isUpdate := update.Type == acp.UpdateToolCallUpdate
+		if isUpdate {
+			r.sessions.UpdateToolCall(sessionID, record.ID, record.Status, record.Content, parts, record.DiffSummary)
+		} else {
+			record.Timestamp = time.Now()
+			r.sessions.AddToolCall(sessionID, record)
+		}
+		return recorded{toolCall: &record, isUpdate: isUpdate}
+
+	case acp.UpdatePlan:
+		r.flushLocked(sessionID)
+		plan := session.PlanFromEntries(update.Entries)
+		plan.Timestamp = time.Now()
+		r.sessions.AddPlan(sessionID, plan)
+		return recorded{plan: &plan}
+	}
+	return recorded{}
+}
+
+// startReplay collects the updates of a session instead of storing them,
+// until finishReplay.
+func (r *recorder) startReplay(sessionID, agentName, cwd string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked(sessionID)
+	r.replays[sessionID] = &replay{
+		rec:       session.SessionRecord{ID: sessionID, AgentName: agentName, CWD: cwd},
+		toolCalls: make(map[string]int),
+	}
+}
+
+// finishReplay stops collecting the updates of a session and, if store is
+// set, merges those the store lacks. A replayed history repeats what was
+// recorded live under other IDs, so it is matched by content.
+func (r *recorder) finishReplay(sessionID string, store bool) error {
+	r.mu.Lock()
+	rp := r.replays[sessionID]
+	delete(r.replays, sessionID)
+	r.mu.Unlock()
+	if rp == nil || !store {
+		return nil
+	}
+
+	rec := &rp.rec
+	if stored := r.sessions.Get(sessionID); stored != nil {
+		rec.Messages = session.UnrecordedMessages(stored.Messages, rec.Messages)
+		rec.ToolCalls = session.UnrecordedToolCalls(stored.ToolCalls, rec.ToolCalls)
+		rec.Plans = session.UnrecordedPlans(stored.Plans, rec.Plans)
+	}
+	_, err := r.sessions.Merge(rec)
+	return err
+}
+
+// add collects one replayed update the way record stores a live one.
+func (rp *replay) add(update acp.SessionUpdate) {
+	rec := &rp.rec
+	switch update.Type {
+	case acp.UpdateUserMessageChunk, acp.UpdateAgentMessageChunk, acp.UpdateAgentThoughtChunk:
+		if update.MessageContent == nil || update.MessageContent.Text == "" {
+			return
+		}
+		role, kind := "agent", session.MessageText
+		switch {
+		case update.Type == acp.UpdateUserMessageChunk:
+			role, kind = "user", ""
+		case update.Type == acp.UpdateAgentThoughtChunk || update.MessageContent.Type == "thought":
+			kind = session.MessageThought
+		}
+		if n := len(rec.Messages); rp.streaming && rec.Messages[n-1].Role == role && rec.Messages[n-1].Kind == kind {
+			rec.Messages[n-1].Content += update.MessageContent.Text
+			return
+		}
+		rec.Messages = append(rec.Messages, session.Message{
+			ID:        uuid.NewString(),
+			Role:      role,
+			Kind:      kind,
+			Content:   update.MessageContent.Text,
+			Timestamp: time.Now(),
+		})
+		rp.streaming = true
+
+	case acp.UpdateToolCall, acp.UpdateToolCallUpdate:
+		rp.streaming = false
+		parts := session.ToolCallParts(update.ToolContent)
+		i, ok := rp.toolCalls[update.ToolCallID]
+		if ok && update.Title == "" {
+			update.Title = rec.ToolCalls[i].Title
+		}
+		record := session.ToolCallFromUpdate(update, parts)
+		if !ok {
+			record.Timestamp = time.Now()
+			rp.toolCalls[record.ID] = len(rec.ToolCalls)
+			rec.ToolCalls = append(rec.ToolCalls, record)
+			return
+		}
+		tc := &rec.ToolCalls[i]
+		tc.Status = record.Status
+		if record.Content != "" {
+			tc.Content = record.Content
+		}
+		tc.Parts = parts
+		tc.DiffSummary = record.DiffSummary
+
+	case acp.UpdatePlan:
+		rp.streaming = false
+		plan := session.PlanFromEntries(update.Entries)
+		plan.ID = uuid.NewString()
+		plan.Timestamp = time.Now()
+		rec.Plans = append(rec.Plans, plan)
+	}
+}
+
+// captureTerminalOutput copies the output of finished terminals into the
+// tool call, as the window does.
+func (r *recorder) captureTerminalOutput(parts []session.ToolCallPart, status string) {
+	switch status {
+	case "completed", "failed", "cancelled":
+	default:
+		return
+	}
+	for i := range parts {
+		p := &parts[i]
+		if p.Type != "terminal" || p.TerminalID == "" || strings.TrimSpace(p.Text) != "" {
+			continue
+		}
+		if out, err := r.terminal.HandleOutput(acp.TerminalOutputParams{TerminalID: p.TerminalID}); err == nil {
+			p.Text = out.Output
+		}
+	}
+}
+
+// flush stores the agent text streamed in a session since the last flush
+// as one message.
+func (r *recorder) flush(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked(sessionID)
+}
+
+func (r *recorder) flushLocked(sessionID string) {
+	stream := r.streams[sessionID]
+	delete(r.streams, sessionID)
+	if stream == nil || stream.text.Len() == 0 {
+		return
+	}
+	r.sessions.AddMessage(sessionID, session.Message{
+		ID:        uuid.NewString(),
+		Role:      "agent",
+		Kind:      stream.kind,
+		Content:   stream.text.String(),
+		Timestamp: stream.at,
+	})
+}
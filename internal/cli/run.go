@@ -0,0 +1,401 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	bfs "bytesmith/internal/fs"
+	"bytesmith/internal/policy"
+	"bytesmith/internal/session"
+	"bytesmith/internal/terminal"
+
+	"github.com/google/uuid"
+)
+
+// RunCommand runs one prompt against an agent without the desktop window.
+const RunCommand = "run"
+
+// Exit statuses of the run command. A prompt that ends normally exits 0;
+// other stop reasons map to their own status so scripts can tell them
+// apart.
+const (
+	exitOK              = 0   // stop reason end_turn
+	exitFailure         = 1   // the agent could not be started or failed
+	exitUsage           = 2   // invalid arguments
+	exitMaxTokens       = 3   // stop reason max_tokens
+	exitMaxTurnRequests = 4   // stop reason max_turn_requests
+	exitRefusal         = 5   // stop reason refusal
+	exitCancelled       = 130 // stop reason cancelled, or interrupted
+)
+
+// Permission modes of the run command. Configured rules always apply;
+// the mode decides requests no rule covers, since nobody is there to ask.
+const (
+	permissionsPolicy = "policy" // the autoApprove setting decides; otherwise deny
+	permissionsAllow  = "allow"  // allow
+	permissionsDeny   = "deny"   // deny
+)
+
+// cancelGrace is how long an interrupted prompt may take to wind down after
+// the agent was asked to cancel it.
+const cancelGrace = 5 * time.Second
+
+type runOptions struct {
+	agent       string
+	cwd         string
+	format      string
+	permissions string
+	timeout     time.Duration
+	verbose     bool
+	prompt      string
+}
+
+// exitCodeFor maps a prompt stop reason to the run command's exit status.
+func exitCodeFor(stopReason string) int {
+	switch stopReason {
+	case "end_turn", "":
+		return exitOK
+	case "max_tokens":
+		return exitMaxTokens
+	case "max_turn_requests":
+		return exitMaxTurnRequests
+	case "refusal":
+		return exitRefusal
+	case "cancelled":
+		return exitCancelled
+	default:
+		return exitFailure
+	}
+}
+
+// parseRunArgs parses the arguments of the run command. The prompt is the
+// remaining arguments, or standard input when they are absent or "-".
+func parseRunArgs(args []string, cfg *agent.Config, stdin io.Reader, stderr io.Writer) (runOptions, error) {
+	opts := runOptions{}
+	flags := flag.NewFlagSet(RunCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.agent, "agent", cfg.Settings.DefaultAgent, "agent to run, by config name")
+	flags.StringVar(&opts.cwd, "cwd", ".", "working directory of the session")
+	flags.StringVar(&opts.format, "format", formatText, "output format: text or jsonl")
+	flags.StringVar(&opts.permissions, "permissions", permissionsPolicy,
+		"decision for permission requests no rule covers: policy, allow or deny")
+	flags.DurationVar(&opts.timeout, "timeout", 0, "cancel the prompt after this long (0 waits)")
+	flags.BoolVar(&opts.verbose, "verbose", false, "copy the agent's stderr to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: bytesmith run [flags] <prompt | ->")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Exit status: 0 end_turn, 1 error, 2 usage, 3 max_tokens, 4 max_turn_requests,")
+		fmt.Fprintln(stderr, "5 refusal, 130 cancelled.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if strings.TrimSpace(opts.agent) == "" {
+		return opts, errors.New("no agent given and no default agent configured")
+	}
+	switch opts.format {
+	case formatText, formatJSONL:
+	default:
+		return opts, fmt.Errorf("unknown format %q", opts.format)
+	}
+	switch opts.permissions {
+	case permissionsPolicy, permissionsAllow, permissionsDeny:
+	default:
+		return opts, fmt.Errorf("unknown permissions mode %q", opts.permissions)
+	}
+	cwd, err := filepath.Abs(opts.cwd)
+	if err != nil {
+		return opts, fmt.Errorf("cwd: %w", err)
+	}
+	opts.cwd = cwd
+
+	opts.prompt = strings.Join(flags.Args(), " ")
+	if opts.prompt == "" || opts.prompt == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return opts, fmt.Errorf("read prompt: %w", err)
+		}
+		opts.prompt = string(data)
+	}
+	if strings.TrimSpace(opts.prompt) == "" {
+		return opts, errors.New("empty prompt")
+	}
+	return opts, nil
+}
+
+// runHeadless implements "bytesmith run": it connects to an agent, sends one
+// prompt in a new session and streams the session to stdout. The session
+// is recorded in the session store like one started from the window.
+func runHeadless(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := agent.LoadConfig(agent.ConfigPath())
+	if err != nil {
+		fmt.Fprintln(stderr, "bytesmith: failed to load config, using defaults:", err)
+		cfg = agent.DefaultConfig()
+	}
+
+	opts, err := parseRunArgs(args, cfg, stdin, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintln(stderr, "bytesmith run:", err)
+		return exitUsage
+	}
+
+	autoApprove := opts.permissions == permissionsAllow ||
+		opts.permissions == permissionsPolicy && cfg.Settings.AutoApprove
+	engine, err := policy.New(cfg.PermissionRules, autoApprove)
+	if err != nil {
+		fmt.Fprintln(stderr, "bytesmith run:", err)
+		return exitUsage
+	}
+
+	r := &runner{
+		opts:     opts,
+		cfg:      cfg,
+		out:      newOutput(opts.format, stdout),
+		stderr:   stderr,
+		policy:   engine,
+		manager:  agent.NewManager(cfg),
+		fs:       bfs.NewProvider(),
+		terminal: terminal.NewProvider(),
+		sessions: session.NewStore(),
+	}
+	r.recorder = newRecorder(r.sessions, r.terminal)
+	defer r.close()
+
+	stopReason, err := r.run(ctx)
+	if err != nil {
+		r.out.failure(err)
+		if ctx.Err() != nil {
+			return exitCancelled
+		}
+		return exitFailure
+	}
+	code := exitCodeFor(stopReason)
+	r.out.done(stopReason, code)
+	return code
+}
+
+// runner drives one headless prompt.
+type runner struct {
+	opts     runOptions
+	cfg      *agent.Config
+	out      output
+	stderr   io.Writer
+	policy   *policy.Engine
+	manager  *agent.Manager
+	fs       *bfs.Provider
+	terminal *terminal.Provider
+	sessions session.Store
+	recorder *recorder
+
+	mu        sync.Mutex // serializes output
+	sessionID string
+}
+
+func (r *runner) run(ctx context.Context) (string, error) {
+	r.fs.SetExtraRoots(r.cfg.Sandbox.ExtraRoots)
+	if len(r.cfg.Sandbox.SensitivePatterns) > 0 {
+		r.fs.SetSensitivePatterns(r.cfg.Sandbox.SensitivePatterns)
+	}
+	r.fs.OnAccessRequest(r.approveFileAccess)
+
+	conn, err := r.manager.Connect(r.opts.agent, r.opts.cwd)
+	if err != nil {
+		return "", err
+	}
+	r.wire(conn)
+
+	result, err := conn.Client.NewSession(ctx, r.opts.cwd, nil)
+	if err != nil {
+		return "", fmt.Errorf("new session: %w", err)
+	}
+	sessionID := result.SessionID
+
+	r.mu.Lock()
+	r.sessionID = sessionID
+	r.mu.Unlock()
+
+	// The connection ends with this process, so the stored session is
+	// not tied to it.
+	r.sessions.Create(sessionID, conn.Agent.Name, "", r.opts.cwd)
+	r.fs.RegisterSession(sessionID, r.opts.cwd, conn.Agent.Name)
+	r.recorder.userMessage(sessionID, r.opts.prompt)
+	r.out.session(sessionID, conn.Agent.Name, r.opts.cwd)
+
+	promptCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if r.opts.timeout > 0 {
+		promptCtx, cancel = context.WithTimeout(promptCtx, r.opts.timeout)
+		defer cancel()
+	}
+
+	// On interrupt, ask the agent to stop and give it a moment to report
+	// the cancelled turn before abandoning the request.
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Client.Cancel(sessionID)
+			select {
+			case <-time.After(cancelGrace):
+				cancel()
+			case <-promptCtx.Done():
+			}
+		case <-promptCtx.Done():
+		}
+	}()
+
+	res, err := conn.Client.Prompt(promptCtx, sessionID, []acp.ContentBlock{{Type: "text", Text: r.opts.prompt}})
+	r.recorder.flush(sessionID)
+	if err != nil {
+		if errors.Is(promptCtx.Err(), context.DeadlineExceeded) {
+			_ = conn.Client.Cancel(sessionID)
+			return "", fmt.Errorf("prompt timed out after %s", r.opts.timeout)
+		}
+		return "", err
+	}
+	return res.StopReason, nil
+}
+
+func (r *runner) close() {
+	r.terminal.CloseAll()
+	r.manager.DisconnectAll()
+	_ = r.sessions.Close()
+}
+
+// wire routes the connection's callbacks to the runner and the providers.
+func (r *runner) wire(conn *agent.Connection) {
+	conn.Client.OnSessionUpdate(r.handleSessionUpdate)
+	conn.Client.OnRequestPermission(func(params acp.RequestPermissionParams) acp.RequestPermissionResult {
+		return r.decidePermission(conn.Agent.Name, params)
+	})
+	conn.Client.OnRequestUserInput(func(acp.ToolRequestUserInputParams) acp.ToolRequestUserInputResponse {
+		// Nobody can answer; the agent proceeds without answers.
+		return acp.ToolRequestUserInputResponse{Answers: map[string]acp.ToolRequestUserInputAnswer{}}
+	})
+
+	conn.Client.OnFSReadTextFile(r.fs.HandleReadTextFile)
+	conn.Client.OnFSWriteTextFile(r.fs.HandleWriteTextFile)
+
+	conn.Client.OnTerminalCreate(r.terminal.HandleCreate)
+	conn.Client.OnTerminalOutput(r.terminal.HandleOutput)
+	conn.Client.OnTerminalWait(r.terminal.HandleWaitForExit)
+	conn.Client.OnTerminalKill(r.terminal.HandleKill)
+	conn.Client.OnTerminalRelease(r.terminal.HandleRelease)
+
+	go func() {
+		for line := range conn.Client.StderrCh() {
+			if r.opts.verbose {
+				fmt.Fprintln(r.stderr, line)
+			}
+		}
+	}()
+}
+
+// decidePermission answers a permission request from the policy. Requests
+// the policy leaves to the user are rejected.
+func (r *runner) decidePermission(agentName string, params acp.RequestPermissionParams) acp.RequestPermissionResult {
+	result := r.policy.Evaluate(policy.RequestFor(agentName, r.opts.cwd, params.ToolCall))
+	if result.Decision == policy.Ask {
+		result = policy.Result{Decision: policy.Deny, Reason: "no rule allows it and nobody can be asked"}
+	}
+	optionID := policy.OptionFor(result.Decision, params.Options)
+
+	r.mu.Lock()
+	r.recorder.flush(r.sessionID)
+	r.out.permission(params.ToolCall, result, optionID)
+	r.mu.Unlock()
+
+	if optionID == "" {
+		return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "cancelled"}}
+	}
+	return acp.RequestPermissionResult{Outcome: acp.PermissionOutcome{Outcome: "selected", OptionID: optionID}}
+}
+
+// approveFileAccess decides accesses the sandbox holds back from the
+// policy, as the window does. Nobody can be asked, so the accesses no rule
+// allows are denied.
+func (r *runner) approveFileAccess(req bfs.AccessRequest) bool {
+	tc := fileAccessToolCall(req)
+	result := evaluateFileAccess(r.policy, req, r.opts.cwd, tc)
+	if result.Decision == policy.Ask {
+		result = policy.Result{Decision: policy.Deny, Reason: "no rule allows it and nobody can be asked"}
+	}
+
+	r.mu.Lock()
+	r.recorder.flush(r.sessionID)
+	r.out.permission(tc, result, "")
+	r.mu.Unlock()
+	return result.Decision == policy.Allow
+}
+
+// Options offered when a file access is left to the user.
+const (
+	fileAccessAllowOption  = "allow"
+	fileAccessRejectOption = "reject"
+)
+
+var fileAccessOptions = []acp.PermissionOption{
+	{OptionID: fileAccessAllowOption, Name: "Allow", Kind: "allow_once"},
+	{OptionID: fileAccessRejectOption, Name: "Deny", Kind: "reject_once"},
+}
+
+// evaluateFileAccess runs a file access held back by the sandbox through
+// the policy, leaving the ones only auto-approve would allow undecided
+// when they need an explicit approval.
+func evaluateFileAccess(engine *policy.Engine, req bfs.AccessRequest, cwd string, tc acp.ToolCallUpdate) policy.Result {
+	result := engine.Evaluate(policy.RequestFor(req.AgentName, cwd, tc))
+	if req.NeedsExplicitApproval() {
+		result = policy.WithoutAutoApprove(result)
+	}
+	return result
+}
+
+// fileAccessToolCall describes an access outside the sandbox as a tool call,
+// so permission rules can match it.
+func fileAccessToolCall(req bfs.AccessRequest) acp.ToolCallUpdate {
+	kind := "read"
+	if req.Operation == bfs.OpWrite {
+		kind = "edit"
+	}
+	rawInput, _ := json.Marshal(map[string]string{"path": req.Path, "reason": req.Reason})
+	return acp.ToolCallUpdate{
+		ToolCallID: "fs-access-" + uuid.NewString(),
+		Title:      fmt.Sprintf("%s %s (%s)", kind, req.Path, req.Reason),
+		Kind:       kind,
+		Locations:  []acp.ToolCallLocation{{Path: req.Path}},
+		RawInput:   rawInput,
+	}
+}
+
+func (r *runner) handleSessionUpdate(params acp.SessionUpdateParams) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if params.SessionID != r.sessionID {
+		return
+	}
+
+	rec := r.recorder.record(params)
+	switch {
+	case rec.text != "":
+		r.out.message(rec.kind, rec.text)
+	case rec.toolCall != nil:
+		r.out.toolCall(*rec.toolCall, rec.isUpdate)
+	case rec.plan != nil:
+		r.out.plan(*rec.plan)
+	}
+}
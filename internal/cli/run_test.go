@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/agent"
+	"bytesmith/internal/policy"
+	"bytesmith/internal/session"
+)
+
+func TestParseRunArgs(t *testing.T) {
+	cfg := agent.DefaultConfig()
+	cfg.Settings.DefaultAgent = "opencode"
+
+	opts, err := parseRunArgs([]string{"--format", "jsonl", "fix", "the", "tests"}, cfg, strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if opts.agent != "opencode" || opts.format != formatJSONL || opts.permissions != permissionsPolicy || opts.prompt != "fix the tests" {
+		t.Fatalf("options = %+v", opts)
+	}
+
+	opts, err = parseRunArgs([]string{"--agent", "gemini", "-"}, cfg, strings.NewReader("from stdin\n"), io.Discard)
+	if err != nil {
+		t.Fatalf("parse stdin prompt: %v", err)
+	}
+	if opts.agent != "gemini" || opts.prompt != "from stdin\n" {
+		t.Fatalf("options = %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"--format", "xml", "hi"},
+		{"--permissions", "ask", "hi"},
+		{},
+	} {
+		if _, err := parseRunArgs(args, cfg, strings.NewReader(" "), io.Discard); err == nil {
+			t.Fatalf("parse %q: expected an error", args)
+		}
+	}
+}
+
+func TestExitCodeFor(t *testing.T) {
+	cases := map[string]int{
+		"end_turn":          exitOK,
+		"max_tokens":        exitMaxTokens,
+		"max_turn_requests": exitMaxTurnRequests,
+		"refusal":           exitRefusal,
+		"cancelled":         exitCancelled,
+		"something_new":     exitFailure,
+	}
+	for reason, want := range cases {
+		if got := exitCodeFor(reason); got != want {
+			t.Fatalf("exitCodeFor(%q) = %d, want %d", reason, got, want)
+		}
+	}
+}
+
+func TestTextOutputSeparatesEvents(t *testing.T) {
+	var buf bytes.Buffer
+	out := newOutput(formatText, &buf)
+	out.session("s1", "opencode", "/work")
+	out.message(session.MessageText, "Looking")
+	out.message(session.MessageThought, "hidden")
+	out.toolCall(session.ToolCallRecord{ID: "t1", Title: "Read main.go"}, false)
+	out.permission(acp.ToolCallUpdate{Title: "Run rm"}, policy.Result{Decision: policy.Deny, Reason: "no"}, "")
+	out.toolCall(session.ToolCallRecord{ID: "t1", Title: "Read main.go", Status: "completed"}, true)
+	out.message(session.MessageText, "Done.")
+	out.done("max_tokens", exitMaxTokens)
+
+	want := "Looking\n[tool] Read main.go\n[permission] denied Run rm (no)\n[tool] Read main.go: completed\nDone.\n[stopped] max_tokens (session s1)\n"
+	if buf.String() != want {
+		t.Fatalf("output = %q, want %q", buf.String(), want)
+	}
+}
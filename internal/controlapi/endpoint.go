@@ -0,0 +1,88 @@
+package controlapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EndpointFileName is the file in the config directory that tells clients
+// where the control API listens and which token to send.
+const EndpointFileName = "control.json"
+
+// Endpoint is the content of the endpoint file.
+type Endpoint struct {
+	// Address is "http://127.0.0.1:port" or "unix:/path/to.sock"; empty
+	// while the API is not running.
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+// ReadEndpoint reads the endpoint file at path.
+func ReadEndpoint(path string) (Endpoint, error) {
+	var ep Endpoint
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ep, err
+	}
+	if err := json.Unmarshal(data, &ep); err != nil {
+		return ep, fmt.Errorf("controlapi: parse %s: %w", path, err)
+	}
+	return ep, nil
+}
+
+// WriteEndpoint writes the endpoint file, readable by the owner only.
+func WriteEndpoint(path string, ep Endpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("controlapi: create config dir: %w", err)
+	}
+	data, err := json.MarshalIndent(ep, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("controlapi: write endpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("controlapi: write endpoint: %w", err)
+	}
+	return nil
+}
+
+// LoadToken returns the token stored in the endpoint file at path, or a
+// new random one when there is none, so clients configured with the token
+// keep working across restarts.
+func LoadToken(path string) (string, error) {
+	ep, err := ReadEndpoint(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if token := strings.TrimSpace(ep.Token); token != "" {
+		return token, nil
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("controlapi: generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// expandHome replaces a leading "~/" with the home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,196 @@
+package controlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// method is one callable method of the target.
+type method struct {
+	fn     reflect.Value
+	params []reflect.Type
+	// hasResult and hasError describe the return values: (), (T), (error)
+	// or (T, error).
+	hasResult bool
+	hasError  bool
+}
+
+// MethodInfo describes a callable method in the /methods listing.
+type MethodInfo struct {
+	Name   string   `json:"name"`
+	Params []string `json:"params"`
+	Result string   `json:"result,omitempty"`
+}
+
+// methodsOf collects the exported methods of target that can be called
+// with JSON arguments. Methods taking functions, channels or interfaces
+// (such as a context) are skipped, as are those returning anything other
+// than a value, an error or both.
+func methodsOf(target any, exclude []string) map[string]method {
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+
+	v := reflect.ValueOf(target)
+	t := v.Type()
+	methods := make(map[string]method)
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if skip[m.Name] || m.Type.IsVariadic() {
+			continue
+		}
+		fn := v.Method(i)
+		ft := fn.Type()
+
+		callable := true
+		params := make([]reflect.Type, ft.NumIn())
+		for j := range params {
+			params[j] = ft.In(j)
+			switch params[j].Kind() {
+			case reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer:
+				callable = false
+			}
+		}
+
+		out := method{fn: fn, params: params}
+		switch ft.NumOut() {
+		case 0:
+		case 1:
+			if ft.Out(0) == errorType {
+				out.hasError = true
+			} else {
+				out.hasResult = true
+			}
+		case 2:
+			out.hasResult = true
+			out.hasError = ft.Out(1) == errorType
+			callable = callable && out.hasError
+		default:
+			callable = false
+		}
+		if callable {
+			methods[m.Name] = out
+		}
+	}
+	return methods
+}
+
+func (s *Server) describe() []MethodInfo {
+	infos := make([]MethodInfo, 0, len(s.methods))
+	for name, m := range s.methods {
+		info := MethodInfo{Name: name, Params: make([]string, 0, len(m.params))}
+		for _, p := range m.params {
+			info.Params = append(info.Params, p.String())
+		}
+		if m.hasResult {
+			info.Result = m.fn.Type().Out(0).String()
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// callResponse is the body of a successful call. Result is omitted for
+// methods that return nothing but an error.
+type callResponse struct {
+	Result any `json:"result,omitempty"`
+}
+
+func (s *Server) serveCall(w http.ResponseWriter, r *http.Request, name string) {
+	m, ok := s.methods[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown method %q", name))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("read request: %w", err))
+		return
+	}
+	args, err := m.decodeArgs(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+
+	out, err := m.call(args)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+	if m.hasError {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+	var resp callResponse
+	if m.hasResult {
+		resp.Result = out[0].Interface()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// call invokes the method, turning a panic into an error so one bad call
+// cannot take the app down.
+func (m method) call(args []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return m.fn.Call(args), nil
+}
+
+// decodeArgs decodes a JSON array of positional arguments. Missing
+// trailing arguments take their zero value, as they do for the frontend
+// bindings; an empty body calls the method with no arguments.
+func (m method) decodeArgs(body []byte) ([]reflect.Value, error) {
+	var raw []json.RawMessage
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, errors.New("arguments must be a JSON array")
+		}
+	}
+	if len(raw) > len(m.params) {
+		return nil, fmt.Errorf("takes %d arguments, got %d", len(m.params), len(raw))
+	}
+
+	args := make([]reflect.Value, len(m.params))
+	for i, t := range m.params {
+		ptr := reflect.New(t)
+		if i < len(raw) {
+			if err := json.Unmarshal(raw[i], ptr.Interface()); err != nil {
+				return nil, fmt.Errorf("argument %d: %w", i+1, err)
+			}
+		}
+		args[i] = ptr.Elem()
+	}
+	return args, nil
+}
+
+// errorResponse is the body of every failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,217 @@
+// Package controlapi serves a local HTTP and WebSocket API for editor
+// plugins and scripts. Every exported method of a target value (the
+// backend app) can be called over HTTP, and the events published on the
+// event bus stream over a WebSocket. The server only listens on loopback
+// addresses or Unix sockets, and every request must carry the token from
+// the endpoint file.
+package controlapi
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bytesmith/internal/events"
+)
+
+// maxRequestBytes bounds the size of a call's argument list.
+const maxRequestBytes = 4 << 20
+
+// Server is the control API server.
+type Server struct {
+	methods map[string]method
+	bus     *events.Bus
+	token   string
+
+	mu       sync.Mutex
+	listener net.Listener
+	http     *http.Server
+	address  string
+	socket   string
+}
+
+// New creates a server that calls the exported methods of target, except
+// the ones named in exclude, and streams the events published on bus.
+// Requests must present token.
+func New(target any, bus *events.Bus, token string, exclude ...string) *Server {
+	return &Server{
+		methods: methodsOf(target, exclude),
+		bus:     bus,
+		token:   token,
+	}
+}
+
+// Listen starts serving in the background. addr is "host:port" on a
+// loopback interface, or "unix:" followed by a socket path.
+func (s *Server) Listen(addr string) error {
+	ln, address, socket, err := listen(addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.mu.Lock()
+	s.listener = ln
+	s.http = srv
+	s.address = address
+	s.socket = socket
+	s.mu.Unlock()
+
+	go func() { _ = srv.Serve(ln) }()
+	return nil
+}
+
+// Address returns where the running server can be reached:
+// "http://127.0.0.1:port" or "unix:/path/to.sock". It is "" before Listen.
+func (s *Server) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.address
+}
+
+// Close stops the server and removes its socket file, if any.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	srv, socket := s.http, s.socket
+	s.http, s.listener, s.address, s.socket = nil, nil, "", ""
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	err := srv.Close()
+	if socket != "" {
+		_ = os.Remove(socket)
+	}
+	return err
+}
+
+func listen(addr string) (ln net.Listener, address, socket string, err error) {
+	addr = strings.TrimSpace(addr)
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		path = expandHome(strings.TrimPrefix(path, "//"))
+		if path == "" {
+			return nil, "", "", fmt.Errorf("controlapi: empty socket path")
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, "", "", fmt.Errorf("controlapi: create socket dir: %w", err)
+		}
+		// A socket left behind by a crashed run would make Listen fail.
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("controlapi: listen: %w", err)
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			_ = ln.Close()
+			return nil, "", "", fmt.Errorf("controlapi: chmod socket: %w", err)
+		}
+		return ln, "unix:" + path, path, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("controlapi: address %q: %w", addr, err)
+	}
+	if !isLoopback(host) {
+		return nil, "", "", fmt.Errorf("controlapi: address %q is not a loopback address", addr)
+	}
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("controlapi: listen: %w", err)
+	}
+	return ln, "http://" + ln.Addr().String(), "", nil
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ServeHTTP routes the API:
+//
+//	GET  /methods         the callable methods and their parameter types
+//	POST /call/{Method}   call a method; the body is a JSON array of arguments
+//	GET  /events          WebSocket stream of events
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/methods":
+		if r.Method != http.MethodGet {
+			notAllowed(w, http.MethodGet)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.describe())
+
+	case strings.HasPrefix(r.URL.Path, "/call/"):
+		if r.Method != http.MethodPost {
+			notAllowed(w, http.MethodPost)
+			return
+		}
+		s.serveCall(w, r, strings.TrimPrefix(r.URL.Path, "/call/"))
+
+	case r.URL.Path == "/events":
+		s.serveEvents(w, r)
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no route for %s", r.URL.Path))
+	}
+}
+
+// authorize checks the token and rejects requests a web page could have
+// made: browsers always send Origin on cross-site requests, and a Host
+// other than loopback means DNS rebinding. Unix socket requests carry no
+// meaningful Host.
+func (s *Server) authorize(r *http.Request) error {
+	if r.Header.Get("Origin") != "" {
+		return errors.New("cross-origin requests are not allowed")
+	}
+	s.mu.Lock()
+	socket := s.socket
+	s.mu.Unlock()
+	if socket == "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !isLoopback(host) {
+			return fmt.Errorf("host %q is not allowed", r.Host)
+		}
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		// WebSocket clients that cannot set headers pass it as a query
+		// parameter.
+		token = r.URL.Query().Get("token")
+	}
+	token = strings.TrimSpace(token)
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
+
+func notAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
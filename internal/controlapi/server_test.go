@@ -0,0 +1,215 @@
+package controlapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bytesmith/internal/events"
+
+	"github.com/gorilla/websocket"
+)
+
+type testTarget struct{}
+
+type greeting struct {
+	Name  string `json:"name"`
+	Times int    `json:"times"`
+}
+
+func (testTarget) Greet(g greeting) string {
+	return strings.Repeat("hi "+g.Name+" ", g.Times)
+}
+
+func (testTarget) Add(a, b int) (int, error) { return a + b, nil }
+
+func (testTarget) Fail(reason string) error { return errors.New(reason) }
+
+func (testTarget) Startup(ctx context.Context) {}
+
+func (testTarget) Hidden() {}
+
+func startServer(t *testing.T, bus *events.Bus) *Server {
+	t.Helper()
+	srv := New(testTarget{}, bus, "secret", "Hidden")
+	if err := srv.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func call(t *testing.T, srv *Server, path, token, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, srv.Address()+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func TestCallMethods(t *testing.T) {
+	srv := startServer(t, events.NewBus())
+
+	cases := []struct {
+		path, body string
+		status     int
+		want       string
+	}{
+		{"/call/Add", `[2, 3]`, http.StatusOK, `{"result":5}`},
+		{"/call/Greet", `[{"name":"vim","times":1}]`, http.StatusOK, `{"result":"hi vim "}`},
+		{"/call/Greet", ``, http.StatusOK, `{"result":""}`},
+		{"/call/Fail", `["nope"]`, http.StatusUnprocessableEntity, `{"error":"nope"}`},
+		{"/call/Add", `[1, 2, 3]`, http.StatusBadRequest, `{"error":"Add: takes 2 arguments, got 3"}`},
+		{"/call/Add", `{"a":1}`, http.StatusBadRequest, `{"error":"Add: arguments must be a JSON array"}`},
+		{"/call/Hidden", `[]`, http.StatusNotFound, `{"error":"unknown method \"Hidden\""}`},
+		{"/call/Startup", `[]`, http.StatusNotFound, `{"error":"unknown method \"Startup\""}`},
+	}
+	for _, tc := range cases {
+		status, body := call(t, srv, tc.path, "secret", tc.body)
+		if status != tc.status || body != tc.want {
+			t.Fatalf("%s %s = %d %s, want %d %s", tc.path, tc.body, status, body, tc.status, tc.want)
+		}
+	}
+}
+
+func TestRejectsUnauthorizedRequests(t *testing.T) {
+	srv := startServer(t, events.NewBus())
+
+	if status, _ := call(t, srv, "/call/Add", "", `[1, 1]`); status != http.StatusUnauthorized {
+		t.Fatalf("missing token: status %d", status)
+	}
+	if status, _ := call(t, srv, "/call/Add", "wrong", `[1, 1]`); status != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status %d", status)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.Address()+"/call/Add", strings.NewReader(`[1, 1]`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Origin", "https://example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("cross-origin request: status %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, srv.Address()+"/call/Add", strings.NewReader(`[1, 1]`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Host = "attacker.example:80"
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("rebound host: status %d", resp.StatusCode)
+	}
+}
+
+func TestListenOnlyOnLoopback(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:7000"} {
+		srv := New(testTarget{}, events.NewBus(), "secret")
+		if err := srv.Listen(addr); err == nil {
+			srv.Close()
+			t.Fatalf("listen %q: expected an error", addr)
+		}
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "control.sock")
+	srv := New(testTarget{}, events.NewBus(), "secret")
+	if err := srv.Listen("unix:" + sock); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer srv.Close()
+	if srv.Address() != "unix:"+sock {
+		t.Fatalf("address = %q", srv.Address())
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	req, _ := http.NewRequest(http.MethodPost, "http://bytesmith/call/Add", strings.NewReader(`[40, 2]`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	var out struct{ Result int }
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.Result != 42 {
+		t.Fatalf("result = %+v, %v", out, err)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	bus := events.NewBus()
+	srv := startServer(t, bus)
+
+	url := "ws" + strings.TrimPrefix(srv.Address(), "http") + "/events?topics=agent:&token=secret"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	received := make(chan StreamEvent, 1)
+	go func() {
+		var e StreamEvent
+		if err := conn.ReadJSON(&e); err == nil {
+			received <- e
+		}
+	}()
+
+	// The server subscribes after the upgrade completes, so publish until
+	// the stream picks the events up.
+	timeout := time.After(2 * time.Second)
+	for {
+		bus.Publish("file:changed", map[string]string{"path": "a.go"})
+		bus.Publish("agent:message", map[string]string{"text": "hello"})
+		select {
+		case e := <-received:
+			data, _ := json.Marshal(e.Data)
+			if e.Name != "agent:message" || string(data) != `{"text":"hello"}` {
+				t.Fatalf("event = %s %s", e.Name, data)
+			}
+			return
+		case <-timeout:
+			t.Fatal("no event received")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestLoadTokenKeepsExistingToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), EndpointFileName)
+	token, err := LoadToken(path)
+	if err != nil || len(token) != 64 {
+		t.Fatalf("new token = %q, %v", token, err)
+	}
+	if err := WriteEndpoint(path, Endpoint{Address: "http://127.0.0.1:1", Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadToken(path)
+	if err != nil || again != token {
+		t.Fatalf("reloaded token = %q, %v; want %q", again, err, token)
+	}
+}
@@ -0,0 +1,124 @@
+package controlapi
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bytesmith/internal/events"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamBuffer is how many events may queue for a slow client before
+	// its stream is closed.
+	streamBuffer = 256
+	pingInterval = 30 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+// StreamEvent is one event on the /events stream, shaped like the
+// frontend's EventsOn callbacks: the topic name and its payload.
+type StreamEvent struct {
+	Name string `json:"name"`
+	Data any    `json:"data"`
+}
+
+// upgrader accepts only requests without an Origin header; authorize has
+// already turned away the others.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 16 * 1024,
+}
+
+// serveEvents streams bus events to a WebSocket client. The optional
+// "topics" query parameter is a comma-separated list of topic names to
+// send; a name ending in ":" selects a whole family, e.g. "agent:".
+// A client that falls more than streamBuffer events behind is
+// disconnected rather than allowed to stall the backend.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response.
+		return
+	}
+	defer conn.Close()
+
+	match := topicFilter(r.URL.Query().Get("topics"))
+	queue := make(chan StreamEvent, streamBuffer)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+
+	// Handlers run on the publishing goroutines, possibly several at once.
+	unsubscribe := s.bus.Subscribe(func(e events.Event) {
+		if !match(e.Name) {
+			return
+		}
+		select {
+		case queue <- StreamEvent{Name: e.Name, Data: e.Payload}:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+	})
+	defer unsubscribe()
+
+	// The client sends nothing; reading is only needed to notice it going
+	// away and to process control frames.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case e := <-queue:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-overflow:
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "event stream fell behind"),
+				time.Now().Add(writeTimeout))
+			return
+		case <-gone:
+			return
+		}
+	}
+}
+
+// topicFilter returns a matcher for the "topics" query parameter. An
+// empty list matches every topic.
+func topicFilter(list string) func(string) bool {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return func(topic string) bool {
+		if len(names) == 0 {
+			return true
+		}
+		for _, name := range names {
+			if topic == name || strings.HasSuffix(name, ":") && strings.HasPrefix(topic, name) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,353 @@
+// Package diff computes line diffs between two texts using Myers' O(ND)
+// algorithm and groups them into unified-style hunks.
+package diff
+
+import "strings"
+
+// DefaultContext is the number of unchanged lines kept around each change
+// when grouping hunks, as in `diff -u`.
+const DefaultContext = 3
+
+// Op is the kind of a diff line.
+type Op byte
+
+const (
+	// Equal marks a line present in both texts.
+	Equal Op = ' '
+	// Insert marks a line only present in the new text.
+	Insert Op = '+'
+	// Delete marks a line only present in the old text.
+	Delete Op = '-'
+)
+
+// Line is one line of a hunk. Text excludes the line terminator; NoEOL is
+// set on the last line of a text that does not end in a newline.
+type Line struct {
+	Op    Op
+	Text  string
+	NoEOL bool
+}
+
+// Hunk is a group of changes with surrounding context. Starts are 1-based
+// line numbers as in unified diff headers; when a side has no lines, its
+// start is the line before the change.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+
+	// 0-based half-open line ranges covered by the hunk.
+	oldFrom, oldTo int
+	newFrom, newTo int
+}
+
+// edit is one step of an edit script. Indexes are 0-based line numbers.
+type edit struct {
+	op       Op
+	oldIndex int
+	newIndex int
+}
+
+// SplitLines splits s into lines, keeping each line's "\n" terminator so the
+// text can be rebuilt exactly by concatenation.
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Hunks returns the hunks that turn oldText into newText, with context
+// unchanged lines around each change. Changes closer than 2*context lines
+// share a hunk.
+func Hunks(oldText, newText string, context int) []Hunk {
+	a, b := SplitLines(oldText), SplitLines(newText)
+	return group(a, b, compute(a, b), context)
+}
+
+// Stats counts the lines added and deleted between oldText and newText.
+func Stats(oldText, newText string) (additions, deletions int) {
+	for _, e := range compute(SplitLines(oldText), SplitLines(newText)) {
+		switch e.op {
+		case Insert:
+			additions++
+		case Delete:
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// ApplyHunks rebuilds a text from the hunks between oldText and newText,
+// taking the new side of the hunks for which accept returns true and the old
+// side of the others. Hunks are grouped with the given context.
+func ApplyHunks(oldText, newText string, context int, accept func(index int) bool) string {
+	a, b := SplitLines(oldText), SplitLines(newText)
+	hunks := group(a, b, compute(a, b), context)
+
+	var out strings.Builder
+	pos := 0
+	for i, h := range hunks {
+		writeLines(&out, a[pos:h.oldFrom])
+		if accept(i) {
+			writeLines(&out, b[h.newFrom:h.newTo])
+		} else {
+			writeLines(&out, a[h.oldFrom:h.oldTo])
+		}
+		pos = h.oldTo
+	}
+	writeLines(&out, a[pos:])
+	return out.String()
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, l := range lines {
+		b.WriteString(l)
+	}
+}
+
+// compute returns the edit script from a to b.
+func compute(a, b []string) []edit {
+	// Intern lines so the search compares ints.
+	ids := make(map[string]int, len(a)+len(b))
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, l := range lines {
+			id, ok := ids[l]
+			if !ok {
+				id = len(ids)
+				ids[l] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+
+	d := &differ{x: intern(a), y: intern(b)}
+	d.edits = make([]edit, 0, len(a)+len(b))
+	d.diff(0, len(a), 0, len(b))
+	return d.edits
+}
+
+// differ runs the linear-space variant of Myers' algorithm: it finds the
+// middle snake of the shortest edit path and recurses on both halves.
+type differ struct {
+	x, y   []int
+	vf, vb []int
+	edits  []edit
+}
+
+func (d *differ) diff(x0, x1, y0, y1 int) {
+	// Common prefix and suffix never need searching.
+	for x0 < x1 && y0 < y1 && d.x[x0] == d.y[y0] {
+		d.edits = append(d.edits, edit{op: Equal, oldIndex: x0, newIndex: y0})
+		x0++
+		y0++
+	}
+	suffix := 0
+	for x1-suffix > x0 && y1-suffix > y0 && d.x[x1-1-suffix] == d.y[y1-1-suffix] {
+		suffix++
+	}
+	x1, y1 = x1-suffix, y1-suffix
+
+	switch {
+	case x0 == x1 || y0 == y1:
+		d.replace(x0, x1, y0, y1)
+	default:
+		xs, ys, xe, ye, ok := d.middleSnake(x0, x1, y0, y1)
+		if !ok {
+			d.replace(x0, x1, y0, y1)
+			break
+		}
+		d.diff(x0, xs, y0, ys)
+		for i, j := xs, ys; i < xe; i, j = i+1, j+1 {
+			d.edits = append(d.edits, edit{op: Equal, oldIndex: i, newIndex: j})
+		}
+		d.diff(xe, x1, ye, y1)
+	}
+
+	for k := 0; k < suffix; k++ {
+		d.edits = append(d.edits, edit{op: Equal, oldIndex: x1 + k, newIndex: y1 + k})
+	}
+}
+
+// middleSnake returns the start and end of the middle snake of a shortest
+// edit path between x[x0:x1] and y[y0:y1], searching forward from the start
+// and backward from the end until the paths overlap.
+func (d *differ) middleSnake(x0, x1, y0, y1 int) (xs, ys, xe, ye int, ok bool) {
+	n, m := x1-x0, y1-y0
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+
+	size := 2*maxD + 3
+	if cap(d.vf) < size {
+		d.vf = make([]int, size)
+		d.vb = make([]int, size)
+	}
+	vf, vb := d.vf[:size], d.vb[:size]
+	vf[off+1], vb[off+1] = 0, 0
+
+	for D := 0; D <= maxD; D++ {
+		// Forward: diagonal k = x - y.
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.x[x0+x] == d.y[y0+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+
+			if c := delta - k; odd && c >= -(D-1) && c <= D-1 && x+vb[off+c] >= n {
+				return x0 + sx, y0 + sy, x0 + x, y0 + y, true
+			}
+		}
+
+		// Backward, on the reversed sequences: diagonal c = delta - k.
+		for c := -D; c <= D; c += 2 {
+			var x int
+			if c == -D || (c != D && vb[off+c-1] < vb[off+c+1]) {
+				x = vb[off+c+1]
+			} else {
+				x = vb[off+c-1] + 1
+			}
+			y := x - c
+			sx, sy := x, y
+			for x < n && y < m && d.x[x1-1-x] == d.y[y1-1-y] {
+				x++
+				y++
+			}
+			vb[off+c] = x
+
+			if k := delta - c; !odd && k >= -D && k <= D && x+vf[off+k] >= n {
+				return x1 - x, y1 - y, x1 - sx, y1 - sy, true
+			}
+		}
+	}
+
+	return 0, 0, 0, 0, false
+}
+
+// replace emits x[x0:x1] as deleted and y[y0:y1] as inserted.
+func (d *differ) replace(x0, x1, y0, y1 int) {
+	for i := x0; i < x1; i++ {
+		d.edits = append(d.edits, edit{op: Delete, oldIndex: i, newIndex: y0})
+	}
+	for j := y0; j < y1; j++ {
+		d.edits = append(d.edits, edit{op: Insert, oldIndex: x1, newIndex: j})
+	}
+}
+
+// group splits an edit script into hunks with context lines.
+func group(a, b []string, edits []edit, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks []Hunk
+	lastTo := 0
+	for start := 0; start < len(edits); {
+		// Find the next change.
+		for start < len(edits) && edits[start].op == Equal {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend while the gap of equal lines to the next change is small.
+		end := start
+		for {
+			for end < len(edits) && edits[end].op != Equal {
+				end++
+			}
+			gap := end
+			for gap < len(edits) && edits[gap].op == Equal {
+				gap++
+			}
+			if gap == len(edits) || gap-end > 2*context {
+				break
+			}
+			end = gap
+		}
+
+		// Context never reaches back into the previous hunk.
+		from := max(start-context, lastTo)
+		to := min(end+context, len(edits))
+		hunks = append(hunks, makeHunk(a, b, edits, from, to))
+		start, lastTo = to, to
+	}
+	return hunks
+}
+
+func makeHunk(a, b []string, edits []edit, from, to int) Hunk {
+	h := Hunk{oldFrom: -1, newFrom: -1}
+	for _, e := range edits[from:to] {
+		switch e.op {
+		case Equal:
+			h.Lines = append(h.Lines, makeLine(Equal, a[e.oldIndex]))
+			h.markOld(e.oldIndex)
+			h.markNew(e.newIndex)
+		case Delete:
+			h.Lines = append(h.Lines, makeLine(Delete, a[e.oldIndex]))
+			h.markOld(e.oldIndex)
+		case Insert:
+			h.Lines = append(h.Lines, makeLine(Insert, b[e.newIndex]))
+			h.markNew(e.newIndex)
+		}
+	}
+
+	// A side with no lines sits right before the next edit's position.
+	first := edits[from]
+	if h.oldFrom < 0 {
+		h.oldFrom, h.oldTo = first.oldIndex, first.oldIndex
+	}
+	if h.newFrom < 0 {
+		h.newFrom, h.newTo = first.newIndex, first.newIndex
+	}
+
+	h.OldLines = h.oldTo - h.oldFrom
+	h.NewLines = h.newTo - h.newFrom
+	h.OldStart = h.oldFrom + 1
+	if h.OldLines == 0 {
+		h.OldStart = h.oldFrom
+	}
+	h.NewStart = h.newFrom + 1
+	if h.NewLines == 0 {
+		h.NewStart = h.newFrom
+	}
+	return h
+}
+
+func (h *Hunk) markOld(i int) {
+	if h.oldFrom < 0 {
+		h.oldFrom = i
+	}
+	h.oldTo = i + 1
+}
+
+func (h *Hunk) markNew(i int) {
+	if h.newFrom < 0 {
+		h.newFrom = i
+	}
+	h.newTo = i + 1
+}
+
+func makeLine(op Op, raw string) Line {
+	text, hasEOL := strings.CutSuffix(raw, "\n")
+	return Line{Op: op, Text: strings.TrimSuffix(text, "\r"), NoEOL: !hasEOL}
+}
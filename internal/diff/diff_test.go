@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestHunksGroupsChangesWithContext(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	hunks := Hunks(oldText, newText, DefaultContext)
+	if len(hunks) != 2 {
+		t.Fatalf("hunks = %d, want 2: %+v", len(hunks), hunks)
+	}
+
+	first := hunks[0]
+	if first.OldStart != 1 || first.OldLines != 5 || first.NewStart != 1 || first.NewLines != 5 {
+		t.Fatalf("first hunk header = -%d,%d +%d,%d", first.OldStart, first.OldLines, first.NewStart, first.NewLines)
+	}
+	if first.Lines[1] != (Line{Op: Delete, Text: "b"}) || first.Lines[2] != (Line{Op: Insert, Text: "B"}) {
+		t.Fatalf("first hunk lines = %+v", first.Lines)
+	}
+
+	last := hunks[1]
+	if last.OldStart != 11 || last.OldLines != 3 || last.NewStart != 11 || last.NewLines != 4 {
+		t.Fatalf("last hunk header = -%d,%d +%d,%d", last.OldStart, last.OldLines, last.NewStart, last.NewLines)
+	}
+	if got := last.Lines[len(last.Lines)-1]; got != (Line{Op: Insert, Text: "n"}) {
+		t.Fatalf("last line = %+v", got)
+	}
+}
+
+func TestHunksIdenticalAndEmpty(t *testing.T) {
+	if hunks := Hunks("same\n", "same\n", DefaultContext); len(hunks) != 0 {
+		t.Fatalf("identical texts produced hunks: %+v", hunks)
+	}
+
+	hunks := Hunks("", "x\ny\n", DefaultContext)
+	if len(hunks) != 1 || hunks[0].OldStart != 0 || hunks[0].OldLines != 0 || hunks[0].NewStart != 1 || hunks[0].NewLines != 2 {
+		t.Fatalf("create hunks = %+v", hunks)
+	}
+}
+
+func TestApplyHunksSelectsSides(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		oldLines = append(oldLines, line)
+		switch i {
+		case 2:
+			newLines = append(newLines, "C")
+		case 15:
+			newLines = append(newLines, "P", "extra")
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+	oldText := strings.Join(oldLines, "\n")
+	newText := strings.Join(newLines, "\n")
+
+	if got := ApplyHunks(oldText, newText, DefaultContext, func(int) bool { return true }); got != newText {
+		t.Fatalf("accept all = %q, want new text", got)
+	}
+	if got := ApplyHunks(oldText, newText, DefaultContext, func(int) bool { return false }); got != oldText {
+		t.Fatalf("reject all = %q, want old text", got)
+	}
+
+	got := ApplyHunks(oldText, newText, DefaultContext, func(i int) bool { return i == 0 })
+	want := strings.Replace(oldText, "\nc\n", "\nC\n", 1)
+	if got != want {
+		t.Fatalf("accept first = %q, want %q", got, want)
+	}
+}
+
+func TestComputeIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for iter := 0; iter < 500; iter++ {
+		a := randomLines(rng, rng.IntN(12))
+		b := randomLines(rng, rng.IntN(12))
+
+		edits := compute(a, b)
+		var rebuilt []string
+		changes := 0
+		for _, e := range edits {
+			switch e.op {
+			case Equal:
+				if a[e.oldIndex] != b[e.newIndex] {
+					t.Fatalf("equal edit joins %q and %q", a[e.oldIndex], b[e.newIndex])
+				}
+				rebuilt = append(rebuilt, b[e.newIndex])
+			case Insert:
+				rebuilt = append(rebuilt, b[e.newIndex])
+				changes++
+			case Delete:
+				changes++
+			}
+		}
+		if strings.Join(rebuilt, "") != strings.Join(b, "") {
+			t.Fatalf("edits of %q -> %q rebuild %q", a, b, rebuilt)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changes != want {
+			t.Fatalf("edits of %q -> %q: %d changes, want %d", a, b, changes, want)
+		}
+	}
+}
+
+func randomLines(rng *rand.Rand, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = string(rune('a'+rng.IntN(4))) + "\n"
+	}
+	return lines
+}
+
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestUnified(t *testing.T) {
+	got := Unified("a/main.go", "b/main.go", "one\ntwo\nthree", "one\n2\nthree", DefaultContext)
+	want := "--- a/main.go\n+++ b/main.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" one\n" +
+		"-two\n" +
+		"+2\n" +
+		" three\n" +
+		"\\ No newline at end of file\n"
+	if got != want {
+		t.Fatalf("unified diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := Unified("a", "b", "same\n", "same\n", DefaultContext); got != "" {
+		t.Fatalf("equal texts rendered %q", got)
+	}
+
+	if add, del := Stats("a\nb\nc\n", "a\nc\nd\ne\n"); add != 2 || del != 1 {
+		t.Fatalf("stats = +%d -%d, want +2 -1", add, del)
+	}
+}
@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified renders the difference between oldText and newText in unified
+// diff format with the given file names and context. It returns "" when the
+// texts are equal.
+func Unified(oldName, newName, oldText, newText string, context int) string {
+	hunks := Hunks(oldText, newText, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		WriteHunk(&b, h)
+	}
+	return b.String()
+}
+
+// WriteHunk writes one hunk in unified diff format.
+func WriteHunk(b *strings.Builder, h Hunk) {
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+	for _, l := range h.Lines {
+		b.WriteByte(byte(l.Op))
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+		if l.NoEOL {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
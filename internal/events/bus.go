@@ -0,0 +1,106 @@
+// Package events is the in-process event bus between the backend and
+// whatever presents it: the Wails webview, the control API, logs and tests.
+// Publishers emit typed payloads on named topics; subscribers receive every
+// event and pick the ones they need.
+package events
+
+import (
+	"sync"
+)
+
+// Event is one published event. Payload is the value passed to Emit and is
+// serialised as JSON by sinks that leave the process.
+type Event struct {
+	Name    string
+	Payload any
+}
+
+// Handler receives published events. Handlers run synchronously on the
+// publishing goroutine, in publish order, so a slow handler must hand
+// events off to its own goroutine.
+type Handler func(Event)
+
+// Topic names an event and fixes the type of its payload, so publishers
+// and typed subscribers cannot disagree on it.
+type Topic[T any] string
+
+// Name returns the wire name of the topic, e.g. "agent:message".
+func (t Topic[T]) Name() string {
+	return string(t)
+}
+
+// Bus fans events out to subscribers. The zero value is an empty bus, and
+// a nil *Bus drops everything published on it.
+type Bus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   []subscription
+}
+
+type subscription struct {
+	id      int
+	handler Handler
+}
+
+// NewBus returns a bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers h for every event and returns a function that
+// removes it again.
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs = append(b.subs, subscription{id: id, handler: h})
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// Copy rather than filter in place: Publish may be iterating over
+		// the old slice.
+		subs := make([]subscription, 0, len(b.subs))
+		for _, s := range b.subs {
+			if s.id != id {
+				subs = append(subs, s)
+			}
+		}
+		b.subs = subs
+	}
+}
+
+// Publish delivers an event to every current subscriber. Prefer Emit,
+// which checks the payload type against the topic.
+func (b *Bus) Publish(name string, payload any) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	event := Event{Name: name, Payload: payload}
+	for _, s := range subs {
+		s.handler(event)
+	}
+}
+
+// Emit publishes payload on topic.
+func Emit[T any](b *Bus, topic Topic[T], payload T) {
+	b.Publish(topic.Name(), payload)
+}
+
+// On subscribes fn to a single topic.
+func On[T any](b *Bus, topic Topic[T], fn func(T)) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		if e.Name != topic.Name() {
+			return
+		}
+		if payload, ok := e.Payload.(T); ok {
+			fn(payload)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type chunk struct {
+	SessionID string `json:"sessionId"`
+	Text      string `json:"text"`
+}
+
+var (
+	topicChunk = Topic[chunk]("test:chunk")
+	topicDone  = Topic[string]("test:done")
+)
+
+func TestBusDeliversInOrder(t *testing.T) {
+	bus := NewBus()
+	var all []string
+	bus.Subscribe(func(e Event) {
+		all = append(all, e.Name)
+	})
+	var texts []string
+	On(bus, topicChunk, func(c chunk) {
+		texts = append(texts, c.Text)
+	})
+
+	Emit(bus, topicChunk, chunk{SessionID: "s1", Text: "a"})
+	Emit(bus, topicDone, "end_turn")
+	Emit(bus, topicChunk, chunk{SessionID: "s1", Text: "b"})
+
+	if got := strings.Join(all, ","); got != "test:chunk,test:done,test:chunk" {
+		t.Fatalf("events = %s", got)
+	}
+	if got := strings.Join(texts, ""); got != "ab" {
+		t.Fatalf("typed subscriber got %q", got)
+	}
+}
+
+func TestBusUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	count := 0
+	unsubscribe := On(bus, topicDone, func(string) { count++ })
+
+	Emit(bus, topicDone, "first")
+	unsubscribe()
+	unsubscribe()
+	Emit(bus, topicDone, "second")
+
+	if count != 1 {
+		t.Fatalf("handler ran %d times, want 1", count)
+	}
+}
+
+func TestBusPayloadSerialisesForSinks(t *testing.T) {
+	bus := NewBus()
+	var raw []byte
+	bus.Subscribe(func(e Event) {
+		raw, _ = json.Marshal(e.Payload)
+	})
+
+	Emit(bus, topicChunk, chunk{SessionID: "s1", Text: "hi"})
+	if string(raw) != `{"sessionId":"s1","text":"hi"}` {
+		t.Fatalf("payload = %s", raw)
+	}
+
+	var nilBus *Bus
+	Emit(nilBus, topicDone, "dropped")
+}
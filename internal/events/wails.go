@@ -0,0 +1,16 @@
+package events
+
+import (
+	"context"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// WailsSink forwards every event to the webview, under the topic name, for
+// the frontend's EventsOn listeners. ctx is the context Wails passes to
+// the app on startup.
+func WailsSink(ctx context.Context) Handler {
+	return func(e Event) {
+		wailsRuntime.EventsEmit(ctx, e.Name, e.Payload)
+	}
+}
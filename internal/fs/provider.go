@@ -3,6 +3,7 @@ package fs
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,14 +11,18 @@ import (
 	"time"
 
 	"bytesmith/internal/acp"
+
+	"github.com/google/uuid"
 )
 
 // FileChange records a single file modification made by an agent,
 // capturing before/after content for undo and review.
 type FileChange struct {
+	ID         string
 	Path       string
 	OldContent string
 	NewContent string
+	Created    bool // the file did not exist before the write
 	Timestamp  time.Time
 	SessionID  string
 	AgentName  string
@@ -25,32 +30,57 @@ type FileChange struct {
 
 // Provider handles fs/read_text_file and fs/write_text_file requests from agents.
 // It reads and writes files on disk, tracks all modifications for undo/review,
-// and emits events when files are changed.
+// and emits events when files are changed. Access is sandboxed to the
+// session's cwd and the extra roots; anything else, and sensitive files,
+// must be approved through OnAccessRequest.
 type Provider struct {
 	changes       []FileChange
 	mu            sync.RWMutex
 	onFileChanged func(FileChange)
+
+	sessions        map[string]sandboxSession
+	extraRoots      []string
+	sensitive       []string
+	onAccessRequest func(AccessRequest) bool
+
+	review          map[string]*reviewSession
+	onStagedChanged func(sessionID, path string)
 }
 
 // NewProvider creates a new file system Provider.
 func NewProvider() *Provider {
 	return &Provider{
-		changes: make([]FileChange, 0),
+		changes:   make([]FileChange, 0),
+		sessions:  make(map[string]sandboxSession),
+		review:    make(map[string]*reviewSession),
+		sensitive: append([]string(nil), DefaultSensitivePatterns...),
 	}
 }
 
 // HandleReadTextFile reads a text file from disk, applying optional line offset
 // and limit. Offset is 1-based. If offset is 0 or negative, it defaults to 1.
 // If limit is 0 or negative, all lines from offset onward are returned.
+// Files staged in the session's review overlay are read from the overlay.
 func (p *Provider) HandleReadTextFile(params acp.FSReadTextFileParams) (*acp.FSReadTextFileResult, error) {
-	f, err := os.Open(params.Path)
+	path, err := p.checkAccess(params.SessionID, params.Path, OpRead)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file %s: %w", params.Path, err)
+		return nil, err
+	}
+
+	var r io.Reader
+	if content, ok := p.stagedContent(params.SessionID, path); ok {
+		r = strings.NewReader(content)
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file %s: %w", params.Path, err)
+		}
+		defer f.Close()
+		r = f
 	}
-	defer f.Close()
 
 	var allLines []string
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(r)
 	// Increase scanner buffer for long lines (1MB).
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 	for scanner.Scan() {
@@ -97,33 +127,49 @@ func (p *Provider) HandleReadTextFile(params acp.FSReadTextFileParams) (*acp.FSR
 
 // HandleWriteTextFile writes content to a file, creating parent directories
 // if needed. It reads the existing content first to record the change for
-// undo capability and emits a FileChanged event.
+// undo capability and emits a FileChanged event. In review mode the write is
+// staged in the session's overlay instead.
 func (p *Provider) HandleWriteTextFile(params acp.FSWriteTextFileParams) error {
+	path, err := p.checkAccess(params.SessionID, params.Path, OpWrite)
+	if err != nil {
+		return err
+	}
+
+	if staged, err := p.stage(params.SessionID, path, params.Content); err != nil || staged {
+		return err
+	}
+
 	// Read existing content for change tracking (ignore error if file doesn't exist).
 	var oldContent string
-	if data, err := os.ReadFile(params.Path); err == nil {
+	created := true
+	if data, err := os.ReadFile(path); err == nil {
 		oldContent = string(data)
+		created = false
 	}
 
 	// Create parent directories if they don't exist.
-	dir := filepath.Dir(params.Path)
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("failed to create directories for %s: %w", params.Path, err)
+		return fmt.Errorf("failed to create directories for %s: %w", path, err)
 	}
 
 	// Write the file.
-	if err := os.WriteFile(params.Path, []byte(params.Content), 0o644); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", params.Path, err)
+	if err := os.WriteFile(path, []byte(params.Content), 0o644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
 	}
 
 	change := FileChange{
-		Path:       params.Path,
+		ID:         uuid.NewString(),
+		Path:       path,
 		OldContent: oldContent,
 		NewContent: params.Content,
+		Created:    created,
 		Timestamp:  time.Now(),
+		SessionID:  params.SessionID,
 	}
 
 	p.mu.Lock()
+	change.AgentName = p.sessions[params.SessionID].agentName
 	p.changes = append(p.changes, change)
 	handler := p.onFileChanged
 	p.mu.Unlock()
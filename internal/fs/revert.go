@@ -0,0 +1,49 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrConflict is returned by RevertChange when the file on disk no longer
+// holds the content the agent wrote.
+var ErrConflict = errors.New("file changed since the agent wrote it")
+
+// RevertChange undoes a recorded write: the file is restored to oldContent,
+// or removed when the write created it. The revert is refused with
+// ErrConflict unless the file still holds newContent, so edits made after
+// the agent's write are never lost.
+func RevertChange(path, oldContent, newContent string, created bool) error {
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		if created {
+			// Already gone; nothing to undo.
+			return nil
+		}
+		return fmt.Errorf("%w: %s was deleted", ErrConflict, path)
+	case err != nil:
+		return fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	if string(data) != newContent {
+		return fmt.Errorf("%w: %s", ErrConflict, path)
+	}
+
+	if created {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove file %s: %w", path, err)
+		}
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(oldContent), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRevertChange(t *testing.T) {
+	dir := t.TempDir()
+
+	edited := filepath.Join(dir, "edited.txt")
+	if err := os.WriteFile(edited, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevertChange(edited, "old", "new", false); err != nil {
+		t.Fatalf("revert edit: %v", err)
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "old" {
+		t.Fatalf("content after revert = %q, want old", data)
+	}
+
+	created := filepath.Join(dir, "created.txt")
+	if err := os.WriteFile(created, []byte("fresh"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevertChange(created, "", "fresh", true); err != nil {
+		t.Fatalf("revert create: %v", err)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Fatalf("created file still exists after revert")
+	}
+
+	// The user edited the file after the agent wrote it.
+	if err := os.WriteFile(edited, []byte("user edit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevertChange(edited, "old", "new", false); !errors.Is(err, ErrConflict) {
+		t.Fatalf("revert over user edit: err = %v, want ErrConflict", err)
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "user edit" {
+		t.Fatalf("conflicting revert modified the file: %q", data)
+	}
+}
@@ -0,0 +1,298 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"bytesmith/internal/diff"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotStaged is returned when a review operation names a file that has
+// no staged change.
+var ErrNotStaged = errors.New("no staged change")
+
+// StagedFile is an agent write held in a session's review overlay.
+type StagedFile struct {
+	SessionID string
+	Path      string
+	// Base is the disk content the change applies to; BaseExists is false
+	// when the agent is creating the file.
+	Base       string
+	BaseExists bool
+	Content    string
+	UpdatedAt  time.Time
+}
+
+// reviewSession is the overlay of a session in review mode.
+type reviewSession struct {
+	enabled bool
+	files   map[string]*StagedFile
+}
+
+// SetReviewMode turns review mode on or off for a session. While it is on,
+// agent writes are staged instead of written to disk. Turning it off keeps
+// already staged files until they are accepted or rejected.
+func (p *Provider) SetReviewMode(sessionID string, enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rs := p.reviewSessionLocked(sessionID)
+	rs.enabled = enabled
+	if !enabled && len(rs.files) == 0 {
+		delete(p.review, sessionID)
+	}
+}
+
+// ReviewMode reports whether review mode is on for a session.
+func (p *Provider) ReviewMode(sessionID string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	rs := p.review[sessionID]
+	return rs != nil && rs.enabled
+}
+
+// OnStagedChanged registers a callback invoked whenever a session's staged
+// files change. Only one handler is supported.
+func (p *Provider) OnStagedChanged(handler func(sessionID, path string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onStagedChanged = handler
+}
+
+// StagedFiles returns the staged files of a session sorted by path.
+func (p *Provider) StagedFiles(sessionID string) []StagedFile {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	rs := p.review[sessionID]
+	if rs == nil {
+		return []StagedFile{}
+	}
+	out := make([]StagedFile, 0, len(rs.files))
+	for _, f := range rs.files {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// StagedHunks returns the hunks between a staged file's base and content.
+func (p *Provider) StagedHunks(sessionID, path string) ([]diff.Hunk, error) {
+	f, err := p.stagedFile(sessionID, path)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Hunks(f.Base, f.Content, diff.DefaultContext), nil
+}
+
+// AcceptFile writes a staged file to disk and drops it from the overlay.
+// The write is reported through OnFileChanged like a direct agent write.
+func (p *Provider) AcceptFile(sessionID, path string) error {
+	return p.acceptStaged(sessionID, path, func(f *StagedFile) string { return f.Content })
+}
+
+// RejectFile drops a staged file without touching the disk.
+func (p *Provider) RejectFile(sessionID, path string) error {
+	return p.updateStaged(sessionID, path, func(f *StagedFile) string { return f.Base })
+}
+
+// AcceptHunk writes one hunk of a staged file to disk. The rest of the
+// staged content stays in the overlay.
+func (p *Provider) AcceptHunk(sessionID, path string, index int) error {
+	return p.acceptStaged(sessionID, path, func(f *StagedFile) string {
+		return diff.ApplyHunks(f.Base, f.Content, diff.DefaultContext, func(i int) bool { return i == index })
+	})
+}
+
+// RejectHunk removes one hunk from a staged file.
+func (p *Provider) RejectHunk(sessionID, path string, index int) error {
+	return p.updateStaged(sessionID, path, func(f *StagedFile) string {
+		return diff.ApplyHunks(f.Base, f.Content, diff.DefaultContext, func(i int) bool { return i != index })
+	})
+}
+
+// stage records an agent write in the session's overlay. It reports false
+// when the session is not reviewing and the path has no staged change, in
+// which case the write goes to disk.
+func (p *Provider) stage(sessionID, path, content string) (bool, error) {
+	p.mu.Lock()
+	rs := p.review[sessionID]
+	if rs == nil {
+		p.mu.Unlock()
+		return false, nil
+	}
+	f := rs.files[path]
+	if f == nil && !rs.enabled {
+		p.mu.Unlock()
+		return false, nil
+	}
+	if f == nil {
+		f = &StagedFile{SessionID: sessionID, Path: path}
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			f.Base, f.BaseExists = string(data), true
+		case !os.IsNotExist(err):
+			p.mu.Unlock()
+			return false, fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		rs.files[path] = f
+	}
+	f.Content = content
+	f.UpdatedAt = time.Now()
+	handler := p.onStagedChanged
+	p.mu.Unlock()
+
+	if handler != nil {
+		handler(sessionID, path)
+	}
+	return true, nil
+}
+
+// stagedContent returns the overlaid content of path for a session.
+func (p *Provider) stagedContent(sessionID, path string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	rs := p.review[sessionID]
+	if rs == nil {
+		return "", false
+	}
+	f := rs.files[path]
+	if f == nil {
+		return "", false
+	}
+	return f.Content, true
+}
+
+func (p *Provider) stagedFile(sessionID, path string) (StagedFile, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if rs := p.review[sessionID]; rs != nil {
+		if f := rs.files[path]; f != nil {
+			return *f, nil
+		}
+	}
+	return StagedFile{}, fmt.Errorf("%w for %s", ErrNotStaged, path)
+}
+
+// acceptStaged writes next(f) to disk, provided the disk still holds the
+// staged file's base, then rebases the staged file on it.
+func (p *Provider) acceptStaged(sessionID, path string, next func(f *StagedFile) string) error {
+	p.mu.Lock()
+	rs := p.review[sessionID]
+	var f *StagedFile
+	if rs != nil {
+		f = rs.files[path]
+	}
+	if f == nil {
+		p.mu.Unlock()
+		return fmt.Errorf("%w for %s", ErrNotStaged, path)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil && (!f.BaseExists || string(data) != f.Base):
+		err = fmt.Errorf("%w: %s", ErrConflict, path)
+	case os.IsNotExist(err) && f.BaseExists:
+		err = fmt.Errorf("%w: %s was deleted", ErrConflict, path)
+	case os.IsNotExist(err):
+		err = nil
+	}
+	if err != nil {
+		p.mu.Unlock()
+		return err
+	}
+
+	content := next(f)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to create directories for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+
+	change := FileChange{
+		ID:         uuid.NewString(),
+		Path:       path,
+		OldContent: f.Base,
+		NewContent: content,
+		Created:    !f.BaseExists,
+		Timestamp:  time.Now(),
+		SessionID:  sessionID,
+		AgentName:  p.sessions[sessionID].agentName,
+	}
+	p.changes = append(p.changes, change)
+
+	f.Base, f.BaseExists = content, true
+	p.dropIfSettledLocked(sessionID, path)
+	changed := p.onFileChanged
+	staged := p.onStagedChanged
+	p.mu.Unlock()
+
+	if changed != nil {
+		changed(change)
+	}
+	if staged != nil {
+		staged(sessionID, path)
+	}
+	return nil
+}
+
+// updateStaged replaces a staged file's content with next(f).
+func (p *Provider) updateStaged(sessionID, path string, next func(f *StagedFile) string) error {
+	p.mu.Lock()
+	rs := p.review[sessionID]
+	var f *StagedFile
+	if rs != nil {
+		f = rs.files[path]
+	}
+	if f == nil {
+		p.mu.Unlock()
+		return fmt.Errorf("%w for %s", ErrNotStaged, path)
+	}
+
+	f.Content = next(f)
+	f.UpdatedAt = time.Now()
+	p.dropIfSettledLocked(sessionID, path)
+	handler := p.onStagedChanged
+	p.mu.Unlock()
+
+	if handler != nil {
+		handler(sessionID, path)
+	}
+	return nil
+}
+
+// dropIfSettledLocked removes a staged file whose content matches its base.
+// A staged creation is settled only once it exists on disk.
+func (p *Provider) dropIfSettledLocked(sessionID, path string) {
+	rs := p.review[sessionID]
+	f := rs.files[path]
+	if f.Content != f.Base {
+		return
+	}
+	if !f.BaseExists && f.Content != "" {
+		return
+	}
+	delete(rs.files, path)
+	if !rs.enabled && len(rs.files) == 0 {
+		delete(p.review, sessionID)
+	}
+}
+
+func (p *Provider) reviewSessionLocked(sessionID string) *reviewSession {
+	rs := p.review[sessionID]
+	if rs == nil {
+		rs = &reviewSession{files: make(map[string]*StagedFile)}
+		p.review[sessionID] = rs
+	}
+	return rs
+}
@@ -0,0 +1,97 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bytesmith/internal/acp"
+)
+
+func TestReviewModeStagesWrites(t *testing.T) {
+	p, workspace, _, _ := newSandboxedProvider(t)
+	path := filepath.Join(workspace, "main.go")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var changed []FileChange
+	p.OnFileChanged(func(c FileChange) { changed = append(changed, c) })
+	p.SetReviewMode("s1", true)
+
+	if err := p.HandleWriteTextFile(acp.FSWriteTextFileParams{SessionID: "s1", Path: path, Content: "two\n"}); err != nil {
+		t.Fatalf("staged write: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "one\n" {
+		t.Fatalf("disk changed while reviewing: %q", data)
+	}
+	res, err := p.HandleReadTextFile(acp.FSReadTextFileParams{SessionID: "s1", Path: path})
+	if err != nil || res.Content != "two\n" {
+		t.Fatalf("read through overlay = %q (%v), want staged content", res.Content, err)
+	}
+
+	if err := p.AcceptFile("s1", path); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "two\n" {
+		t.Fatalf("disk after accept = %q", data)
+	}
+	if len(changed) != 1 || changed[0].OldContent != "one\n" || changed[0].NewContent != "two\n" {
+		t.Fatalf("changes after accept = %+v", changed)
+	}
+	if staged := p.StagedFiles("s1"); len(staged) != 0 {
+		t.Fatalf("staged after accept = %+v", staged)
+	}
+}
+
+func TestReviewModeHunksAndConflicts(t *testing.T) {
+	p, workspace, _, _ := newSandboxedProvider(t)
+	path := filepath.Join(workspace, "list.txt")
+	base := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	if err := os.WriteFile(path, []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p.SetReviewMode("s1", true)
+	staged := "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n"
+	if err := p.HandleWriteTextFile(acp.FSWriteTextFileParams{SessionID: "s1", Path: path, Content: staged}); err != nil {
+		t.Fatal(err)
+	}
+
+	hunks, err := p.StagedHunks("s1", path)
+	if err != nil || len(hunks) != 2 {
+		t.Fatalf("hunks = %d (%v), want 2", len(hunks), err)
+	}
+
+	if err := p.AcceptHunk("s1", path, 0); err != nil {
+		t.Fatalf("accept hunk: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\n" {
+		t.Fatalf("disk after accepting first hunk = %q", data)
+	}
+
+	if err := p.RejectHunk("s1", path, 0); err != nil {
+		t.Fatalf("reject hunk: %v", err)
+	}
+	if _, err := p.StagedHunks("s1", path); !errors.Is(err, ErrNotStaged) {
+		t.Fatalf("file still staged after rejecting its last hunk: %v", err)
+	}
+
+	// A user edit on disk makes a later accept conflict.
+	if err := p.HandleWriteTextFile(acp.FSWriteTextFileParams{SessionID: "s1", Path: path, Content: "agent\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("user\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AcceptFile("s1", path); !errors.Is(err, ErrConflict) {
+		t.Fatalf("accept over user edit: err = %v, want ErrConflict", err)
+	}
+	if err := p.RejectFile("s1", path); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "user\n" {
+		t.Fatalf("reject touched the disk: %q", data)
+	}
+}
@@ -0,0 +1,246 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrAccessDenied is returned when an agent touches a path outside its
+// sandbox, or a sensitive file, and the access was not approved.
+var ErrAccessDenied = errors.New("access denied")
+
+// Access reasons reported in AccessRequest.
+const (
+	ReasonOutsideWorkspace = "outside_workspace"
+	ReasonSensitive        = "sensitive"
+)
+
+// Access operations reported in AccessRequest.
+const (
+	OpRead  = "read"
+	OpWrite = "write"
+)
+
+// DefaultSensitivePatterns are the files that need explicit approval even
+// inside the workspace. Patterns ending in "/" match a directory anywhere in
+// the path; the rest are matched against the file name.
+var DefaultSensitivePatterns = []string{
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.keystore",
+	"*.jks",
+	"id_rsa*",
+	"id_dsa*",
+	"id_ecdsa*",
+	"id_ed25519*",
+	".netrc",
+	".npmrc",
+	".pypirc",
+	".git-credentials",
+	".htpasswd",
+	"credentials.json",
+	".ssh/",
+	".gnupg/",
+	".aws/",
+	".kube/",
+	".docker/",
+}
+
+// sensitiveExemptSuffixes mark template files that match a sensitive
+// pattern but hold no secrets (".env.example").
+var sensitiveExemptSuffixes = []string{".example", ".sample", ".template"}
+
+// AccessRequest describes an access that needs approval.
+type AccessRequest struct {
+	SessionID string
+	AgentName string
+	Path      string
+	Operation string
+	Reason    string
+}
+
+// NeedsExplicitApproval reports whether only a permission rule or the user
+// may allow the access. The auto-approve setting never covers sensitive
+// files; an access outside the workspace is decided like any other
+// permission request.
+func (r AccessRequest) NeedsExplicitApproval() bool {
+	return r.Reason == ReasonSensitive
+}
+
+// sandboxSession is a session registered with the provider.
+type sandboxSession struct {
+	cwd       string
+	agentName string
+}
+
+// RegisterSession makes cwd the workspace root for sessionID. Files under it
+// and under the extra roots are accessible without approval.
+func (p *Provider) RegisterSession(sessionID, cwd, agentName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions[sessionID] = sandboxSession{cwd: cwd, agentName: agentName}
+}
+
+// SetExtraRoots sets directories that every session may access in addition
+// to its cwd. A leading "~/" expands to the home directory.
+func (p *Provider) SetExtraRoots(roots []string) {
+	expanded := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if rest, ok := strings.CutPrefix(root, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				root = filepath.Join(home, rest)
+			}
+		}
+		expanded = append(expanded, root)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.extraRoots = expanded
+}
+
+// SetSensitivePatterns replaces the sensitive file patterns. A nil slice
+// restores DefaultSensitivePatterns.
+func (p *Provider) SetSensitivePatterns(patterns []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if patterns == nil {
+		patterns = DefaultSensitivePatterns
+	}
+	p.sensitive = append([]string(nil), patterns...)
+}
+
+// OnAccessRequest registers the callback that approves out-of-sandbox and
+// sensitive accesses. Without one, such accesses are denied.
+func (p *Provider) OnAccessRequest(handler func(AccessRequest) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onAccessRequest = handler
+}
+
+// checkAccess resolves path for sessionID and asks for approval when it is
+// outside the session's roots or sensitive. It returns the resolved path,
+// with symlinks evaluated, which is the one that must be opened.
+func (p *Provider) checkAccess(sessionID, path, op string) (string, error) {
+	p.mu.RLock()
+	sess := p.sessions[sessionID]
+	roots := make([]string, 0, len(p.extraRoots)+1)
+	if sess.cwd != "" {
+		roots = append(roots, sess.cwd)
+	}
+	roots = append(roots, p.extraRoots...)
+	sensitive := p.sensitive
+	approve := p.onAccessRequest
+	p.mu.RUnlock()
+
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	if !filepath.IsAbs(path) {
+		if sess.cwd == "" {
+			return "", fmt.Errorf("relative path %s without a session cwd", path)
+		}
+		path = filepath.Join(sess.cwd, path)
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	req := AccessRequest{
+		SessionID: sessionID,
+		AgentName: sess.agentName,
+		Path:      resolved,
+		Operation: op,
+	}
+
+	switch {
+	case !withinAnyRoot(resolved, roots):
+		req.Reason = ReasonOutsideWorkspace
+	case isSensitive(resolved, sensitive) || isSensitive(filepath.Clean(path), sensitive):
+		req.Reason = ReasonSensitive
+	default:
+		return resolved, nil
+	}
+
+	if approve == nil || !approve(req) {
+		return "", fmt.Errorf("%w: %s %s (%s)", ErrAccessDenied, op, resolved, req.Reason)
+	}
+	return resolved, nil
+}
+
+// resolvePath evaluates the symlinks of the longest existing prefix of path
+// so that writes to files that do not exist yet are resolved as well.
+func resolvePath(path string) (string, error) {
+	path = filepath.Clean(path)
+	existing := path
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+func withinAnyRoot(path string, roots []string) bool {
+	for _, root := range roots {
+		if strings.TrimSpace(root) == "" {
+			continue
+		}
+		resolvedRoot, err := resolvePath(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolvedRoot, path)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitive(path string, patterns []string) bool {
+	base := filepath.Base(path)
+	for _, suffix := range sensitiveExemptSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return false
+		}
+	}
+
+	components := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			for _, c := range components {
+				if c == dir {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, base); matched {
+			return true
+		}
+	}
+	return false
+}
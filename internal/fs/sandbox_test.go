@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bytesmith/internal/acp"
+)
+
+func newSandboxedProvider(t *testing.T) (*Provider, string, string, *[]AccessRequest) {
+	t.Helper()
+
+	workspace := t.TempDir()
+	outside := t.TempDir()
+
+	p := NewProvider()
+	p.RegisterSession("s1", workspace, "agent")
+
+	var asked []AccessRequest
+	p.OnAccessRequest(func(req AccessRequest) bool {
+		asked = append(asked, req)
+		return false
+	})
+	return p, workspace, outside, &asked
+}
+
+func TestSandboxAllowsWorkspaceFiles(t *testing.T) {
+	p, workspace, _, asked := newSandboxedProvider(t)
+
+	path := filepath.Join(workspace, "src", "main.go")
+	if err := p.HandleWriteTextFile(acp.FSWriteTextFileParams{SessionID: "s1", Path: path, Content: "package main\n"}); err != nil {
+		t.Fatalf("write inside workspace: %v", err)
+	}
+	res, err := p.HandleReadTextFile(acp.FSReadTextFileParams{SessionID: "s1", Path: "src/main.go"})
+	if err != nil {
+		t.Fatalf("read relative path: %v", err)
+	}
+	if res.Content != "package main\n" {
+		t.Fatalf("content = %q", res.Content)
+	}
+	if len(*asked) != 0 {
+		t.Fatalf("approval requested for workspace files: %+v", *asked)
+	}
+
+	changes := p.GetChanges()
+	if len(changes) != 1 || changes[0].SessionID != "s1" || changes[0].AgentName != "agent" {
+		t.Fatalf("changes = %+v", changes)
+	}
+}
+
+func TestSandboxRejectsEscapes(t *testing.T) {
+	p, workspace, outside, asked := newSandboxedProvider(t)
+
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(workspace, "link")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	paths := []string{
+		secret,
+		filepath.Join(workspace, "..", filepath.Base(outside), "secret.txt"),
+		filepath.Join(link, "secret.txt"),
+	}
+	for _, path := range paths {
+		_, err := p.HandleReadTextFile(acp.FSReadTextFileParams{SessionID: "s1", Path: path})
+		if !errors.Is(err, ErrAccessDenied) {
+			t.Fatalf("read %s: err = %v, want ErrAccessDenied", path, err)
+		}
+	}
+
+	err := p.HandleWriteTextFile(acp.FSWriteTextFileParams{SessionID: "s1", Path: filepath.Join(link, "new.txt"), Content: "x"})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("write through symlink: err = %v, want ErrAccessDenied", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file written outside the workspace")
+	}
+
+	if len(*asked) != len(paths)+1 {
+		t.Fatalf("approval requests = %d, want %d", len(*asked), len(paths)+1)
+	}
+	for _, req := range *asked {
+		if req.Reason != ReasonOutsideWorkspace {
+			t.Fatalf("reason = %q, want %q", req.Reason, ReasonOutsideWorkspace)
+		}
+	}
+}
+
+func TestSandboxSensitiveFilesAndExtraRoots(t *testing.T) {
+	p, workspace, outside, asked := newSandboxedProvider(t)
+
+	for _, name := range []string{".env", ".env.example"} {
+		if err := os.WriteFile(filepath.Join(workspace, name), []byte("KEY=1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := p.HandleReadTextFile(acp.FSReadTextFileParams{SessionID: "s1", Path: filepath.Join(workspace, ".env")})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("read .env: err = %v, want ErrAccessDenied", err)
+	}
+	if len(*asked) != 1 || (*asked)[0].Reason != ReasonSensitive {
+		t.Fatalf("approval requests = %+v, want one sensitive", *asked)
+	}
+
+	if _, err := p.HandleReadTextFile(acp.FSReadTextFileParams{SessionID: "s1", Path: filepath.Join(workspace, ".env.example")}); err != nil {
+		t.Fatalf("read .env.example: %v", err)
+	}
+
+	p.SetExtraRoots([]string{outside})
+	if err := p.HandleWriteTextFile(acp.FSWriteTextFileParams{SessionID: "s1", Path: filepath.Join(outside, "notes.txt"), Content: "ok"}); err != nil {
+		t.Fatalf("write under extra root: %v", err)
+	}
+
+	p.OnAccessRequest(func(AccessRequest) bool { return true })
+	if _, err := p.HandleReadTextFile(acp.FSReadTextFileParams{SessionID: "s1", Path: filepath.Join(workspace, ".env")}); err != nil {
+		t.Fatalf("read approved .env: %v", err)
+	}
+}
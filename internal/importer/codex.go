@@ -0,0 +1,444 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bytesmith/internal/session"
+)
+
+// CodexAgentName is the agent imported Codex sessions are stored under, the
+// Codex agent ByteSmith discovers.
+const CodexAgentName = "codex-app-server"
+
+// maxCodexLine caps one rollout line. Lines carry whole tool outputs.
+const maxCodexLine = 32 * 1024 * 1024
+
+// Codex prepends these blocks to the first user message of a turn. They are
+// context for the model, not something the user typed.
+var codexContextPrefixes = []string{
+	"<environment_context>",
+	"<user_instructions>",
+	"# AGENTS.md instructions",
+}
+
+// CodexSessionsDir returns the directory Codex writes rollout files to:
+// $CODEX_HOME/sessions, or ~/.codex/sessions.
+func CodexSessionsDir() (string, error) {
+	if home := strings.TrimSpace(os.Getenv("CODEX_HOME")); home != "" {
+		return filepath.Join(home, "sessions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("importer: locate codex sessions: %w", err)
+	}
+	return filepath.Join(home, ".codex", "sessions"), nil
+}
+
+// ImportCodex merges every rollout file under dir into store. An empty dir
+// means CodexSessionsDir. Files that cannot be read are listed in
+// Result.Skipped.
+func ImportCodex(store session.Store, dir string) (Result, error) {
+	var result Result
+	if strings.TrimSpace(dir) == "" {
+		var err error
+		if dir, err = CodexSessionsDir(); err != nil {
+			return result, err
+		}
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".jsonl" {
+			return nil
+		}
+		rec, err := ReadCodexRolloutFile(path)
+		if err != nil {
+			result.Skip(path, err)
+			return nil
+		}
+		if len(rec.Messages) == 0 && len(rec.ToolCalls) == 0 {
+			return nil
+		}
+		return result.Add(store, rec)
+	})
+	if err != nil {
+		return result, fmt.Errorf("importer: codex sessions: %w", err)
+	}
+	return result, nil
+}
+
+// ReadCodexRolloutFile reads one Codex rollout file.
+func ReadCodexRolloutFile(path string) (*session.SessionRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCodexRollout(f)
+}
+
+// ReadCodexRollout converts a Codex rollout, one JSON object per line, into
+// a session record. Both the current format, where every line wraps a
+// typed payload, and the older one, where response items are written bare
+// after a header line, are understood.
+//
+// Message IDs are derived from the session ID and the item ID or, lacking
+// one, the line number; tool calls use their call ID.
+func ReadCodexRollout(r io.Reader) (*session.SessionRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCodexLine)
+
+	c := codexConverter{calls: make(map[string]int)}
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var line codexLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			// A rollout may end in a partially written line.
+			continue
+		}
+		c.line = lineNo
+		switch {
+		case len(line.Payload) > 0:
+			ts := parseCodexTime(line.Timestamp)
+			switch line.Type {
+			case "session_meta":
+				var meta codexMeta
+				if json.Unmarshal(line.Payload, &meta) == nil {
+					c.meta(meta, ts)
+				}
+			case "response_item":
+				var item codexItem
+				if json.Unmarshal(line.Payload, &item) == nil {
+					c.item(item, ts)
+				}
+			}
+		case line.RecordType != "":
+			// Legacy state snapshots carry no history.
+		case line.Type == "" && line.ID != "":
+			c.meta(codexMeta{ID: line.ID, Timestamp: line.Timestamp}, time.Time{})
+		default:
+			var item codexItem
+			if json.Unmarshal(scanner.Bytes(), &item) == nil {
+				c.item(item, parseCodexTime(line.Timestamp))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("importer: read rollout: %w", err)
+	}
+	if c.rec == nil {
+		return nil, errors.New("importer: rollout has no session header")
+	}
+	return c.rec, nil
+}
+
+// codexLine is a rollout line. Current rollouts set Type and Payload;
+// legacy ones write the header and response items bare.
+type codexLine struct {
+	Timestamp  string          `json:"timestamp"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	ID         string          `json:"id"`
+	RecordType string          `json:"record_type"`
+}
+
+type codexMeta struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	CWD       string `json:"cwd"`
+}
+
+type codexItem struct {
+	Type      string            `json:"type"`
+	ID        string            `json:"id"`
+	Role      string            `json:"role"`
+	Content   []codexContent    `json:"content"`
+	Summary   []codexContent    `json:"summary"`
+	Name      string            `json:"name"`
+	Arguments string            `json:"arguments"`
+	Input     string            `json:"input"`
+	CallID    string            `json:"call_id"`
+	Status    string            `json:"status"`
+	Action    *codexShellAction `json:"action"`
+	Output    json.RawMessage   `json:"output"`
+}
+
+type codexContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type codexShellAction struct {
+	Command []string `json:"command"`
+}
+
+// codexConverter accumulates the record of one rollout.
+type codexConverter struct {
+	rec   *session.SessionRecord
+	line  int
+	last  time.Time
+	calls map[string]int // call ID -> index in rec.ToolCalls
+}
+
+func (c *codexConverter) meta(meta codexMeta, ts time.Time) {
+	if c.rec != nil || strings.TrimSpace(meta.ID) == "" {
+		return
+	}
+	created := parseCodexTime(meta.Timestamp)
+	if created.IsZero() {
+		created = ts
+	}
+	c.last = created
+	c.rec = &session.SessionRecord{
+		ID:        meta.ID,
+		AgentName: CodexAgentName,
+		CWD:       meta.CWD,
+		CreatedAt: created,
+	}
+}
+
+func (c *codexConverter) item(item codexItem, ts time.Time) {
+	if c.rec == nil {
+		return
+	}
+	if ts.IsZero() {
+		ts = c.last
+	} else {
+		c.last = ts
+	}
+
+	switch item.Type {
+	case "message":
+		c.message(item, ts)
+	case "reasoning":
+		if text := joinCodexText(item.Summary); text != "" {
+			c.addMessage(item.ID, session.Message{Role: "agent", Kind: session.MessageThought, Content: text, Timestamp: ts})
+		}
+	case "function_call":
+		kind, title := codexToolKind(item.Name, item.Arguments)
+		c.addToolCall(item.CallID, session.ToolCallRecord{Title: title, Kind: kind, Status: "completed", Timestamp: ts}, prettyCodexJSON(item.Arguments))
+	case "custom_tool_call":
+		kind := "other"
+		if item.Name == "apply_patch" {
+			kind = "edit"
+		}
+		c.addToolCall(item.CallID, session.ToolCallRecord{Title: item.Name, Kind: kind, Status: codexStatus(item.Status), Timestamp: ts}, item.Input)
+	case "local_shell_call":
+		var command string
+		if item.Action != nil {
+			command = strings.Join(item.Action.Command, " ")
+		}
+		callID := nonEmpty(item.CallID, item.ID)
+		c.addToolCall(callID, session.ToolCallRecord{Title: command, Kind: "execute", Status: codexStatus(item.Status), Timestamp: ts}, "")
+	case "function_call_output", "custom_tool_call_output":
+		c.toolOutput(item.CallID, item.Output)
+	}
+}
+
+func (c *codexConverter) message(item codexItem, ts time.Time) {
+	var role string
+	switch item.Role {
+	case "user":
+		role = "user"
+	case "assistant":
+		role = "agent"
+	default:
+		// Developer and system messages are instructions, not history.
+		return
+	}
+
+	texts := make([]string, 0, len(item.Content))
+	for _, part := range item.Content {
+		text := strings.TrimSpace(part.Text)
+		if text == "" || role == "user" && isCodexContext(text) {
+			continue
+		}
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return
+	}
+	content := strings.Join(texts, "\n\n")
+	c.addMessage(item.ID, session.Message{Role: role, Kind: session.MessageText, Content: content, Timestamp: ts})
+	if role == "user" && c.rec.Title == "" {
+		c.rec.Title = session.TitleFromPrompt(content)
+	}
+}
+
+func (c *codexConverter) addMessage(id string, msg session.Message) {
+	// Message IDs are unique per database, and a forked rollout repeats
+	// the items of its parent, so IDs are scoped to the session.
+	if id == "" {
+		id = fmt.Sprint(c.line)
+	}
+	msg.ID = fmt.Sprintf("codex:%s:%s", c.rec.ID, id)
+	c.rec.Messages = append(c.rec.Messages, msg)
+}
+
+func (c *codexConverter) addToolCall(callID string, tc session.ToolCallRecord, input string) {
+	if callID == "" {
+		return
+	}
+	tc.ID = callID
+	if strings.TrimSpace(tc.Title) == "" {
+		tc.Title = "Tool call"
+	}
+	if input = strings.TrimSpace(input); input != "" {
+		tc.Content = "Input:\n" + input
+	}
+	c.calls[callID] = len(c.rec.ToolCalls)
+	c.rec.ToolCalls = append(c.rec.ToolCalls, tc)
+}
+
+// toolOutput attaches an output to the call it answers. Shell output goes
+// into a terminal part so exports show it as console output.
+func (c *codexConverter) toolOutput(callID string, raw json.RawMessage) {
+	i, ok := c.calls[callID]
+	if !ok {
+		return
+	}
+	tc := &c.rec.ToolCalls[i]
+
+	output, failed := codexOutput(raw)
+	if failed {
+		tc.Status = "failed"
+	}
+	if strings.TrimSpace(output) == "" {
+		return
+	}
+	partType := "content"
+	if tc.Kind == "execute" {
+		partType = "terminal"
+	}
+	tc.Parts = append(tc.Parts, session.ToolCallPart{Type: partType, Text: output})
+	tc.Content = strings.TrimSpace(tc.Content + "\n\nOutput:\n" + output)
+}
+
+// codexOutput extracts the text of a tool output and whether the tool
+// reported failure. Outputs are strings, sometimes holding a JSON object
+// with the shell output and exit code, or lists of content items.
+func codexOutput(raw json.RawMessage) (string, bool) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		var items []codexContent
+		if json.Unmarshal(raw, &items) == nil {
+			return joinCodexText(items), false
+		}
+		var wrapped struct {
+			Content string `json:"content"`
+			Success *bool  `json:"success"`
+		}
+		if json.Unmarshal(raw, &wrapped) == nil {
+			return wrapped.Content, wrapped.Success != nil && !*wrapped.Success
+		}
+		return strings.TrimSpace(string(raw)), false
+	}
+
+	var shell struct {
+		Output   *string `json:"output"`
+		Metadata struct {
+			ExitCode int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(text), "{") && json.Unmarshal([]byte(text), &shell) == nil && shell.Output != nil {
+		return *shell.Output, shell.Metadata.ExitCode != 0
+	}
+	return text, false
+}
+
+// codexToolKind classifies a function call and titles it. Shell calls are
+// titled with their command line.
+func codexToolKind(name, arguments string) (kind, title string) {
+	switch name {
+	case "shell", "container.exec", "shell_command", "exec_command":
+		var args struct {
+			Command json.RawMessage `json:"command"`
+			Cmd     string          `json:"cmd"`
+		}
+		_ = json.Unmarshal([]byte(arguments), &args)
+		var argv []string
+		var line string
+		switch {
+		case json.Unmarshal(args.Command, &argv) == nil:
+			line = strings.Join(argv, " ")
+		case json.Unmarshal(args.Command, &line) == nil:
+		default:
+			line = args.Cmd
+		}
+		return "execute", nonEmpty(line, name)
+	case "apply_patch":
+		return "edit", name
+	case "read_file", "view_image":
+		return "read", name
+	default:
+		return "other", name
+	}
+}
+
+func codexStatus(status string) string {
+	switch status {
+	case "", "completed":
+		return "completed"
+	case "incomplete":
+		return "failed"
+	default:
+		return status
+	}
+}
+
+func isCodexContext(text string) bool {
+	for _, prefix := range codexContextPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinCodexText(parts []codexContent) string {
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if text := strings.TrimSpace(p.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+func prettyCodexJSON(raw string) string {
+	var parsed any
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return raw
+	}
+	formatted, err := json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		return raw
+	}
+	return string(formatted)
+}
+
+func parseCodexTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+func nonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
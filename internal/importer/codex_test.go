@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bytesmith/internal/session"
+)
+
+const codexRollout = `{"timestamp":"2025-06-01T10:00:00.000Z","type":"session_meta","payload":{"id":"0197-codex","timestamp":"2025-06-01T10:00:00.000Z","cwd":"/work","originator":"codex_cli_rs"}}
+{"timestamp":"2025-06-01T10:00:01.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"be careful"}]}}
+{"timestamp":"2025-06-01T10:00:01.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>\n<cwd>/work</cwd>\n</environment_context>"}]}}
+{"timestamp":"2025-06-01T10:00:02.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"List the files"}]}}
+{"timestamp":"2025-06-01T10:00:02.000Z","type":"event_msg","payload":{"type":"user_message","message":"List the files"}}
+{"timestamp":"2025-06-01T10:00:03.000Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"Running ls"}],"encrypted_content":"xyz"}}
+{"timestamp":"2025-06-01T10:00:04.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"ls\",\"-a\"]}","call_id":"call_1"}}
+{"timestamp":"2025-06-01T10:00:05.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"go.mod\\n\",\"metadata\":{\"exit_code\":0}}"}}
+{"timestamp":"2025-06-01T10:00:06.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"There is go.mod."}]}}
+{"timestamp":"2025-06-01T10:00:07.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_te`
+
+func TestReadCodexRollout(t *testing.T) {
+	rec, err := ReadCodexRollout(strings.NewReader(codexRollout))
+	if err != nil {
+		t.Fatalf("read rollout: %v", err)
+	}
+	if rec.ID != "0197-codex" || rec.CWD != "/work" || rec.AgentName != CodexAgentName || rec.Title != "List the files" {
+		t.Fatalf("session = %+v", rec)
+	}
+
+	var got []string
+	for _, m := range rec.Messages {
+		got = append(got, m.Role+"/"+m.Kind+":"+m.Content)
+	}
+	want := []string{"user/text:List the files", "agent/thought:Running ls", "agent/text:There is go.mod."}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("messages = %q, want %q", got, want)
+	}
+	if ts := rec.Messages[0].Timestamp; !ts.Equal(time.Date(2025, 6, 1, 10, 0, 2, 0, time.UTC)) {
+		t.Fatalf("message timestamp = %v", ts)
+	}
+
+	if len(rec.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", rec.ToolCalls)
+	}
+	tc := rec.ToolCalls[0]
+	if tc.ID != "call_1" || tc.Title != "ls -a" || tc.Kind != "execute" || tc.Status != "completed" {
+		t.Fatalf("tool call = %+v", tc)
+	}
+	if len(tc.Parts) != 1 || tc.Parts[0].Type != "terminal" || tc.Parts[0].Text != "go.mod\n" {
+		t.Fatalf("tool call parts = %+v", tc.Parts)
+	}
+}
+
+func TestReadCodexRolloutLegacyFormat(t *testing.T) {
+	legacy := `{"id":"legacy-1","timestamp":"2025-04-20T08:00:00Z","instructions":null}
+{"record_type":"state"}
+{"type":"message","id":"msg_1","role":"user","content":[{"type":"input_text","text":"hello"}]}
+{"type":"local_shell_call","call_id":"call_9","status":"completed","action":{"type":"exec","command":["false"]}}
+{"type":"function_call_output","call_id":"call_9","output":"{\"output\":\"\",\"metadata\":{\"exit_code\":1}}"}`
+
+	rec, err := ReadCodexRollout(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("read rollout: %v", err)
+	}
+	if len(rec.Messages) != 1 || rec.Messages[0].ID != "codex:legacy-1:msg_1" || rec.Messages[0].Timestamp.IsZero() {
+		t.Fatalf("messages = %+v", rec.Messages)
+	}
+	if len(rec.ToolCalls) != 1 || rec.ToolCalls[0].Status != "failed" || rec.ToolCalls[0].Title != "false" {
+		t.Fatalf("tool calls = %+v", rec.ToolCalls)
+	}
+}
+
+func TestImportCodexIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	day := filepath.Join(dir, "2025", "06", "01")
+	if err := os.MkdirAll(day, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(day, "rollout-2025-06-01T10-00-00-0197-codex.jsonl"), []byte(codexRollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(day, "broken.jsonl"), []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := session.NewMemoryStore()
+	result, err := ImportCodex(store, dir)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Created != 1 || result.Messages != 3 || result.ToolCalls != 1 || len(result.Skipped) != 1 {
+		t.Fatalf("first import = %+v", result)
+	}
+
+	result, err = ImportCodex(store, dir)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if result.Created != 0 || result.Messages != 0 || result.ToolCalls != 0 {
+		t.Fatalf("second import = %+v", result)
+	}
+	if rec := store.Get("0197-codex"); rec == nil || !rec.CreatedAt.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("stored session = %+v", rec)
+	}
+}
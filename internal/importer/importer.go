@@ -0,0 +1,42 @@
+// Package importer brings session histories recorded by agents outside
+// ByteSmith into the session store.
+//
+// Converted entries keep their original IDs and timestamps and are stored
+// with Store.Merge, so importing the same history twice adds nothing.
+package importer
+
+import (
+	"fmt"
+
+	"bytesmith/internal/session"
+)
+
+// Result summarizes an import run.
+type Result struct {
+	Sessions  int // sessions read
+	Created   int // sessions that were not stored before
+	Messages  int
+	ToolCalls int
+	// Skipped names the sources that could not be read, with the reason.
+	Skipped []string
+}
+
+// Add merges rec into store and counts what was added.
+func (r *Result) Add(store session.Store, rec *session.SessionRecord) error {
+	merged, err := store.Merge(rec)
+	if err != nil {
+		return fmt.Errorf("importer: session %s: %w", rec.ID, err)
+	}
+	r.Sessions++
+	if merged.Created {
+		r.Created++
+	}
+	r.Messages += merged.Messages
+	r.ToolCalls += merged.ToolCalls
+	return nil
+}
+
+// Skip records a source that could not be imported.
+func (r *Result) Skip(source string, err error) {
+	r.Skipped = append(r.Skipped, fmt.Sprintf("%s: %v", source, err))
+}
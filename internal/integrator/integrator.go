@@ -1,5 +1,11 @@
 package integrator
 
+import (
+	"strings"
+
+	"bytesmith/internal/acp"
+)
+
 // Capabilities describes what an integrator supports.
 type Capabilities struct {
 	ListSessions    bool
@@ -8,6 +14,41 @@ type Capabilities struct {
 	SetMode         bool
 	SetModel        bool
 	SetConfigOption bool
+
+	// Prompt content the agent accepts besides plain text.
+	ImageInput      bool
+	AudioInput      bool
+	EmbeddedContext bool
+
+	// MCP transports the agent can connect to besides stdio.
+	MCPHTTP bool
+	MCPSSE  bool
+}
+
+// Negotiate merges what the agent reported during the ACP handshake into a
+// base capability set. Handshake flags only ever add features: the static
+// tables for OpenCode and Codex already reflect what ByteSmith drives through
+// their dedicated dialects.
+func Negotiate(base Capabilities, init *acp.InitializeResult) Capabilities {
+	if init == nil {
+		return base
+	}
+
+	caps := init.AgentCapabilities
+	base.LoadSession = base.LoadSession || caps.LoadSession
+	if sc := caps.SessionCapabilities; sc != nil {
+		base.ListSessions = base.ListSessions || sc.List != nil
+		base.ResumeSession = base.ResumeSession || sc.Resume != nil
+	}
+	if pc := caps.PromptCapabilities; pc != nil {
+		base.ImageInput = base.ImageInput || pc.Image
+		base.AudioInput = base.AudioInput || pc.Audio
+		base.EmbeddedContext = base.EmbeddedContext || pc.EmbeddedContext
+	}
+	mcp := caps.MCPSupport()
+	base.MCPHTTP = base.MCPHTTP || mcp.HTTP
+	base.MCPSSE = base.MCPSSE || mcp.SSE
+	return base
 }
 
 // AgentServer is a lightweight adapter descriptor for a supported integrator.
@@ -46,6 +87,8 @@ var (
 			SetMode:         true,
 			SetModel:        true,
 			SetConfigOption: true,
+			MCPHTTP:         true,
+			MCPSSE:          true,
 		},
 	}
 	codex = adapter{
@@ -58,30 +101,59 @@ var (
 			SetMode:         false,
 			SetModel:        true,
 			SetConfigOption: false,
-		},
-	}
-	unknown = adapter{
-		id:          "unknown",
-		displayName: "Unknown",
-		capabilities: Capabilities{
-			ListSessions:    false,
-			LoadSession:     false,
-			ResumeSession:   false,
-			SetMode:         false,
-			SetModel:        false,
-			SetConfigOption: false,
+			MCPHTTP:         true,
 		},
 	}
 )
 
+// GenericID identifies agents that are driven purely through standard ACP.
+const GenericID = "acp"
+
 // ForAgent resolves the integrator descriptor for a configured agent name.
-func ForAgent(agentName string) AgentServer {
+// OpenCode and Codex keep their dedicated adapters; any other agent is a
+// generic ACP agent whose capabilities come from the initialize handshake
+// (init may be nil if the handshake has not happened yet).
+func ForAgent(agentName string, init *acp.InitializeResult) AgentServer {
 	switch agentName {
 	case "opencode":
 		return openCode
 	case "codex-app-server":
 		return codex
 	default:
-		return unknown
+		return Generic(init)
+	}
+}
+
+// Generic returns the descriptor for a standard ACP agent. Optional session
+// methods are enabled only when advertised in the agent's capabilities;
+// session/set_mode and model selection are offered because the agent only
+// exposes them through the modes/models it returns for each session.
+func Generic(init *acp.InitializeResult) AgentServer {
+	a := adapter{
+		id:          GenericID,
+		displayName: "ACP Agent",
+		capabilities: Capabilities{
+			SetMode:         true,
+			SetModel:        true,
+			SetConfigOption: true,
+		},
+	}
+	if init == nil {
+		return a
+	}
+
+	if name := firstNonEmpty(init.AgentInfo.Title, init.AgentInfo.Name); name != "" {
+		a.displayName = name
+	}
+	a.capabilities = Negotiate(a.capabilities, init)
+	return a
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if trimmed := strings.TrimSpace(v); trimmed != "" {
+			return trimmed
+		}
 	}
+	return ""
 }
@@ -0,0 +1,84 @@
+package mcpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Environment variables read by the stdio bridge.
+const (
+	EnvURL   = "BYTESMITH_MCP_URL"
+	EnvToken = "BYTESMITH_MCP_TOKEN"
+)
+
+// BridgeCommand is the subcommand of the ByteSmith binary that runs Bridge.
+const BridgeCommand = "mcp-bridge"
+
+// Bridge relays newline-delimited JSON-RPC messages between a stdio MCP
+// client and the HTTP server at url. It returns when in is exhausted or ctx
+// ends.
+func Bridge(ctx context.Context, url, token string, in io.Reader, out io.Writer) error {
+	if strings.TrimSpace(url) == "" || strings.TrimSpace(token) == "" {
+		return fmt.Errorf("mcpserver: %s and %s must be set", EnvURL, EnvToken)
+	}
+
+	client := &http.Client{}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxRequestBytes)
+	writer := bufio.NewWriter(out)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		resp, err := post(ctx, client, url, token, line)
+		if err != nil {
+			return err
+		}
+		if len(resp) == 0 {
+			continue
+		}
+		if _, err := writer.Write(append(bytes.TrimSpace(resp), '\n')); err != nil {
+			return err
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func post(ctx context.Context, client *http.Client, url, token string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mcpserver: bridge request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("mcpserver: bridge response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("mcpserver: bridge: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,309 @@
+// Package mcpserver implements ByteSmith's built-in MCP server. It is served
+// over streamable HTTP on a loopback port and offers tools that only the
+// desktop client can provide: opening files in the UI, asking the user,
+// notifications, the embedded terminal buffer and the session's file
+// changes. Agents that only speak stdio reach it through Bridge.
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Name is the server name used in mcpServers entries.
+const Name = "bytesmith"
+
+// ProtocolVersion is the MCP revision the server implements.
+const ProtocolVersion = "2025-03-26"
+
+// maxRequestBytes bounds the size of a JSON-RPC request body.
+const maxRequestBytes = 4 << 20
+
+// Server is the built-in MCP server. Each session gets a token; requests
+// carry it as a bearer token and tools act on the session it is bound to.
+type Server struct {
+	host    Host
+	version string
+
+	mu        sync.RWMutex
+	tokens    map[string]string        // token -> session ID ("" until bound)
+	resolvers map[string]func() string // token -> session resolver, for shared tokens
+	listener  net.Listener
+	http      *http.Server
+}
+
+// New creates a server backed by host. version is reported to clients.
+func New(host Host, version string) *Server {
+	return &Server{
+		host:      host,
+		version:   version,
+		tokens:    make(map[string]string),
+		resolvers: make(map[string]func() string),
+	}
+}
+
+// Start listens on a random loopback port and serves in the background.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("mcpserver: listen: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.mu.Lock()
+	s.listener = ln
+	s.http = srv
+	s.mu.Unlock()
+
+	go func() { _ = srv.Serve(ln) }()
+	return nil
+}
+
+// URL returns the endpoint of the running server, or "" before Start.
+func (s *Server) URL() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.listener == nil {
+		return ""
+	}
+	return "http://" + s.listener.Addr().String() + "/mcp"
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	srv := s.http
+	s.http, s.listener = nil, nil
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Close()
+}
+
+// NewToken issues a token that is not bound to a session yet. Agents receive
+// their MCP servers before they report the new session's ID, so the token is
+// bound with Bind once it is known.
+func (s *Server) NewToken() string {
+	token := uuid.NewString()
+	s.mu.Lock()
+	s.tokens[token] = ""
+	s.mu.Unlock()
+	return token
+}
+
+// Bind attaches a token to a session.
+func (s *Server) Bind(token, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[token]; ok {
+		s.tokens[token] = sessionID
+	}
+}
+
+// BindResolver attaches a token shared by several sessions: each request
+// acts on the session resolve returns when it arrives, or on none if it
+// returns "". It serves agents that register MCP servers once for many
+// sessions, so a request does not say which session made it.
+func (s *Server) BindResolver(token string, resolve func() string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[token]; ok {
+		s.resolvers[token] = resolve
+	}
+}
+
+// Revoke invalidates a token.
+func (s *Server) Revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+	delete(s.resolvers, token)
+}
+
+// RevokeSession invalidates every token bound to sessionID.
+func (s *Server) RevokeSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, id := range s.tokens {
+		if id == sessionID {
+			delete(s.tokens, token)
+		}
+	}
+}
+
+func (s *Server) session(token string) (string, bool) {
+	s.mu.RLock()
+	id, ok := s.tokens[token]
+	resolve := s.resolvers[token]
+	s.mu.RUnlock()
+	if resolve != nil {
+		id = resolve()
+	}
+	return id, ok
+}
+
+// ServeHTTP implements the streamable HTTP transport. Every POST carries one
+// JSON-RPC message; responses are returned as plain JSON.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/mcp" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	sessionID, ok := s.session(strings.TrimSpace(token))
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	resp := s.Handle(r.Context(), sessionID, body)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+// Handle processes one JSON-RPC message for sessionID and returns the
+// encoded response, or nil for notifications.
+func (s *Server) Handle(ctx context.Context, sessionID string, body []byte) []byte {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return encodeResponse(response{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &rpcError{Code: codeParseError, Message: "parse error"},
+		})
+	}
+	if req.ID == nil {
+		// Notifications (notifications/initialized, cancellations) need no
+		// answer.
+		return nil
+	}
+
+	resp := response{JSONRPC: "2.0", ID: *req.ID}
+	result, err := s.dispatch(ctx, sessionID, req)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return encodeResponse(resp)
+}
+
+func (s *Server) dispatch(ctx context.Context, sessionID string, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if params.ProtocolVersion != "" && params.ProtocolVersion < ProtocolVersion {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools": map[string]any{},
+			},
+			"serverInfo": map[string]any{
+				"name":    Name,
+				"version": s.version,
+			},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": toolDefinitions()}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params"}
+		}
+		return s.callTool(ctx, sessionID, params.Name, params.Arguments)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// JSON-RPC
+// ---------------------------------------------------------------------------
+
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+func encodeResponse(resp response) []byte {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{
+			JSONRPC: "2.0",
+			ID:      resp.ID,
+			Error:   &rpcError{Code: codeInternalError, Message: err.Error()},
+		})
+	}
+	return data
+}
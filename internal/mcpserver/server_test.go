@@ -0,0 +1,183 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeHost struct {
+	opened   []string
+	notified []Notification
+}
+
+func (h *fakeHost) OpenFile(sessionID, path string, line int) error {
+	h.opened = append(h.opened, sessionID+":"+path)
+	return nil
+}
+
+func (h *fakeHost) AskUser(ctx context.Context, sessionID string, q Question) ([]string, bool, error) {
+	return []string{q.Options[0]}, true, nil
+}
+
+func (h *fakeHost) Notify(sessionID string, n Notification) error {
+	h.notified = append(h.notified, n)
+	return nil
+}
+
+func (h *fakeHost) ReadTerminal(sessionID, terminalID string, maxBytes int) (TerminalOutput, error) {
+	return TerminalOutput{TerminalID: "t1", CWD: "/repo", Output: "\x1b[32mok\x1b[0m\r\n"}, nil
+}
+
+func (h *fakeHost) ListFileChanges(sessionID string) ([]FileChange, error) {
+	return []FileChange{{ID: "c1", Path: "/repo/main.go", Status: "applied"}}, nil
+}
+
+func rpc(t *testing.T, srv http.Handler, token, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var out map[string]any
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, out
+}
+
+func toolText(t *testing.T, resp map[string]any) (string, bool) {
+	t.Helper()
+	result, ok := resp["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("no result in %v", resp)
+	}
+	content := result["content"].([]any)
+	isError, _ := result["isError"].(bool)
+	return content[0].(map[string]any)["text"].(string), isError
+}
+
+func TestServerRequiresBoundToken(t *testing.T) {
+	host := &fakeHost{}
+	srv := New(host, "test")
+
+	if code, _ := rpc(t, srv, "bogus", `{"jsonrpc":"2.0","id":1,"method":"ping"}`); code != http.StatusUnauthorized {
+		t.Fatalf("unknown token: status = %d, want 401", code)
+	}
+
+	token := srv.NewToken()
+	_, resp := rpc(t, srv, token, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"notify","arguments":{"message":"hi"}}}`)
+	if _, isError := toolText(t, resp); !isError || len(host.notified) != 0 {
+		t.Fatalf("unbound token reached the host: %v", resp)
+	}
+
+	srv.Bind(token, "s1")
+	_, resp = rpc(t, srv, token, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"open_file","arguments":{"path":"main.go"}}}`)
+	if _, isError := toolText(t, resp); isError || len(host.opened) != 1 || host.opened[0] != "s1:main.go" {
+		t.Fatalf("open_file: resp = %v, opened = %v", resp, host.opened)
+	}
+
+	srv.RevokeSession("s1")
+	if code, _ := rpc(t, srv, token, `{"jsonrpc":"2.0","id":3,"method":"ping"}`); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status = %d, want 401", code)
+	}
+}
+
+func TestServerResolvesSharedToken(t *testing.T) {
+	host := &fakeHost{}
+	srv := New(host, "test")
+	token := srv.NewToken()
+	current := "s1"
+	srv.BindResolver(token, func() string { return current })
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"open_file","arguments":{"path":"main.go"}}}`
+	rpc(t, srv, token, call)
+	current = "s2"
+	rpc(t, srv, token, call)
+	current = ""
+	_, resp := rpc(t, srv, token, call)
+	if _, isError := toolText(t, resp); !isError {
+		t.Fatalf("call with no session resolved reached the host: %v", resp)
+	}
+	if strings.Join(host.opened, ",") != "s1:main.go,s2:main.go" {
+		t.Fatalf("opened = %v, want one file per resolved session", host.opened)
+	}
+
+	srv.Revoke(token)
+	if code, _ := rpc(t, srv, token, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status = %d, want 401", code)
+	}
+}
+
+func TestServerProtocol(t *testing.T) {
+	srv := New(&fakeHost{}, "test")
+	token := srv.NewToken()
+	srv.Bind(token, "s1")
+
+	_, resp := rpc(t, srv, token, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+	result := resp["result"].(map[string]any)
+	if result["protocolVersion"] != "2024-11-05" {
+		t.Fatalf("protocolVersion = %v, want the client's older revision", result["protocolVersion"])
+	}
+
+	if code, resp := rpc(t, srv, token, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); code != http.StatusAccepted || resp != nil {
+		t.Fatalf("notification: status = %d, body = %v", code, resp)
+	}
+
+	_, resp = rpc(t, srv, token, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tools := resp["result"].(map[string]any)["tools"].([]any)
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	if strings.Join(names, ",") != "open_file,ask_user,notify,read_terminal,list_file_changes" {
+		t.Fatalf("tools = %v", names)
+	}
+
+	_, resp = rpc(t, srv, token, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"read_terminal","arguments":{}}}`)
+	if text, _ := toolText(t, resp); text != "Terminal t1 (/repo):\nok\n" {
+		t.Fatalf("read_terminal = %q", text)
+	}
+
+	_, resp = rpc(t, srv, token, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"ask_user","arguments":{"question":"Deploy?","options":["yes","no"]}}}`)
+	if text, _ := toolText(t, resp); text != `{"answers":["yes"]}` {
+		t.Fatalf("ask_user = %q", text)
+	}
+
+	_, resp = rpc(t, srv, token, `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nope"}}`)
+	if resp["error"] == nil {
+		t.Fatalf("unknown tool should be a JSON-RPC error: %v", resp)
+	}
+}
+
+func TestBridgeRelaysMessages(t *testing.T) {
+	srv := New(&fakeHost{}, "test")
+	token := srv.NewToken()
+	srv.Bind(token, "s1")
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}
+{"jsonrpc":"2.0","method":"notifications/initialized"}
+{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_file_changes"}}
+`)
+	var out bytes.Buffer
+	if err := Bridge(context.Background(), ts.URL+"/mcp", token, in, &out); err != nil {
+		t.Fatalf("bridge: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bridge wrote %d responses, want 2: %q", len(lines), out.String())
+	}
+	if !strings.Contains(lines[1], `/repo/main.go`) {
+		t.Fatalf("list_file_changes response = %s", lines[1])
+	}
+}
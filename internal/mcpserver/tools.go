@@ -0,0 +1,261 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNoSession is returned by tools called with a token that is not bound to
+// a session yet.
+var ErrNoSession = errors.New("no session bound to this MCP connection")
+
+// defaultTerminalBytes is how much terminal output read_terminal returns
+// when the agent does not ask for a size.
+const defaultTerminalBytes = 16 * 1024
+
+// Host provides the desktop-side features behind the tools.
+type Host interface {
+	// OpenFile shows path in the UI, at line when it is positive.
+	OpenFile(sessionID, path string, line int) error
+	// AskUser asks the user a question and blocks until it is answered.
+	// answered is false when the user dismissed it.
+	AskUser(ctx context.Context, sessionID string, q Question) (answers []string, answered bool, err error)
+	// Notify shows a notification.
+	Notify(sessionID string, n Notification) error
+	// ReadTerminal returns the recent output of an embedded terminal; an
+	// empty terminalID picks the terminal that best matches the session.
+	ReadTerminal(sessionID, terminalID string, maxBytes int) (TerminalOutput, error)
+	// ListFileChanges returns the file changes recorded for the session.
+	ListFileChanges(sessionID string) ([]FileChange, error)
+}
+
+// Question is a structured question for the user.
+type Question struct {
+	Header     string   `json:"header,omitempty"`
+	Question   string   `json:"question"`
+	Options    []string `json:"options,omitempty"`
+	Multiple   bool     `json:"multiple,omitempty"`
+	AllowOther bool     `json:"allowOther,omitempty"`
+}
+
+// Notification is a message shown to the user.
+type Notification struct {
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+	Level   string `json:"level,omitempty"` // "info" (default), "success", "warning" or "error"
+}
+
+// TerminalOutput is the scrollback of an embedded terminal.
+type TerminalOutput struct {
+	TerminalID string `json:"terminalId"`
+	CWD        string `json:"cwd"`
+	Output     string `json:"output"`
+}
+
+// FileChange is a file write recorded for a session.
+type FileChange struct {
+	ID         string    `json:"id"`
+	Path       string    `json:"path"`
+	ToolCallID string    `json:"toolCallId,omitempty"`
+	Created    bool      `json:"created"`
+	Status     string    `json:"status"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+type toolDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+func toolDefinitions() []toolDefinition {
+	return []toolDefinition{
+		{
+			Name:        "open_file",
+			Description: "Open a file in the ByteSmith editor so the user can see it, optionally at a line.",
+			InputSchema: objectSchema(map[string]any{
+				"path": stringProp("Absolute path, or a path relative to the session's working directory."),
+				"line": map[string]any{"type": "integer", "description": "1-based line to reveal.", "minimum": 1},
+			}, "path"),
+		},
+		{
+			Name:        "ask_user",
+			Description: "Ask the user a question in the ByteSmith UI and wait for the answer. Prefer options when the answer is a choice.",
+			InputSchema: objectSchema(map[string]any{
+				"question":   stringProp("The question to ask."),
+				"header":     stringProp("Short title shown above the question."),
+				"options":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Answers the user can pick from."},
+				"multiple":   map[string]any{"type": "boolean", "description": "Allow picking several options."},
+				"allowOther": map[string]any{"type": "boolean", "description": "Allow a free-form answer besides the options."},
+			}, "question"),
+		},
+		{
+			Name:        "notify",
+			Description: "Show a notification to the user in the ByteSmith UI.",
+			InputSchema: objectSchema(map[string]any{
+				"message": stringProp("Notification text."),
+				"title":   stringProp("Optional title."),
+				"level":   map[string]any{"type": "string", "enum": []string{"info", "success", "warning", "error"}},
+			}, "message"),
+		},
+		{
+			Name:        "read_terminal",
+			Description: "Read the recent output of one of the user's embedded terminals in ByteSmith. Only terminals opened in the session's workspace can be read.",
+			InputSchema: objectSchema(map[string]any{
+				"terminalId": stringProp("Terminal to read; defaults to the most recent terminal in the session's workspace."),
+				"maxBytes":   map[string]any{"type": "integer", "description": "Maximum bytes of output to return.", "minimum": 1},
+			}),
+		},
+		{
+			Name:        "list_file_changes",
+			Description: "List the files changed in this session, with their status (applied or reverted).",
+			InputSchema: objectSchema(map[string]any{}),
+		},
+	}
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+// toolResult is the result of tools/call. Tool failures are reported in the
+// result, not as JSON-RPC errors, so the model can see them.
+type toolResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func textResult(text string) toolResult {
+	return toolResult{Content: []textContent{{Type: "text", Text: text}}}
+}
+
+func errorResult(err error) toolResult {
+	r := textResult(err.Error())
+	r.IsError = true
+	return r
+}
+
+func (s *Server) callTool(ctx context.Context, sessionID, name string, args json.RawMessage) (any, error) {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	var run func() (string, error)
+	switch name {
+	case "open_file":
+		var in struct {
+			Path string `json:"path"`
+			Line int    `json:"line"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil || strings.TrimSpace(in.Path) == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "open_file requires a path"}
+		}
+		run = func() (string, error) {
+			if err := s.host.OpenFile(sessionID, in.Path, in.Line); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Opened %s.", in.Path), nil
+		}
+	case "ask_user":
+		var q Question
+		if err := json.Unmarshal(args, &q); err != nil || strings.TrimSpace(q.Question) == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "ask_user requires a question"}
+		}
+		run = func() (string, error) {
+			answers, answered, err := s.host.AskUser(ctx, sessionID, q)
+			if err != nil {
+				return "", err
+			}
+			if !answered {
+				return "The user dismissed the question without answering.", nil
+			}
+			data, _ := json.Marshal(map[string]any{"answers": answers})
+			return string(data), nil
+		}
+	case "notify":
+		var n Notification
+		if err := json.Unmarshal(args, &n); err != nil || strings.TrimSpace(n.Message) == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "notify requires a message"}
+		}
+		run = func() (string, error) {
+			if err := s.host.Notify(sessionID, n); err != nil {
+				return "", err
+			}
+			return "Notification shown.", nil
+		}
+	case "read_terminal":
+		var in struct {
+			TerminalID string `json:"terminalId"`
+			MaxBytes   int    `json:"maxBytes"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid read_terminal arguments"}
+		}
+		if in.MaxBytes <= 0 {
+			in.MaxBytes = defaultTerminalBytes
+		}
+		run = func() (string, error) {
+			out, err := s.host.ReadTerminal(sessionID, in.TerminalID, in.MaxBytes)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Terminal %s (%s):\n%s", out.TerminalID, out.CWD, StripANSI(out.Output)), nil
+		}
+	case "list_file_changes":
+		run = func() (string, error) {
+			changes, err := s.host.ListFileChanges(sessionID)
+			if err != nil {
+				return "", err
+			}
+			if changes == nil {
+				changes = []FileChange{}
+			}
+			data, err := json.MarshalIndent(changes, "", "  ")
+			if err != nil {
+				return "", err
+			}
+			return string(data), nil
+		}
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", name)}
+	}
+
+	if sessionID == "" {
+		return errorResult(ErrNoSession), nil
+	}
+	text, err := run()
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(text), nil
+}
+
+// ansiEscape matches CSI and OSC escape sequences and other two-byte escapes.
+var ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// StripANSI removes terminal escape sequences and carriage returns from s.
+func StripANSI(s string) string {
+	s = ansiEscape.ReplaceAllString(s, "")
+	return strings.ReplaceAll(s, "\r", "")
+}
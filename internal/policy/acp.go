@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"bytesmith/internal/acp"
+)
+
+// RequestFor describes an ACP permission request to the engine. The
+// command and paths are read from the tool call's locations, diff content
+// and raw input.
+func RequestFor(agentName, cwd string, tc acp.ToolCallUpdate) Request {
+	return Request{
+		AgentName: agentName,
+		CWD:       cwd,
+		ToolKind:  tc.Kind,
+		Title:     tc.Title,
+		Command:   permissionCommand(tc),
+		Paths:     permissionPaths(tc),
+	}
+}
+
+// OptionFor returns the permission option that carries out decision: an
+// allow option for Allow and a reject option for Deny. It returns "" for
+// Ask, or when the agent offered no suitable option.
+func OptionFor(decision Decision, options []acp.PermissionOption) string {
+	switch decision {
+	case Allow:
+		return PickOption(options, "allow_once", "allow_always")
+	case Deny:
+		return PickOption(options, "reject_once", "reject_always")
+	default:
+		return ""
+	}
+}
+
+// PickOption returns the first option whose kind matches, in the
+// order of preference given.
+func PickOption(options []acp.PermissionOption, kinds ...string) string {
+	for _, kind := range kinds {
+		for _, opt := range options {
+			if strings.EqualFold(opt.Kind, kind) {
+				return opt.OptionID
+			}
+		}
+	}
+	return ""
+}
+
+// permissionCommand extracts the shell command from a tool call's raw input.
+func permissionCommand(tc acp.ToolCallUpdate) string {
+	input := rawInputObject(tc.RawInput)
+	for _, key := range []string{"command", "cmd"} {
+		switch v := input[key].(type) {
+		case string:
+			if strings.TrimSpace(v) != "" {
+				return strings.TrimSpace(v)
+			}
+		case []any:
+			parts := make([]string, 0, len(v))
+			for _, p := range v {
+				if s, ok := p.(string); ok {
+					parts = append(parts, s)
+				}
+			}
+			if len(parts) > 0 {
+				return strings.Join(parts, " ")
+			}
+		}
+	}
+	return ""
+}
+
+// permissionPaths collects the files a tool call touches from its locations,
+// diff content and raw input.
+func permissionPaths(tc acp.ToolCallUpdate) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(p string) {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			return
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+
+	for _, loc := range tc.Locations {
+		add(loc.Path)
+	}
+	for _, c := range tc.Content {
+		if c.Type == "diff" {
+			add(c.Path)
+		}
+	}
+	input := rawInputObject(tc.RawInput)
+	for _, key := range []string{"path", "filePath", "file_path", "filepath"} {
+		if s, ok := input[key].(string); ok {
+			add(s)
+		}
+	}
+	return paths
+}
+
+func rawInputObject(raw json.RawMessage) map[string]any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,318 @@
+// Package policy decides agent permission requests without asking the user
+// when a configured rule covers them.
+//
+// Rules are evaluated in order and the first matching rule wins. A rule
+// matches when every condition it sets matches; conditions left empty match
+// anything. When no rule matches, the request is allowed if auto-approve is
+// enabled and otherwise left for the user to decide.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Decision is the outcome of evaluating a permission request.
+type Decision string
+
+const (
+	// Allow approves the request without prompting.
+	Allow Decision = "allow"
+	// Deny rejects the request without prompting.
+	Deny Decision = "deny"
+	// Ask shows the request to the user.
+	Ask Decision = "ask"
+)
+
+// AutoApproveRuleID is reported when no rule matched and the request was
+// allowed because of the auto-approve setting.
+const AutoApproveRuleID = "settings.autoApprove"
+
+// WithoutAutoApprove leaves a request that was only allowed by the
+// auto-approve setting undecided.
+func WithoutAutoApprove(r Result) Result {
+	if r.RuleID == AutoApproveRuleID {
+		return Result{Decision: Ask}
+	}
+	return r
+}
+
+// Rule is one entry of the "permissionRules" list in config.json.
+type Rule struct {
+	ID          string   `json:"id,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Decision    Decision `json:"decision"`
+
+	// ToolKinds matches the ACP tool kind (read, edit, delete, move, search,
+	// execute, fetch, think, other). Codex kinds are mapped onto these.
+	ToolKinds []string `json:"toolKinds,omitempty"`
+	// Paths are globs matched against the files the request touches, both as
+	// absolute paths and relative to the session cwd. "**" crosses
+	// directories and a leading "~/" expands to the home directory. Allow
+	// rules require every path to match; deny and ask rules need just one.
+	// A deny rule cannot tell whether a request that names no files (such
+	// as most shell commands) touches its paths, so it makes it ask.
+	Paths []string `json:"paths,omitempty"`
+	// Command is a regular expression matched against the shell command.
+	Command string `json:"command,omitempty"`
+	// Agents lists agent names from config.json.
+	Agents []string `json:"agents,omitempty"`
+	// CWDs are globs matched against the session working directory.
+	CWDs []string `json:"cwds,omitempty"`
+}
+
+// Request is what the engine knows about a permission request.
+type Request struct {
+	AgentName string
+	CWD       string
+	ToolKind  string
+	Title     string
+	Command   string
+	Paths     []string
+}
+
+// Result is the engine's decision and the rule that made it. RuleID is
+// empty when the decision is the default Ask.
+type Result struct {
+	Decision Decision
+	RuleID   string
+	Reason   string
+}
+
+// Engine evaluates requests against a fixed rule set.
+type Engine struct {
+	rules       []compiledRule
+	autoApprove bool
+}
+
+type compiledRule struct {
+	Rule
+	id        string
+	toolKinds map[string]bool
+	paths     []*regexp.Regexp
+	command   *regexp.Regexp
+	agents    map[string]bool
+	cwds      []*regexp.Regexp
+}
+
+// New compiles rules. autoApprove is the AppSettings.AutoApprove flag and
+// only applies when no rule matches.
+func New(rules []Rule, autoApprove bool) (*Engine, error) {
+	e := &Engine{autoApprove: autoApprove}
+	for i, r := range rules {
+		cr, err := compileRule(i, r)
+		if err != nil {
+			return nil, err
+		}
+		e.rules = append(e.rules, cr)
+	}
+	return e, nil
+}
+
+func compileRule(index int, r Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: r, id: strings.TrimSpace(r.ID)}
+	if cr.id == "" {
+		cr.id = fmt.Sprintf("rule[%d]", index)
+	}
+
+	switch Decision(strings.ToLower(string(r.Decision))) {
+	case Allow, Deny, Ask:
+		cr.Decision = Decision(strings.ToLower(string(r.Decision)))
+	default:
+		return cr, fmt.Errorf("policy: %s: invalid decision %q", cr.id, r.Decision)
+	}
+
+	if len(r.ToolKinds) > 0 {
+		cr.toolKinds = make(map[string]bool, len(r.ToolKinds))
+		for _, k := range r.ToolKinds {
+			cr.toolKinds[NormalizeKind(k)] = true
+		}
+	}
+	if len(r.Agents) > 0 {
+		cr.agents = make(map[string]bool, len(r.Agents))
+		for _, name := range r.Agents {
+			cr.agents[strings.TrimSpace(name)] = true
+		}
+	}
+
+	for _, g := range r.Paths {
+		re, err := globToRegexp(g)
+		if err != nil {
+			return cr, fmt.Errorf("policy: %s: path %q: %w", cr.id, g, err)
+		}
+		cr.paths = append(cr.paths, re)
+	}
+	for _, g := range r.CWDs {
+		re, err := globToRegexp(g)
+		if err != nil {
+			return cr, fmt.Errorf("policy: %s: cwd %q: %w", cr.id, g, err)
+		}
+		cr.cwds = append(cr.cwds, re)
+	}
+	if strings.TrimSpace(r.Command) != "" {
+		re, err := regexp.Compile(r.Command)
+		if err != nil {
+			return cr, fmt.Errorf("policy: %s: command: %w", cr.id, err)
+		}
+		cr.command = re
+	}
+
+	return cr, nil
+}
+
+// Evaluate returns the decision for req.
+func (e *Engine) Evaluate(req Request) Result {
+	if e == nil {
+		return Result{Decision: Ask}
+	}
+
+	for _, r := range e.rules {
+		if r.Decision == Deny && len(r.paths) > 0 && len(req.Paths) == 0 && r.matchesScope(req) {
+			return Result{Decision: Ask, RuleID: r.id, Reason: fmt.Sprintf("%s guards files the request does not name", r.id)}
+		}
+		if r.matches(req) {
+			reason := r.Description
+			if reason == "" {
+				reason = fmt.Sprintf("matched %s", r.id)
+			}
+			return Result{Decision: r.Decision, RuleID: r.id, Reason: reason}
+		}
+	}
+
+	if e.autoApprove {
+		return Result{Decision: Allow, RuleID: AutoApproveRuleID, Reason: "auto-approve is enabled"}
+	}
+	return Result{Decision: Ask}
+}
+
+func (r compiledRule) matches(req Request) bool {
+	if !r.matchesScope(req) {
+		return false
+	}
+	if len(r.paths) > 0 {
+		if len(req.Paths) == 0 {
+			return false
+		}
+		matched := 0
+		for _, p := range req.Paths {
+			if r.pathMatches(p, req.CWD) {
+				matched++
+			}
+		}
+		if r.Decision == Allow {
+			return matched == len(req.Paths)
+		}
+		return matched > 0
+	}
+	return true
+}
+
+// matchesScope checks every condition of the rule except its paths.
+func (r compiledRule) matchesScope(req Request) bool {
+	if r.agents != nil && !r.agents[req.AgentName] {
+		return false
+	}
+	if r.toolKinds != nil && !r.toolKinds[NormalizeKind(req.ToolKind)] {
+		return false
+	}
+	if len(r.cwds) > 0 && !anyMatch(r.cwds, cleanPath(req.CWD)) {
+		return false
+	}
+	if r.command != nil && (req.Command == "" || !r.command.MatchString(req.Command)) {
+		return false
+	}
+	return true
+}
+
+func (r compiledRule) pathMatches(path, cwd string) bool {
+	abs := path
+	if !filepath.IsAbs(abs) && cwd != "" {
+		abs = filepath.Join(cwd, abs)
+	}
+	abs = cleanPath(abs)
+	if anyMatch(r.paths, abs) {
+		return true
+	}
+	if cwd != "" {
+		if rel, err := filepath.Rel(cleanPath(cwd), abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return anyMatch(r.paths, filepath.ToSlash(rel))
+		}
+	}
+	return false
+}
+
+// NormalizeKind maps the tool kinds used by different runtimes onto the ACP
+// kind vocabulary.
+func NormalizeKind(kind string) string {
+	k := strings.ToLower(strings.TrimSpace(kind))
+	switch k {
+	case "command_execution", "commandexecution", "exec", "bash", "shell":
+		return "execute"
+	case "file_change", "filechange", "write", "patch", "apply_patch":
+		return "edit"
+	case "webfetch", "web_fetch":
+		return "fetch"
+	case "":
+		return "other"
+	default:
+		return k
+	}
+}
+
+func anyMatch(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanPath(p string) string {
+	if p == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+// globToRegexp converts a path glob to an anchored regular expression.
+// "*" and "?" stay within one path segment, "**" spans segments and a
+// "**/" prefix also matches zero directories.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	g := strings.TrimSpace(glob)
+	if strings.HasPrefix(g, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			g = filepath.Join(home, g[2:])
+		}
+	}
+	g = filepath.ToSlash(g)
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(g); i++ {
+		c := g[i]
+		switch c {
+		case '*':
+			if i+1 < len(g) && g[i+1] == '*' {
+				i++
+				if i+1 < len(g) && g[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,111 @@
+package policy
+
+import "testing"
+
+func TestEvaluateFirstMatchingRuleWins(t *testing.T) {
+	engine, err := New([]Rule{
+		{ID: "no-rm", Decision: Deny, ToolKinds: []string{"execute"}, Command: `\brm\s+-rf\b`},
+		{ID: "shell", Decision: Allow, ToolKinds: []string{"execute"}},
+	}, false)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	got := engine.Evaluate(Request{ToolKind: "command_execution", Command: "rm -rf /tmp/x"})
+	if got.Decision != Deny || got.RuleID != "no-rm" {
+		t.Fatalf("rm -rf = %+v, want deny by no-rm", got)
+	}
+
+	got = engine.Evaluate(Request{ToolKind: "execute", Command: "go test ./..."})
+	if got.Decision != Allow || got.RuleID != "shell" {
+		t.Fatalf("go test = %+v, want allow by shell", got)
+	}
+}
+
+func TestEvaluatePathGlobs(t *testing.T) {
+	engine, err := New([]Rule{
+		{ID: "secrets", Decision: Ask, Paths: []string{"**/.env", "**/*.pem"}},
+		{ID: "src", Decision: Allow, ToolKinds: []string{"edit"}, Paths: []string{"src/**"}, CWDs: []string{"/work/**"}},
+	}, false)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	got := engine.Evaluate(Request{CWD: "/work/app", ToolKind: "edit", Paths: []string{"src/main.go", "/work/app/.env"}})
+	if got.Decision != Ask || got.RuleID != "secrets" {
+		t.Fatalf("edit touching .env = %+v, want ask by secrets", got)
+	}
+
+	got = engine.Evaluate(Request{CWD: "/work/app", ToolKind: "edit", Paths: []string{"src/main.go", "src/lib/a.go"}})
+	if got.Decision != Allow || got.RuleID != "src" {
+		t.Fatalf("edit under src = %+v, want allow by src", got)
+	}
+
+	// Allow rules need every path to match.
+	got = engine.Evaluate(Request{CWD: "/work/app", ToolKind: "edit", Paths: []string{"src/main.go", "go.mod"}})
+	if got.Decision != Ask || got.RuleID != "" {
+		t.Fatalf("edit outside src = %+v, want default ask", got)
+	}
+
+	// Cwd outside /work does not match the src rule.
+	got = engine.Evaluate(Request{CWD: "/other", ToolKind: "edit", Paths: []string{"src/main.go"}})
+	if got.RuleID != "" {
+		t.Fatalf("edit in /other = %+v, want no rule", got)
+	}
+}
+
+func TestEvaluateAgentsAndAutoApprove(t *testing.T) {
+	engine, err := New([]Rule{
+		{Decision: Deny, Agents: []string{"gemini"}, ToolKinds: []string{"delete"}},
+	}, true)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	got := engine.Evaluate(Request{AgentName: "gemini", ToolKind: "delete"})
+	if got.Decision != Deny || got.RuleID != "rule[0]" {
+		t.Fatalf("gemini delete = %+v, want deny by rule[0]", got)
+	}
+
+	got = engine.Evaluate(Request{AgentName: "opencode", ToolKind: "delete"})
+	if got.Decision != Allow || got.RuleID != AutoApproveRuleID {
+		t.Fatalf("opencode delete = %+v, want auto-approve", got)
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	if _, err := New([]Rule{{Decision: "maybe"}}, false); err == nil {
+		t.Fatal("expected error for invalid decision")
+	}
+	if _, err := New([]Rule{{Decision: Allow, Command: "("}}, false); err == nil {
+		t.Fatal("expected error for invalid command regexp")
+	}
+}
+
+func TestPathDenyRuleAsksWhenRequestNamesNoFiles(t *testing.T) {
+	engine, err := New([]Rule{
+		{ID: "ssh", Decision: Deny, ToolKinds: []string{"read", "execute"}, Paths: []string{"~/.ssh/**"}},
+		{ID: "docs", Decision: Ask, Paths: []string{"docs/**"}},
+	}, true)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	got := engine.Evaluate(Request{CWD: "/work", ToolKind: "execute", Command: "cat ~/.ssh/id_rsa"})
+	if got.Decision != Ask || got.RuleID != "ssh" {
+		t.Fatalf("command without paths = %+v, want ask by ssh", got)
+	}
+
+	// Outside the deny rule's tool kinds, and for ask rules, a request
+	// without paths is not matched.
+	got = engine.Evaluate(Request{CWD: "/work", ToolKind: "fetch"})
+	if got.Decision != Allow || got.RuleID != AutoApproveRuleID {
+		t.Fatalf("fetch without paths = %+v, want auto-approve", got)
+	}
+
+	// Requests that name files are decided by the paths as before.
+	got = engine.Evaluate(Request{CWD: "/work", ToolKind: "read", Paths: []string{"/work/main.go"}})
+	if got.Decision != Allow || got.RuleID != AutoApproveRuleID {
+		t.Fatalf("read outside the rule = %+v, want auto-approve", got)
+	}
+}
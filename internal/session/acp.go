@@ -0,0 +1,169 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"bytesmith/internal/acp"
+	"bytesmith/internal/diff"
+)
+
+// Conversions from ACP session updates to stored records, shared by the
+// desktop app and the headless CLI.
+
+// ToolCallFromUpdate builds the stored form of a tool call update from its
+// parts, as returned by ToolCallParts.
+func ToolCallFromUpdate(update acp.SessionUpdate, parts []ToolCallPart) ToolCallRecord {
+	return ToolCallRecord{
+		ID:          update.ToolCallID,
+		Title:       update.Title,
+		Kind:        update.Kind,
+		Status:      update.Status,
+		Content:     formatToolCallContent(parts, update),
+		Parts:       parts,
+		DiffSummary: summarizeDiffParts(parts),
+	}
+}
+
+// ToolCallParts normalizes the content of a tool call update.
+func ToolCallParts(parts []acp.ToolCallContent) []ToolCallPart {
+	result := make([]ToolCallPart, 0, len(parts))
+	for _, part := range parts {
+		p := ToolCallPart{
+			Type: strings.ToLower(strings.TrimSpace(part.Type)),
+			Path: part.Path,
+		}
+
+		if p.Type == "" {
+			p.Type = "content"
+		}
+
+		if part.Content != nil {
+			p.Text = part.Content.Text
+		}
+
+		switch p.Type {
+		case "diff":
+			p.OldText = part.OldText
+			p.NewText = part.NewText
+		case "terminal":
+			p.TerminalID = part.TerminalID
+		}
+
+		result = append(result, p)
+	}
+	return result
+}
+
+// PlanFromEntries builds the stored form of a plan update.
+func PlanFromEntries(entries []acp.PlanEntry) PlanRecord {
+	plan := PlanRecord{Entries: make([]PlanEntry, 0, len(entries))}
+	for _, e := range entries {
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Content:  e.Content,
+			Priority: e.Priority,
+			Status:   e.Status,
+		})
+	}
+	return plan
+}
+
+func formatToolCallContent(parts []ToolCallPart, update acp.SessionUpdate) string {
+	sections := make([]string, 0, 6)
+
+	for _, part := range parts {
+		switch part.Type {
+		case "content":
+			if strings.TrimSpace(part.Text) != "" {
+				sections = append(sections, "Content:\n"+part.Text)
+			}
+		case "diff":
+			var b strings.Builder
+			if strings.TrimSpace(part.Path) != "" {
+				b.WriteString("Diff: " + part.Path + "\n")
+			} else {
+				b.WriteString("Diff:\n")
+			}
+			b.WriteString(diff.Unified("a/"+diffName(part.Path), "b/"+diffName(part.Path), part.OldText, part.NewText, diff.DefaultContext))
+			rendered := strings.TrimSpace(b.String())
+			if rendered != "" {
+				sections = append(sections, rendered)
+			}
+		case "terminal":
+			terminalText := part.Text
+			switch {
+			case strings.TrimSpace(part.TerminalID) != "" && strings.TrimSpace(terminalText) != "":
+				sections = append(sections, fmt.Sprintf("Terminal (%s):\n%s", part.TerminalID, terminalText))
+			case strings.TrimSpace(part.TerminalID) != "":
+				sections = append(sections, fmt.Sprintf("Terminal: %s", part.TerminalID))
+			case strings.TrimSpace(terminalText) != "":
+				sections = append(sections, "Terminal:\n"+terminalText)
+			}
+		default:
+			if strings.TrimSpace(part.Text) != "" {
+				sections = append(sections, part.Text)
+			}
+		}
+	}
+
+	if len(update.Locations) > 0 {
+		lines := make([]string, 0, len(update.Locations))
+		for _, loc := range update.Locations {
+			if loc.Line > 0 {
+				lines = append(lines, fmt.Sprintf("- %s:%d", loc.Path, loc.Line))
+			} else {
+				lines = append(lines, fmt.Sprintf("- %s", loc.Path))
+			}
+		}
+		sections = append(sections, "Locations:\n"+strings.Join(lines, "\n"))
+	}
+
+	if input := prettyJSON(update.RawInput); input != "" {
+		sections = append(sections, "Input:\n"+input)
+	}
+	if output := prettyJSON(update.RawOutput); output != "" {
+		sections = append(sections, "Output:\n"+output)
+	}
+
+	return strings.TrimSpace(strings.Join(sections, "\n\n"))
+}
+
+func summarizeDiffParts(parts []ToolCallPart) ToolCallDiffSummary {
+	summary := ToolCallDiffSummary{}
+	for _, part := range parts {
+		if part.Type != "diff" {
+			continue
+		}
+		summary.Files++
+		additions, deletions := diff.Stats(part.OldText, part.NewText)
+		summary.Additions += additions
+		summary.Deletions += deletions
+	}
+	return summary
+}
+
+// diffName is the file name shown in unified diff headers.
+func diffName(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return "file"
+	}
+	return strings.TrimPrefix(path, "/")
+}
+
+func prettyJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var parsed any
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return strings.TrimSpace(string(raw))
+	}
+
+	formatted, err := json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		return strings.TrimSpace(string(raw))
+	}
+	return string(formatted)
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"slices"
+	"strings"
+)
+
+// De-duplication of history read back from an agent against what was
+// recorded live, shared by the desktop backfill and the headless proxy.
+
+// UnrecordedMessages returns the remote messages not stored yet. Messages
+// recorded live carry local IDs, so a remote message that matches no ID
+// is matched against a stored message of the same role and kind with the
+// same text, each stored message standing for one remote message. A
+// streamed reply is stored whole but kept remotely as several text parts;
+// agent parts that continue a stored reply are consumed from it in order.
+func UnrecordedMessages(stored, remote []Message) []Message {
+	recorded := make([]recordedMessage, len(stored))
+	byID := make(map[string]int, len(stored))
+	for i, m := range stored {
+		text := normalizeMessageText(m.Content)
+		recorded[i] = recordedMessage{key: messageKey(m), text: text, rest: text}
+		if m.ID != "" {
+			byID[m.ID] = i
+		}
+	}
+
+	var result []Message
+	for _, m := range remote {
+		if i, ok := byID[m.ID]; ok && m.ID != "" {
+			recorded[i].rest = ""
+			continue
+		}
+		if !consumeRecorded(recorded, m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// recordedMessage is a stored message during UnrecordedMessages; rest is
+// the part of its text no remote message has matched yet.
+type recordedMessage struct {
+	key  string
+	text string
+	rest string
+}
+
+// consumeRecorded marks the stored text m accounts for as matched and
+// reports whether there was any.
+func consumeRecorded(recorded []recordedMessage, m Message) bool {
+	text := normalizeMessageText(m.Content)
+	if text == "" {
+		return true
+	}
+	key := messageKey(m)
+
+	for i := range recorded {
+		r := &recorded[i]
+		if r.key == key && r.rest == r.text && r.text == text {
+			r.rest = ""
+			return true
+		}
+	}
+	if m.Role != "agent" {
+		return false
+	}
+	for i := range recorded {
+		r := &recorded[i]
+		if r.key == key && r.rest != "" && strings.HasPrefix(r.rest, text) {
+			r.rest = strings.TrimSpace(r.rest[len(text):])
+			return true
+		}
+	}
+	return false
+}
+
+func messageKey(m Message) string {
+	kind := m.Kind
+	if kind == "" {
+		kind = MessageText
+	}
+	return m.Role + "/" + kind
+}
+
+func normalizeMessageText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// UnrecordedToolCalls returns the remote tool calls not stored yet. Live
+// tool calls are stored under the agent's call ID, so the ID suffices.
+func UnrecordedToolCalls(stored, remote []ToolCallRecord) []ToolCallRecord {
+	ids := make(map[string]bool, len(stored))
+	for _, tc := range stored {
+		ids[tc.ID] = true
+	}
+
+	var result []ToolCallRecord
+	for _, tc := range remote {
+		if !ids[tc.ID] {
+			result = append(result, tc)
+		}
+	}
+	return result
+}
+
+// UnrecordedPlans returns the remote plans not stored yet. Plans recorded
+// live carry local IDs, so a remote plan is matched against a stored plan
+// with the same entries, each stored plan standing for one remote plan.
+func UnrecordedPlans(stored, remote []PlanRecord) []PlanRecord {
+	used := make([]bool, len(stored))
+
+	var result []PlanRecord
+	for _, plan := range remote {
+		found := false
+		for i, s := range stored {
+			if !used[i] && slices.Equal(s.Entries, plan.Entries) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			result = append(result, plan)
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package session
+
+import "testing"
+
+func TestUnrecordedMessages(t *testing.T) {
+	stored := []Message{
+		{ID: "local-1", Role: "user", Content: "Should I continue with the refactor?"},
+		{ID: "local-2", Role: "agent", Kind: MessageText, Content: "Yes, the  parser\nfirst."},
+		{ID: "p-9", Role: "agent", Kind: MessageThought, Content: "thinking"},
+		{ID: "local-3", Role: "user", Content: "go on"},
+	}
+	remote := []Message{
+		{ID: "m1", Role: "user", Kind: MessageText, Content: "Should I continue with the refactor?"},
+		{ID: "p1", Role: "agent", Kind: MessageText, Content: "Yes, the parser"},
+		{ID: "p2", Role: "agent", Kind: MessageText, Content: "first."},
+		{ID: "p-9", Role: "agent", Kind: MessageThought, Content: "thinking, reworded"},
+		{ID: "m2", Role: "user", Kind: MessageText, Content: "go on"},
+		{ID: "m3", Role: "user", Kind: MessageText, Content: "continue"},
+		{ID: "m4", Role: "user", Kind: MessageText, Content: "go on"},
+		{ID: "p3", Role: "agent", Kind: MessageText, Content: "parser"},
+		{ID: "m5", Role: "user", Kind: MessageText, Content: "   "},
+	}
+
+	if got := messageIDs(UnrecordedMessages(stored, remote)); got != "m3,m4,p3" {
+		t.Fatalf("unrecorded = %s, want m3,m4,p3", got)
+	}
+}
+
+func TestUnrecordedMessagesEmptyStore(t *testing.T) {
+	remote := []Message{
+		{ID: "m1", Role: "user", Content: "yes"},
+		{ID: "m2", Role: "user", Content: "yes"},
+	}
+	if got := UnrecordedMessages(nil, remote); len(got) != 2 {
+		t.Fatalf("unrecorded = %s, want both messages", messageIDs(got))
+	}
+}
+
+func TestUnrecordedPlans(t *testing.T) {
+	build := []PlanEntry{{Content: "build", Status: "completed"}}
+	test := []PlanEntry{{Content: "test", Status: "pending"}}
+	stored := []PlanRecord{{ID: "local-1", Entries: build}}
+	remote := []PlanRecord{{ID: "r1", Entries: build}, {ID: "r2", Entries: build}, {ID: "r3", Entries: test}}
+
+	got := UnrecordedPlans(stored, remote)
+	if len(got) != 2 || got[0].ID != "r2" || got[1].ID != "r3" {
+		t.Fatalf("unrecorded = %+v, want r2 and r3", got)
+	}
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -9,35 +10,49 @@ import (
 
 // MemoryStore is an in-memory session store used as fallback and in tests.
 type MemoryStore struct {
-	sessions map[string]*SessionRecord
-	mu       sync.RWMutex
+	sessions    map[string]*SessionRecord
+	fileChanges map[string][]FileChangeRecord
+	mu          sync.RWMutex
 }
 
 // NewMemoryStore creates a new in-memory Store.
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		sessions: make(map[string]*SessionRecord),
+		sessions:    make(map[string]*SessionRecord),
+		fileChanges: make(map[string][]FileChangeRecord),
 	}
 }
 
 // Create initialises a new SessionRecord and stores it. If a session with the
-// given ID already exists it is silently overwritten.
+// given ID already exists, as when a stored session is loaded again, only its
+// agent, connection and working directory are updated, as in SQLiteStore.
 func (s *MemoryStore) Create(id, agentName, connectionID, cwd string) *SessionRecord {
 	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.sessions[id]; ok {
+		rec.AgentName = agentName
+		rec.ConnectionID = connectionID
+		rec.CWD = cwd
+		rec.UpdatedAt = now
+		return cloneSessionRecord(rec)
+	}
+
 	rec := &SessionRecord{
 		ID:           id,
 		AgentName:    agentName,
 		ConnectionID: connectionID,
 		CWD:          cwd,
+		Tags:         []string{},
 		Messages:     make([]Message, 0),
 		ToolCalls:    make([]ToolCallRecord, 0),
+		Plans:        make([]PlanRecord, 0),
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
-
-	s.mu.Lock()
 	s.sessions[id] = rec
-	s.mu.Unlock()
 
 	return rec
 }
@@ -71,6 +86,9 @@ func (s *MemoryStore) AddMessage(sessionID string, msg Message) {
 	if msg.Timestamp.IsZero() {
 		msg.Timestamp = time.Now()
 	}
+	if msg.Kind == "" {
+		msg.Kind = MessageText
+	}
 
 	rec.Messages = append(rec.Messages, msg)
 	rec.UpdatedAt = time.Now()
@@ -96,6 +114,29 @@ func (s *MemoryStore) AddToolCall(sessionID string, tc ToolCallRecord) {
 	rec.UpdatedAt = time.Now()
 }
 
+// AddPlan appends a plan update to the session.
+// It is a no-op if the session does not exist.
+func (s *MemoryStore) AddPlan(sessionID string, plan PlanRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.sessions[sessionID]
+	if !ok {
+		return
+	}
+
+	if plan.ID == "" {
+		plan.ID = uuid.NewString()
+	}
+	if plan.Timestamp.IsZero() {
+		plan.Timestamp = time.Now()
+	}
+	plan.Entries = append([]PlanEntry(nil), plan.Entries...)
+
+	rec.Plans = append(rec.Plans, plan)
+	rec.UpdatedAt = time.Now()
+}
+
 // UpdateToolCall finds an existing tool call by ID within the session and
 // updates its status and content fields. It is a no-op if the session or
 // tool call is not found.
@@ -129,6 +170,137 @@ func (s *MemoryStore) UpdateToolCall(
 	}
 }
 
+// AddFileChange records a file write for the session.
+// It is a no-op if the session does not exist.
+func (s *MemoryStore) AddFileChange(change FileChangeRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.sessions[change.SessionID]
+	if !ok {
+		return
+	}
+
+	if change.ID == "" {
+		change.ID = uuid.NewString()
+	}
+	if change.Timestamp.IsZero() {
+		change.Timestamp = time.Now()
+	}
+	if change.Status == "" {
+		change.Status = FileChangeApplied
+	}
+
+	s.fileChanges[change.SessionID] = append(s.fileChanges[change.SessionID], change)
+	rec.UpdatedAt = time.Now()
+}
+
+// GetFileChange returns one file change by ID, or nil if not found.
+func (s *MemoryStore) GetFileChange(id string) *FileChangeRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, changes := range s.fileChanges {
+		for _, c := range changes {
+			if c.ID == id {
+				out := c
+				return &out
+			}
+		}
+	}
+	return nil
+}
+
+// ListFileChanges returns the session's file changes, oldest first.
+func (s *MemoryStore) ListFileChanges(sessionID string) []FileChangeRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]FileChangeRecord{}, s.fileChanges[sessionID]...)
+}
+
+// MarkFileChangeReverted flags a file change as reverted.
+func (s *MemoryStore) MarkFileChangeReverted(id string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, changes := range s.fileChanges {
+		for i := range changes {
+			if changes[i].ID == id {
+				changes[i].Status = FileChangeReverted
+				changes[i].RevertedAt = at
+				return
+			}
+		}
+	}
+}
+
+// Search scans every message and tool call for the query terms. Hits are
+// ranked by how often the terms occur.
+func (s *MemoryStore) Search(query string, filter SearchFilter) ([]SearchHit, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := make([]SearchHit, 0)
+	for _, rec := range s.sessions {
+		if !filter.matchesSession(rec.AgentName, rec.CWD) {
+			continue
+		}
+		for _, m := range rec.Messages {
+			if !filter.matchesTime(m.Timestamp) {
+				continue
+			}
+			if score, ok := matchText(m.Content, terms); ok {
+				hits = append(hits, SearchHit{
+					Kind:      SearchHitMessage,
+					SessionID: rec.ID,
+					AgentName: rec.AgentName,
+					CWD:       rec.CWD,
+					MessageID: m.ID,
+					Role:      m.Role,
+					Snippet:   plainSnippet(m.Content, terms),
+					Timestamp: m.Timestamp,
+					Score:     float64(score),
+				})
+			}
+		}
+		for _, tc := range rec.ToolCalls {
+			if !filter.matchesTime(tc.Timestamp) {
+				continue
+			}
+			text := tc.Title + "\n" + tc.Content
+			if score, ok := matchText(text, terms); ok {
+				hits = append(hits, SearchHit{
+					Kind:       SearchHitToolCall,
+					SessionID:  rec.ID,
+					AgentName:  rec.AgentName,
+					CWD:        rec.CWD,
+					ToolCallID: tc.ID,
+					Role:       tc.Title,
+					Snippet:    plainSnippet(text, terms),
+					Timestamp:  tc.Timestamp,
+					Score:      float64(score),
+				})
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Timestamp.After(hits[j].Timestamp)
+	})
+	if limit := filter.limit(); len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
 // List returns all session records.
 func (s *MemoryStore) List() []*SessionRecord {
 	s.mu.RLock()
@@ -141,11 +313,214 @@ func (s *MemoryStore) List() []*SessionRecord {
 	return out
 }
 
+// GetSummary returns the summary of one session, or nil if not found.
+func (s *MemoryStore) GetSummary(id string) *SessionSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	summary := summarize(rec)
+	return &summary
+}
+
+// ListSummaries returns one page of session summaries and the number of
+// sessions matching opts.
+func (s *MemoryStore) ListSummaries(opts ListOptions) ([]SessionSummary, int) {
+	s.mu.RLock()
+	out := make([]SessionSummary, 0, len(s.sessions))
+	for _, rec := range s.sessions {
+		if opts.ConnectionID != "" && rec.ConnectionID != opts.ConnectionID {
+			continue
+		}
+		if !opts.matchesMetadata(rec.Tags, rec.Archived) {
+			continue
+		}
+		out = append(out, summarize(rec))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Pinned != out[j].Pinned {
+			return out[i].Pinned
+		}
+		ti, tj := opts.sortTime(out[i]), opts.sortTime(out[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return paginate(out, opts.Offset, opts.Limit), len(out)
+}
+
+// ListMessages returns a page of at most limit messages, skipping the offset
+// newest ones. A zero limit returns every remaining message.
+func (s *MemoryStore) ListMessages(sessionID string, offset, limit int) MessagePage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page := MessagePage{Messages: []Message{}, ToolCalls: []ToolCallRecord{}, Offset: max(offset, 0)}
+	rec, ok := s.sessions[sessionID]
+	if !ok {
+		return page
+	}
+
+	msgs := rec.Messages
+	page.Total = len(msgs)
+	if page.Offset >= len(msgs) && len(msgs) > 0 {
+		return page
+	}
+
+	end := len(msgs) - page.Offset
+	start := 0
+	if limit > 0 {
+		start = max(end-limit, 0)
+	}
+	page.Messages = append(page.Messages, msgs[start:end]...)
+
+	var from, before time.Time
+	if start > 0 {
+		from = msgs[start].Timestamp
+	}
+	if end < len(msgs) {
+		before = msgs[end].Timestamp
+	}
+	page.ToolCalls = pageToolCalls(rec.ToolCalls, from, before)
+	for i := range page.ToolCalls {
+		page.ToolCalls[i].Parts = append([]ToolCallPart(nil), page.ToolCalls[i].Parts...)
+	}
+	return page
+}
+
+// SetTitle renames a session.
+func (s *MemoryStore) SetTitle(id, title string) {
+	s.updateMetadata(id, func(rec *SessionRecord) { rec.Title = title })
+}
+
+// SetTags replaces the tags of a session.
+func (s *MemoryStore) SetTags(id string, tags []string) {
+	tags = normalizeTags(tags)
+	s.updateMetadata(id, func(rec *SessionRecord) { rec.Tags = tags })
+}
+
+// SetPinned pins or unpins a session.
+func (s *MemoryStore) SetPinned(id string, pinned bool) {
+	s.updateMetadata(id, func(rec *SessionRecord) { rec.Pinned = pinned })
+}
+
+// SetArchived archives or restores a session.
+func (s *MemoryStore) SetArchived(id string, archived bool) {
+	s.updateMetadata(id, func(rec *SessionRecord) { rec.Archived = archived })
+}
+
+func (s *MemoryStore) updateMetadata(id string, update func(rec *SessionRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.sessions[id]; ok {
+		update(rec)
+	}
+}
+
+// Import stores a copy of rec.
+func (s *MemoryStore) Import(rec *SessionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[rec.ID]; ok {
+		return ErrSessionExists
+	}
+	imported := cloneSessionRecord(rec)
+	imported.Tags = normalizeTags(imported.Tags)
+	for i := range imported.Messages {
+		if imported.Messages[i].Kind == "" {
+			imported.Messages[i].Kind = MessageText
+		}
+	}
+	s.sessions[rec.ID] = imported
+	return nil
+}
+
+// Merge adds the entries of rec whose IDs are not stored yet.
+func (s *MemoryStore) Merge(rec *SessionRecord) (MergeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sessions[rec.ID]
+	if !ok {
+		existing = cloneSessionRecord(rec)
+		existing.Tags = nil
+		existing.Messages, existing.ToolCalls, existing.Plans = nil, nil, nil
+		existing.CreatedAt, existing.UpdatedAt = sessionTimes(rec)
+		s.sessions[rec.ID] = existing
+	} else if existing.Title == "" {
+		existing.Title = rec.Title
+	}
+	result := MergeResult{Created: !ok}
+
+	existing.Tags = normalizeTags(append(existing.Tags, rec.Tags...))
+	seen := make(map[string]bool)
+	for _, m := range existing.Messages {
+		seen["m:"+m.ID] = true
+	}
+	for _, tc := range existing.ToolCalls {
+		seen["t:"+tc.ID] = true
+	}
+	for _, p := range existing.Plans {
+		seen["p:"+p.ID] = true
+	}
+	added := cloneSessionRecord(rec)
+	for _, m := range added.Messages {
+		if seen["m:"+m.ID] {
+			continue
+		}
+		seen["m:"+m.ID] = true
+		if m.Kind == "" {
+			m.Kind = MessageText
+		}
+		existing.Messages = append(existing.Messages, m)
+		result.Messages++
+	}
+	for _, tc := range added.ToolCalls {
+		if !seen["t:"+tc.ID] {
+			seen["t:"+tc.ID] = true
+			existing.ToolCalls = append(existing.ToolCalls, tc)
+			result.ToolCalls++
+		}
+	}
+	for _, p := range added.Plans {
+		if !seen["p:"+p.ID] {
+			seen["p:"+p.ID] = true
+			existing.Plans = append(existing.Plans, p)
+			result.Plans++
+		}
+	}
+	if result.Added() == 0 {
+		return result, nil
+	}
+
+	sort.SliceStable(existing.Messages, func(i, j int) bool {
+		return existing.Messages[i].Timestamp.Before(existing.Messages[j].Timestamp)
+	})
+	sort.SliceStable(existing.ToolCalls, func(i, j int) bool {
+		return existing.ToolCalls[i].Timestamp.Before(existing.ToolCalls[j].Timestamp)
+	})
+	sort.SliceStable(existing.Plans, func(i, j int) bool {
+		return existing.Plans[i].Timestamp.Before(existing.Plans[j].Timestamp)
+	})
+	if _, last := activitySpan(rec); ok && last.After(existing.UpdatedAt) {
+		existing.UpdatedAt = last
+	}
+	return result, nil
+}
+
 // Delete removes a session from the store.
 func (s *MemoryStore) Delete(id string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.sessions, id)
+	delete(s.fileChanges, id)
 }
 
 // Close satisfies Store.
@@ -159,10 +534,32 @@ func cloneSessionRecord(rec *SessionRecord) *SessionRecord {
 	}
 
 	copyRec := *rec
+	copyRec.Tags = append([]string{}, rec.Tags...)
 	copyRec.Messages = append([]Message(nil), rec.Messages...)
 	copyRec.ToolCalls = append([]ToolCallRecord(nil), rec.ToolCalls...)
 	for i := range copyRec.ToolCalls {
 		copyRec.ToolCalls[i].Parts = append([]ToolCallPart(nil), copyRec.ToolCalls[i].Parts...)
 	}
+	copyRec.Plans = append([]PlanRecord(nil), rec.Plans...)
+	for i := range copyRec.Plans {
+		copyRec.Plans[i].Entries = append([]PlanEntry(nil), copyRec.Plans[i].Entries...)
+	}
 	return &copyRec
 }
+
+func summarize(rec *SessionRecord) SessionSummary {
+	return SessionSummary{
+		ID:            rec.ID,
+		AgentName:     rec.AgentName,
+		ConnectionID:  rec.ConnectionID,
+		CWD:           rec.CWD,
+		Title:         rec.Title,
+		Tags:          append([]string{}, rec.Tags...),
+		Pinned:        rec.Pinned,
+		Archived:      rec.Archived,
+		MessageCount:  len(rec.Messages),
+		ToolCallCount: len(rec.ToolCalls),
+		CreatedAt:     rec.CreatedAt,
+		UpdatedAt:     rec.UpdatedAt,
+	}
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Archived filters for ListOptions.Archived.
+const (
+	ArchivedExclude = ""        // hide archived sessions
+	ArchivedOnly    = "only"    // list only archived sessions
+	ArchivedInclude = "include" // list archived sessions with the rest
+)
+
+// maxTitleRunes caps generated titles.
+const maxTitleRunes = 80
+
+// TitleFromPrompt derives a session title from a user prompt: its first
+// non-empty line with whitespace collapsed, shortened to fit a list row.
+func TitleFromPrompt(prompt string) string {
+	var line string
+	for _, l := range strings.Split(prompt, "\n") {
+		if line = strings.Join(strings.Fields(l), " "); line != "" {
+			break
+		}
+	}
+	if utf8.RuneCountInString(line) <= maxTitleRunes {
+		return line
+	}
+	runes := []rune(line)[:maxTitleRunes-1]
+	return strings.TrimRight(string(runes), " ") + "…"
+}
+
+// normalizeTags trims tags, drops empty ones and duplicates, and sorts them.
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// matchesMetadata reports whether a session passes the tag and archived
+// filters of opts.
+func (o ListOptions) matchesMetadata(tags []string, archived bool) bool {
+	switch o.Archived {
+	case ArchivedOnly:
+		if !archived {
+			return false
+		}
+	case ArchivedInclude:
+	default:
+		if archived {
+			return false
+		}
+	}
+	for _, t := range o.Tags {
+		if !slices.Contains(tags, t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package session
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Search hit kinds.
+const (
+	SearchHitMessage  = "message"
+	SearchHitToolCall = "tool_call"
+)
+
+// DefaultSearchLimit caps the hits returned when SearchFilter.Limit is unset.
+const DefaultSearchLimit = 50
+
+// Snippets mark matched terms with these tags; the rest of the snippet is
+// HTML-escaped so it can be rendered as is.
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// snippetStart and snippetEnd delimit matches in raw snippets before they
+// are escaped.
+const (
+	snippetStart = "\x02"
+	snippetEnd   = "\x03"
+)
+
+// SearchFilter narrows a search. Zero values do not filter.
+type SearchFilter struct {
+	AgentName string
+	// CWD matches sessions in the directory or one of its subdirectories.
+	CWD   string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// SearchHit is one message or tool call matching a search.
+type SearchHit struct {
+	Kind       string // SearchHitMessage or SearchHitToolCall
+	SessionID  string
+	AgentName  string
+	CWD        string
+	MessageID  string // set for message hits
+	ToolCallID string // set for tool call hits
+	Role       string // message role, or the tool call title
+	Snippet    string
+	Timestamp  time.Time
+	// Score orders hits; higher is more relevant.
+	Score float64
+}
+
+// searchTerms splits a user query into lowercase terms.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+}
+
+// ftsQuery turns a user query into an FTS5 query matching every term, the
+// last one as a prefix so results show up while typing.
+func ftsQuery(query string) string {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"`
+	}
+	quoted[len(quoted)-1] += "*"
+	return strings.Join(quoted, " ")
+}
+
+// renderSnippet escapes a raw snippet and turns its match delimiters into
+// highlight tags.
+func renderSnippet(raw string) string {
+	escaped := html.EscapeString(raw)
+	escaped = strings.ReplaceAll(escaped, snippetStart, HighlightStart)
+	return strings.ReplaceAll(escaped, snippetEnd, HighlightEnd)
+}
+
+func (f SearchFilter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultSearchLimit
+	}
+	return f.Limit
+}
+
+func (f SearchFilter) matchesSession(agentName, cwd string) bool {
+	if f.AgentName != "" && agentName != f.AgentName {
+		return false
+	}
+	if f.CWD != "" {
+		root := strings.TrimRight(f.CWD, "/")
+		if cwd != root && !strings.HasPrefix(cwd, root+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+func (f SearchFilter) matchesTime(ts time.Time) bool {
+	if !f.From.IsZero() && ts.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && ts.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// matchText scores text against terms for stores without a full-text index.
+// Every term must occur; the score is the number of occurrences.
+func matchText(text string, terms []string) (score int, ok bool) {
+	lower := strings.ToLower(text)
+	for _, t := range terms {
+		n := strings.Count(lower, t)
+		if n == 0 {
+			return 0, false
+		}
+		score += n
+	}
+	return score, true
+}
+
+// plainSnippet cuts a window of text around the first occurrence of any term
+// and highlights the terms in it.
+func plainSnippet(text string, terms []string) string {
+	const window = 60
+
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowercasing changed byte offsets; match case-sensitively instead.
+		lower = text
+	}
+	first := -1
+	for _, t := range terms {
+		if i := strings.Index(lower, t); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := max(first-window, 0)
+	end := min(first+window, len(text))
+	// Stay on rune boundaries.
+	for start > 0 && !isRuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !isRuneStart(text[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	segment := text[start:end]
+	lowerSegment := lower[start:end]
+	for pos := 0; pos < len(segment); {
+		next, term := -1, ""
+		for _, t := range terms {
+			if i := strings.Index(lowerSegment[pos:], t); i >= 0 && (next < 0 || i < next) {
+				next, term = i, t
+			}
+		}
+		if next < 0 {
+			b.WriteString(segment[pos:])
+			break
+		}
+		b.WriteString(segment[pos : pos+next])
+		b.WriteString(snippetStart)
+		b.WriteString(segment[pos+next : pos+next+len(term)])
+		b.WriteString(snippetEnd)
+		pos += next + len(term)
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return renderSnippet(b.String())
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -3,11 +3,12 @@ package session
 import (
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,7 +30,10 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("session: create db dir: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", path)
+	// Connection-level pragmas go in the DSN so every pooled connection
+	// gets them. The busy timeout lets the app and `bytesmith run` write
+	// to the same database without failing with SQLITE_BUSY.
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
 	if err != nil {
 		return nil, fmt.Errorf("session: open sqlite: %w", err)
 	}
@@ -38,10 +42,6 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 		db.Close()
 		return nil, fmt.Errorf("session: enable wal: %w", err)
 	}
-	if _, err := db.Exec(`PRAGMA foreign_keys=ON;`); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("session: enable foreign keys: %w", err)
-	}
 
 	store := &SQLiteStore{db: db}
 	if err := store.migrate(); err != nil {
@@ -63,14 +63,15 @@ func (s *SQLiteStore) migrate() error {
 			updated_at TEXT NOT NULL
 		);`,
 		`CREATE TABLE IF NOT EXISTS messages (
-			id TEXT PRIMARY KEY,
+			row_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			id TEXT NOT NULL UNIQUE,
 			session_id TEXT NOT NULL,
 			role TEXT NOT NULL,
+			kind TEXT NOT NULL DEFAULT 'text',
 			content TEXT NOT NULL,
 			timestamp TEXT NOT NULL,
 			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
 		);`,
-		`CREATE INDEX IF NOT EXISTS idx_messages_session_ts ON messages(session_id, timestamp);`,
 		`CREATE TABLE IF NOT EXISTS tool_calls (
 			row_id INTEGER PRIMARY KEY AUTOINCREMENT,
 			session_id TEXT NOT NULL,
@@ -87,7 +88,36 @@ func (s *SQLiteStore) migrate() error {
 			UNIQUE(session_id, tool_call_id),
 			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
 		);`,
-		`CREATE INDEX IF NOT EXISTS idx_tool_calls_session_ts ON tool_calls(session_id, timestamp);`,
+		`CREATE TABLE IF NOT EXISTS file_changes (
+			id TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL,
+			tool_call_id TEXT NOT NULL DEFAULT '',
+			agent_name TEXT NOT NULL DEFAULT '',
+			path TEXT NOT NULL,
+			old_content TEXT NOT NULL,
+			new_content TEXT NOT NULL,
+			created INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			timestamp TEXT NOT NULL,
+			reverted_at TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_file_changes_session_ts ON file_changes(session_id, timestamp);`,
+		`CREATE TABLE IF NOT EXISTS session_tags (
+			session_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY(session_id, tag),
+			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_session_tags_tag ON session_tags(tag);`,
+		`CREATE TABLE IF NOT EXISTS plans (
+			id TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL,
+			entries_json TEXT NOT NULL,
+			timestamp TEXT NOT NULL,
+			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_plans_session_ts ON plans(session_id, timestamp);`,
 	}
 
 	for _, stmt := range stmts {
@@ -96,28 +126,175 @@ func (s *SQLiteStore) migrate() error {
 		}
 	}
 
-	if err := s.ensureToolCallColumns(); err != nil {
+	if err := s.ensureColumns("tool_calls", []column{
+		{name: "parts_json", ddl: `ALTER TABLE tool_calls ADD COLUMN parts_json TEXT NOT NULL DEFAULT '[]'`},
+		{name: "diff_additions", ddl: `ALTER TABLE tool_calls ADD COLUMN diff_additions INTEGER NOT NULL DEFAULT 0`},
+		{name: "diff_deletions", ddl: `ALTER TABLE tool_calls ADD COLUMN diff_deletions INTEGER NOT NULL DEFAULT 0`},
+		{name: "diff_files", ddl: `ALTER TABLE tool_calls ADD COLUMN diff_files INTEGER NOT NULL DEFAULT 0`},
+	}); err != nil {
+		return err
+	}
+
+	if err := s.ensureColumns("messages", []column{
+		{name: "kind", ddl: `ALTER TABLE messages ADD COLUMN kind TEXT NOT NULL DEFAULT 'text'`},
+	}); err != nil {
+		return err
+	}
+	if err := s.ensureMessageRowIDs(); err != nil {
+		return err
+	}
+
+	if err := s.ensureColumns("sessions", []column{
+		{name: "title", ddl: `ALTER TABLE sessions ADD COLUMN title TEXT NOT NULL DEFAULT ''`},
+		{name: "pinned", ddl: `ALTER TABLE sessions ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`},
+		{name: "archived", ddl: `ALTER TABLE sessions ADD COLUMN archived INTEGER NOT NULL DEFAULT 0`},
+	}); err != nil {
+		return err
+	}
+
+	if err := s.ensureTimeIndexes(); err != nil {
+		return err
+	}
+
+	if err := s.ensureSearchIndex(); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (s *SQLiteStore) ensureToolCallColumns() error {
-	type column struct {
-		name string
-		ddl  string
+// ensureTimeIndexes indexes the expressions message pages and session
+// listings order and bound by. Timestamps are compared with julianday()
+// because RFC 3339 text with trimmed fractions does not sort as time, so
+// an index on the text column would not serve them.
+func (s *SQLiteStore) ensureTimeIndexes() error {
+	stmts := []string{
+		`DROP INDEX IF EXISTS idx_messages_session_ts;`,
+		`DROP INDEX IF EXISTS idx_tool_calls_session_ts;`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_session_time ON messages(session_id, julianday(timestamp));`,
+		`CREATE INDEX IF NOT EXISTS idx_tool_calls_session_time ON tool_calls(session_id, julianday(timestamp));`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_updated ON sessions(pinned DESC, julianday(updated_at) DESC, id);`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_created ON sessions(pinned DESC, julianday(created_at) DESC, id);`,
 	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("session: migrate time indexes: %w", err)
+		}
+	}
+	return nil
+}
 
-	columns := []column{
-		{name: "parts_json", ddl: `ALTER TABLE tool_calls ADD COLUMN parts_json TEXT NOT NULL DEFAULT '[]'`},
-		{name: "diff_additions", ddl: `ALTER TABLE tool_calls ADD COLUMN diff_additions INTEGER NOT NULL DEFAULT 0`},
-		{name: "diff_deletions", ddl: `ALTER TABLE tool_calls ADD COLUMN diff_deletions INTEGER NOT NULL DEFAULT 0`},
-		{name: "diff_files", ddl: `ALTER TABLE tool_calls ADD COLUMN diff_files INTEGER NOT NULL DEFAULT 0`},
+// ensureMessageRowIDs rebuilds a messages table created without row_id.
+// The search index is keyed on it: the implicit rowid of a table with a
+// text primary key may change on VACUUM. The old index is dropped so that
+// ensureSearchIndex rebuilds it.
+func (s *SQLiteStore) ensureMessageRowIDs() error {
+	exists, err := s.columnExists("messages", "row_id")
+	if err != nil || exists {
+		return err
 	}
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("session: migrate messages: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`CREATE TABLE messages_rebuild (
+			row_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			id TEXT NOT NULL UNIQUE,
+			session_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			kind TEXT NOT NULL DEFAULT 'text',
+			content TEXT NOT NULL,
+			timestamp TEXT NOT NULL,
+			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		);`,
+		`INSERT INTO messages_rebuild (id, session_id, role, kind, content, timestamp)
+		 SELECT id, session_id, role, kind, content, timestamp FROM messages ORDER BY rowid;`,
+		`DROP TABLE messages;`,
+		`ALTER TABLE messages_rebuild RENAME TO messages;`,
+		`DROP TABLE IF EXISTS messages_fts;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("session: migrate messages: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("session: migrate messages: %w", err)
+	}
+	return nil
+}
+
+// ensureSearchIndex creates the FTS5 tables over message content and tool
+// call titles and content, and the triggers that keep them in sync. The
+// index rows share the row_id of the row they index. Existing history is
+// indexed the first time the tables are created.
+func (s *SQLiteStore) ensureSearchIndex() error {
+	var existing int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('messages_fts', 'tool_calls_fts')`,
+	).Scan(&existing); err != nil {
+		return fmt.Errorf("session: migrate search index: %w", err)
+	}
+
+	stmts := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(content, tokenize = 'unicode61 remove_diacritics 2');`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS tool_calls_fts USING fts5(title, content, tokenize = 'unicode61 remove_diacritics 2');`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+			INSERT INTO messages_fts(rowid, content) VALUES (new.row_id, new.content);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE OF content ON messages BEGIN
+			UPDATE messages_fts SET content = new.content WHERE rowid = old.row_id;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+			DELETE FROM messages_fts WHERE rowid = old.row_id;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS tool_calls_fts_insert AFTER INSERT ON tool_calls BEGIN
+			INSERT INTO tool_calls_fts(rowid, title, content) VALUES (new.row_id, new.title, new.content);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS tool_calls_fts_update AFTER UPDATE OF title, content ON tool_calls BEGIN
+			UPDATE tool_calls_fts SET title = new.title, content = new.content WHERE rowid = old.row_id;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS tool_calls_fts_delete AFTER DELETE ON tool_calls BEGIN
+			DELETE FROM tool_calls_fts WHERE rowid = old.row_id;
+		END;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("session: migrate search index: %w", err)
+		}
+	}
+
+	if existing == 2 {
+		return nil
+	}
+	backfill := []string{
+		`DELETE FROM messages_fts;`,
+		`INSERT INTO messages_fts(rowid, content) SELECT row_id, content FROM messages;`,
+		`DELETE FROM tool_calls_fts;`,
+		`INSERT INTO tool_calls_fts(rowid, title, content) SELECT row_id, title, content FROM tool_calls;`,
+	}
+	for _, stmt := range backfill {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("session: backfill search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// column is a column added to an existing table by a later release.
+type column struct {
+	name string
+	ddl  string
+}
+
+// ensureColumns adds the columns missing from table.
+func (s *SQLiteStore) ensureColumns(table string, columns []column) error {
 	for _, c := range columns {
-		exists, err := s.toolCallColumnExists(c.name)
+		exists, err := s.columnExists(table, c.name)
 		if err != nil {
 			return err
 		}
@@ -125,17 +302,17 @@ func (s *SQLiteStore) ensureToolCallColumns() error {
 			continue
 		}
 		if _, err := s.db.Exec(c.ddl); err != nil {
-			return fmt.Errorf("session: migrate add column %s: %w", c.name, err)
+			return fmt.Errorf("session: migrate add column %s.%s: %w", table, c.name, err)
 		}
 	}
 
 	return nil
 }
 
-func (s *SQLiteStore) toolCallColumnExists(name string) (bool, error) {
-	rows, err := s.db.Query(`PRAGMA table_info(tool_calls)`)
+func (s *SQLiteStore) columnExists(table, name string) (bool, error) {
+	rows, err := s.db.Query(`PRAGMA table_info(` + table + `)`)
 	if err != nil {
-		return false, fmt.Errorf("session: pragma table_info(tool_calls): %w", err)
+		return false, fmt.Errorf("session: pragma table_info(%s): %w", table, err)
 	}
 	defer rows.Close()
 
@@ -147,7 +324,7 @@ func (s *SQLiteStore) toolCallColumnExists(name string) (bool, error) {
 		var dflt sql.NullString
 		var pk int
 		if err := rows.Scan(&cid, &colName, &colType, &notNull, &dflt, &pk); err != nil {
-			return false, fmt.Errorf("session: scan pragma table_info(tool_calls): %w", err)
+			return false, fmt.Errorf("session: scan pragma table_info(%s): %w", table, err)
 		}
 		if colName == name {
 			return true, nil
@@ -186,49 +363,27 @@ func (s *SQLiteStore) Create(id, agentName, connectionID, cwd string) *SessionRe
 
 // Get returns the full session record with messages and tool calls.
 func (s *SQLiteStore) Get(id string) *SessionRecord {
-	row := s.db.QueryRow(
-		`SELECT id, agent_name, connection_id, cwd, created_at, updated_at
-		 FROM sessions WHERE id = ?`,
-		id,
-	)
-
-	var rec SessionRecord
-	var createdS, updatedS string
-	if err := row.Scan(&rec.ID, &rec.AgentName, &rec.ConnectionID, &rec.CWD, &createdS, &updatedS); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil
-		}
+	summary := s.GetSummary(id)
+	if summary == nil {
 		return nil
 	}
 
-	rec.CreatedAt = parseRFC3339(createdS)
-	rec.UpdatedAt = parseRFC3339(updatedS)
+	rec := summary.record()
 	rec.Messages = s.messagesForSession(id)
 	rec.ToolCalls = s.toolCallsForSession(id)
-	return &rec
+	rec.Plans = s.plansForSession(id)
+	return rec
 }
 
 // AddMessage appends one message and bumps session updated_at.
 func (s *SQLiteStore) AddMessage(sessionID string, msg Message) {
-	if msg.ID == "" {
-		msg.ID = uuid.NewString()
-	}
-	if msg.Timestamp.IsZero() {
-		msg.Timestamp = time.Now().UTC()
-	}
-	ts := msg.Timestamp.UTC().Format(time.RFC3339Nano)
-
 	tx, err := s.db.Begin()
 	if err != nil {
 		return
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec(
-		`INSERT INTO messages (id, session_id, role, content, timestamp)
-		 VALUES (?, ?, ?, ?, ?)`,
-		msg.ID, sessionID, msg.Role, msg.Content, ts,
-	); err != nil {
+	if added, err := insertMessage(tx, sessionID, msg); err != nil || !added {
 		return
 	}
 
@@ -244,12 +399,7 @@ func (s *SQLiteStore) AddMessage(sessionID string, msg Message) {
 
 // AddToolCall inserts or replaces a tool call record.
 func (s *SQLiteStore) AddToolCall(sessionID string, tc ToolCallRecord) {
-	if tc.Timestamp.IsZero() {
-		tc.Timestamp = time.Now().UTC()
-	}
-	ts := tc.Timestamp.UTC().Format(time.RFC3339Nano)
 	now := time.Now().UTC().Format(time.RFC3339Nano)
-	partsJSON := marshalToolCallParts(tc.Parts)
 
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -257,25 +407,7 @@ func (s *SQLiteStore) AddToolCall(sessionID string, tc ToolCallRecord) {
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec(
-		`INSERT INTO tool_calls (
-		   session_id, tool_call_id, title, kind, status, content,
-		   parts_json, diff_additions, diff_deletions, diff_files, timestamp
-		 )
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		 ON CONFLICT(session_id, tool_call_id) DO UPDATE SET
-		   title=excluded.title,
-		   kind=excluded.kind,
-		   status=excluded.status,
-		   content=excluded.content,
-		   parts_json=excluded.parts_json,
-		   diff_additions=excluded.diff_additions,
-		   diff_deletions=excluded.diff_deletions,
-		   diff_files=excluded.diff_files,
-		   timestamp=excluded.timestamp`,
-		sessionID, tc.ID, tc.Title, tc.Kind, tc.Status, tc.Content,
-		partsJSON, tc.DiffSummary.Additions, tc.DiffSummary.Deletions, tc.DiffSummary.Files, ts,
-	); err != nil {
+	if err := upsertToolCall(tx, sessionID, tc); err != nil {
 		return
 	}
 
@@ -286,6 +418,28 @@ func (s *SQLiteStore) AddToolCall(sessionID string, tc ToolCallRecord) {
 	_ = tx.Commit()
 }
 
+// AddPlan appends a plan update and bumps session updated_at.
+func (s *SQLiteStore) AddPlan(sessionID string, plan PlanRecord) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	if added, err := insertPlan(tx, sessionID, plan); err != nil || !added {
+		return
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE sessions SET updated_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), sessionID,
+	); err != nil {
+		return
+	}
+
+	_ = tx.Commit()
+}
+
 // UpdateToolCall updates status/content for one tool call.
 func (s *SQLiteStore) UpdateToolCall(
 	sessionID,
@@ -315,12 +469,204 @@ func (s *SQLiteStore) UpdateToolCall(
 	_, _ = s.db.Exec(`UPDATE sessions SET updated_at = ? WHERE id = ?`, now, sessionID)
 }
 
-// List returns every session with full messages and tool calls.
-func (s *SQLiteStore) List() []*SessionRecord {
+// AddFileChange inserts one file change and bumps session updated_at.
+func (s *SQLiteStore) AddFileChange(change FileChangeRecord) {
+	if change.ID == "" {
+		change.ID = uuid.NewString()
+	}
+	if change.Timestamp.IsZero() {
+		change.Timestamp = time.Now().UTC()
+	}
+	if change.Status == "" {
+		change.Status = FileChangeApplied
+	}
+	ts := change.Timestamp.UTC().Format(time.RFC3339Nano)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO file_changes (
+		   id, session_id, tool_call_id, agent_name, path,
+		   old_content, new_content, created, status, timestamp, reverted_at
+		 )
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.ID, change.SessionID, change.ToolCallID, change.AgentName, change.Path,
+		change.OldContent, change.NewContent, boolToInt(change.Created), change.Status, ts,
+		formatOptionalTime(change.RevertedAt),
+	); err != nil {
+		return
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE sessions SET updated_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), change.SessionID,
+	); err != nil {
+		return
+	}
+
+	_ = tx.Commit()
+}
+
+// GetFileChange returns one file change by ID, or nil if not found.
+func (s *SQLiteStore) GetFileChange(id string) *FileChangeRecord {
+	changes := s.queryFileChanges(`WHERE id = ?`, id)
+	if len(changes) == 0 {
+		return nil
+	}
+	return &changes[0]
+}
+
+// ListFileChanges returns the session's file changes, oldest first.
+func (s *SQLiteStore) ListFileChanges(sessionID string) []FileChangeRecord {
+	return s.queryFileChanges(`WHERE session_id = ? ORDER BY timestamp ASC`, sessionID)
+}
+
+// MarkFileChangeReverted flags a file change as reverted.
+func (s *SQLiteStore) MarkFileChangeReverted(id string, at time.Time) {
+	_, _ = s.db.Exec(
+		`UPDATE file_changes SET status = ?, reverted_at = ? WHERE id = ?`,
+		FileChangeReverted, formatOptionalTime(at), id,
+	)
+}
+
+func (s *SQLiteStore) queryFileChanges(where string, args ...any) []FileChangeRecord {
 	rows, err := s.db.Query(
-		`SELECT id, agent_name, connection_id, cwd, created_at, updated_at
-		 FROM sessions`,
+		`SELECT
+		   id, session_id, tool_call_id, agent_name, path,
+		   old_content, new_content, created, status, timestamp, reverted_at
+		 FROM file_changes `+where,
+		args...,
 	)
+	if err != nil {
+		return []FileChangeRecord{}
+	}
+	defer rows.Close()
+
+	out := make([]FileChangeRecord, 0)
+	for rows.Next() {
+		var c FileChangeRecord
+		var created int
+		var ts, revertedAt string
+		if err := rows.Scan(
+			&c.ID,
+			&c.SessionID,
+			&c.ToolCallID,
+			&c.AgentName,
+			&c.Path,
+			&c.OldContent,
+			&c.NewContent,
+			&created,
+			&c.Status,
+			&ts,
+			&revertedAt,
+		); err != nil {
+			continue
+		}
+		c.Created = created != 0
+		c.Timestamp = parseRFC3339(ts)
+		c.RevertedAt = parseRFC3339(revertedAt)
+		out = append(out, c)
+	}
+	return out
+}
+
+// Search returns the messages and tool calls matching query, best first.
+// Every term of the query must match; the last one also matches as a
+// prefix.
+func (s *SQLiteStore) Search(query string, filter SearchFilter) ([]SearchHit, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return []SearchHit{}, nil
+	}
+
+	where, args := sqliteSearchFilter(filter)
+	stmt := `SELECT kind, session_id, agent_name, cwd, item_id, role, snippet, timestamp, rank FROM (
+		SELECT 'message' AS kind, m.session_id, s.agent_name, s.cwd, m.id AS item_id, m.role,
+		       snippet(messages_fts, 0, char(2), char(3), '…', 16) AS snippet,
+		       m.timestamp, bm25(messages_fts) AS rank
+		FROM messages_fts
+		JOIN messages m ON m.row_id = messages_fts.rowid
+		JOIN sessions s ON s.id = m.session_id
+		WHERE messages_fts MATCH ?` + strings.ReplaceAll(where, "{ts}", "m.timestamp") + `
+		UNION ALL
+		SELECT 'tool_call' AS kind, t.session_id, s.agent_name, s.cwd, t.tool_call_id AS item_id, t.title,
+		       snippet(tool_calls_fts, -1, char(2), char(3), '…', 16) AS snippet,
+		       t.timestamp, bm25(tool_calls_fts, 2.0, 1.0) AS rank
+		FROM tool_calls_fts
+		JOIN tool_calls t ON t.row_id = tool_calls_fts.rowid
+		JOIN sessions s ON s.id = t.session_id
+		WHERE tool_calls_fts MATCH ?` + strings.ReplaceAll(where, "{ts}", "t.timestamp") + `
+	) ORDER BY rank ASC, timestamp DESC LIMIT ?`
+
+	params := []any{match}
+	params = append(params, args...)
+	params = append(params, match)
+	params = append(params, args...)
+	params = append(params, filter.limit())
+
+	rows, err := s.db.Query(stmt, params...)
+	if err != nil {
+		return nil, fmt.Errorf("session: search: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]SearchHit, 0)
+	for rows.Next() {
+		var h SearchHit
+		var itemID, snippet, ts string
+		var rank float64
+		if err := rows.Scan(&h.Kind, &h.SessionID, &h.AgentName, &h.CWD, &itemID, &h.Role, &snippet, &ts, &rank); err != nil {
+			return nil, fmt.Errorf("session: search: %w", err)
+		}
+		if h.Kind == SearchHitToolCall {
+			h.ToolCallID = itemID
+		} else {
+			h.MessageID = itemID
+		}
+		h.Snippet = renderSnippet(snippet)
+		h.Timestamp = parseRFC3339(ts)
+		// bm25 is lower for better matches.
+		h.Score = -rank
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("session: search: %w", err)
+	}
+	return hits, nil
+}
+
+// sqliteSearchFilter returns the SQL conditions for filter. "{ts}" stands for
+// the timestamp column of the searched table.
+func sqliteSearchFilter(filter SearchFilter) (string, []any) {
+	var where strings.Builder
+	var args []any
+	if filter.AgentName != "" {
+		where.WriteString(` AND s.agent_name = ?`)
+		args = append(args, filter.AgentName)
+	}
+	if filter.CWD != "" {
+		root := strings.TrimRight(filter.CWD, "/")
+		where.WriteString(` AND (s.cwd = ? OR substr(s.cwd, 1, ?) = ?)`)
+		args = append(args, root, len(root)+1, root+"/")
+	}
+	if !filter.From.IsZero() {
+		where.WriteString(` AND julianday({ts}) >= julianday(?)`)
+		args = append(args, filter.From.UTC().Format(time.RFC3339Nano))
+	}
+	if !filter.To.IsZero() {
+		where.WriteString(` AND julianday({ts}) <= julianday(?)`)
+		args = append(args, filter.To.UTC().Format(time.RFC3339Nano))
+	}
+	return where.String(), args
+}
+
+// List returns every session with full messages and tool calls.
+func (s *SQLiteStore) List() []*SessionRecord {
+	rows, err := s.db.Query(`SELECT ` + sessionSummaryColumns + ` FROM sessions s`)
 	if err != nil {
 		return nil
 	}
@@ -328,16 +674,18 @@ func (s *SQLiteStore) List() []*SessionRecord {
 
 	result := make([]*SessionRecord, 0)
 	for rows.Next() {
-		var rec SessionRecord
-		var createdS, updatedS string
-		if err := rows.Scan(&rec.ID, &rec.AgentName, &rec.ConnectionID, &rec.CWD, &createdS, &updatedS); err != nil {
+		summary, err := scanSessionSummary(rows)
+		if err != nil {
 			continue
 		}
-		rec.CreatedAt = parseRFC3339(createdS)
-		rec.UpdatedAt = parseRFC3339(updatedS)
+		result = append(result, summary.record())
+	}
+	rows.Close()
+
+	for _, rec := range result {
 		rec.Messages = s.messagesForSession(rec.ID)
 		rec.ToolCalls = s.toolCallsForSession(rec.ID)
-		result = append(result, &rec)
+		rec.Plans = s.plansForSession(rec.ID)
 	}
 
 	sort.Slice(result, func(i, j int) bool {
@@ -346,6 +694,369 @@ func (s *SQLiteStore) List() []*SessionRecord {
 	return result
 }
 
+// sessionSummaryColumns selects a session row with its tags as a JSON array
+// and its message and tool call counts.
+const sessionSummaryColumns = `s.id, s.agent_name, s.connection_id, s.cwd,
+	s.title, s.pinned, s.archived,
+	(SELECT json_group_array(tag) FROM (
+		SELECT tag FROM session_tags st WHERE st.session_id = s.id ORDER BY tag
+	)),
+	(SELECT COUNT(*) FROM messages m WHERE m.session_id = s.id),
+	(SELECT COUNT(*) FROM tool_calls t WHERE t.session_id = s.id),
+	s.created_at, s.updated_at`
+
+// GetSummary returns the summary of one session without loading its
+// history, or nil if not found.
+func (s *SQLiteStore) GetSummary(id string) *SessionSummary {
+	row := s.db.QueryRow(
+		`SELECT `+sessionSummaryColumns+` FROM sessions s WHERE s.id = ?`,
+		id,
+	)
+	summary, err := scanSessionSummary(row)
+	if err != nil {
+		return nil
+	}
+	return &summary
+}
+
+// ListSummaries returns one page of session summaries, newest first, and
+// the number of sessions matching opts. Counts are computed in sqlite so no
+// history is loaded.
+func (s *SQLiteStore) ListSummaries(opts ListOptions) ([]SessionSummary, int) {
+	where := `1=1`
+	var args []any
+	if opts.ConnectionID != "" {
+		where += ` AND s.connection_id = ?`
+		args = append(args, opts.ConnectionID)
+	}
+	switch opts.Archived {
+	case ArchivedOnly:
+		where += ` AND s.archived = 1`
+	case ArchivedInclude:
+	default:
+		where += ` AND s.archived = 0`
+	}
+	for _, tag := range opts.Tags {
+		where += ` AND EXISTS (SELECT 1 FROM session_tags st WHERE st.session_id = s.id AND st.tag = ?)`
+		args = append(args, tag)
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sessions s WHERE `+where, args...).Scan(&total); err != nil {
+		return []SessionSummary{}, 0
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	column := opts.sortColumn()
+	rows, err := s.db.Query(
+		`SELECT `+sessionSummaryColumns+`
+		 FROM sessions s
+		 WHERE `+where+`
+		 ORDER BY s.pinned DESC, julianday(s.`+column+`) DESC, s.id ASC
+		 LIMIT ? OFFSET ?`,
+		append(args, limit, max(opts.Offset, 0))...,
+	)
+	if err != nil {
+		return []SessionSummary{}, total
+	}
+	defer rows.Close()
+
+	out := make([]SessionSummary, 0)
+	for rows.Next() {
+		summary, err := scanSessionSummary(rows)
+		if err != nil {
+			continue
+		}
+		out = append(out, summary)
+	}
+	return out, total
+}
+
+// ListMessages returns a page of at most limit messages, skipping the offset
+// newest ones, with the tool calls made alongside them. A zero limit
+// returns every remaining message.
+func (s *SQLiteStore) ListMessages(sessionID string, offset, limit int) MessagePage {
+	page := MessagePage{Messages: []Message{}, ToolCalls: []ToolCallRecord{}, Offset: max(offset, 0)}
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM messages WHERE session_id = ?`,
+		sessionID,
+	).Scan(&page.Total); err != nil {
+		return page
+	}
+	if page.Offset >= page.Total && page.Total > 0 {
+		return page
+	}
+
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(
+		`SELECT id, role, kind, content, timestamp
+		 FROM messages
+		 WHERE session_id = ?
+		 ORDER BY julianday(timestamp) DESC, row_id DESC
+		 LIMIT ? OFFSET ?`,
+		sessionID, limit, page.Offset,
+	)
+	if err != nil {
+		return page
+	}
+	var oldest string
+	for rows.Next() {
+		var m Message
+		var ts string
+		if err := rows.Scan(&m.ID, &m.Role, &m.Kind, &m.Content, &ts); err != nil {
+			continue
+		}
+		m.Timestamp = parseRFC3339(ts)
+		page.Messages = append(page.Messages, m)
+		oldest = ts
+	}
+	rows.Close()
+	slices.Reverse(page.Messages)
+
+	// The page owns the tool calls from its oldest message up to the oldest
+	// message of the newer page. Timestamps are compared as times: RFC 3339
+	// strings with trimmed fractions do not sort as text.
+	where := `session_id = ?`
+	args := []any{sessionID}
+	if page.HasMore() {
+		where += ` AND julianday(timestamp) >= julianday(?)`
+		args = append(args, oldest)
+	}
+	if page.Offset > 0 {
+		var before string
+		if err := s.db.QueryRow(
+			`SELECT timestamp
+			 FROM messages
+			 WHERE session_id = ?
+			 ORDER BY julianday(timestamp) DESC, row_id DESC
+			 LIMIT 1 OFFSET ?`,
+			sessionID, page.Offset-1,
+		).Scan(&before); err == nil {
+			where += ` AND julianday(timestamp) < julianday(?)`
+			args = append(args, before)
+		}
+	}
+	page.ToolCalls = s.queryToolCalls(where, args...)
+	return page
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSessionSummary(row rowScanner) (SessionSummary, error) {
+	var summary SessionSummary
+	var pinned, archived int
+	var tagsJSON, createdS, updatedS string
+	if err := row.Scan(
+		&summary.ID,
+		&summary.AgentName,
+		&summary.ConnectionID,
+		&summary.CWD,
+		&summary.Title,
+		&pinned,
+		&archived,
+		&tagsJSON,
+		&summary.MessageCount,
+		&summary.ToolCallCount,
+		&createdS,
+		&updatedS,
+	); err != nil {
+		return SessionSummary{}, err
+	}
+	summary.Pinned = pinned != 0
+	summary.Archived = archived != 0
+	if err := json.Unmarshal([]byte(tagsJSON), &summary.Tags); err != nil || summary.Tags == nil {
+		summary.Tags = []string{}
+	}
+	summary.CreatedAt = parseRFC3339(createdS)
+	summary.UpdatedAt = parseRFC3339(updatedS)
+	return summary, nil
+}
+
+// SetTitle renames a session.
+func (s *SQLiteStore) SetTitle(id, title string) {
+	_, _ = s.db.Exec(`UPDATE sessions SET title = ? WHERE id = ?`, title, id)
+}
+
+// SetTags replaces the tags of a session.
+func (s *SQLiteStore) SetTags(id string, tags []string) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM sessions WHERE id = ?`, id).Scan(&exists); err != nil || exists == 0 {
+		return
+	}
+	if _, err := tx.Exec(`DELETE FROM session_tags WHERE session_id = ?`, id); err != nil {
+		return
+	}
+	for _, tag := range normalizeTags(tags) {
+		if _, err := tx.Exec(`INSERT INTO session_tags (session_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return
+		}
+	}
+	_ = tx.Commit()
+}
+
+// SetPinned pins or unpins a session.
+func (s *SQLiteStore) SetPinned(id string, pinned bool) {
+	_, _ = s.db.Exec(`UPDATE sessions SET pinned = ? WHERE id = ?`, boolToInt(pinned), id)
+}
+
+// SetArchived archives or restores a session.
+func (s *SQLiteStore) SetArchived(id string, archived bool) {
+	_, _ = s.db.Exec(`UPDATE sessions SET archived = ? WHERE id = ?`, boolToInt(archived), id)
+}
+
+// Import stores a complete session record in one transaction.
+func (s *SQLiteStore) Import(rec *SessionRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("session: import: %w", err)
+	}
+	defer tx.Rollback()
+
+	exists, err := sessionExists(tx, rec.ID)
+	if err != nil {
+		return fmt.Errorf("session: import: %w", err)
+	}
+	if exists {
+		return ErrSessionExists
+	}
+	if err := insertSession(tx, rec); err != nil {
+		return fmt.Errorf("session: import session: %w", err)
+	}
+	if _, err := insertHistory(tx, rec); err != nil {
+		return fmt.Errorf("session: import: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("session: import: %w", err)
+	}
+	return nil
+}
+
+// Merge adds the entries of rec missing from the stored session, creating
+// the session if needed, in one transaction.
+func (s *SQLiteStore) Merge(rec *SessionRecord) (MergeResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("session: merge: %w", err)
+	}
+	defer tx.Rollback()
+
+	exists, err := sessionExists(tx, rec.ID)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("session: merge: %w", err)
+	}
+	if !exists {
+		if err := insertSession(tx, rec); err != nil {
+			return MergeResult{}, fmt.Errorf("session: merge session: %w", err)
+		}
+	} else if rec.Title != "" {
+		if _, err := tx.Exec(`UPDATE sessions SET title = ? WHERE id = ? AND title = ''`, rec.Title, rec.ID); err != nil {
+			return MergeResult{}, fmt.Errorf("session: merge title: %w", err)
+		}
+	}
+
+	result, err := insertHistory(tx, rec)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("session: merge: %w", err)
+	}
+	result.Created = !exists
+
+	if exists && result.Added() > 0 {
+		if _, last := activitySpan(rec); !last.IsZero() {
+			ts := last.UTC().Format(time.RFC3339Nano)
+			if _, err := tx.Exec(
+				`UPDATE sessions SET updated_at = ? WHERE id = ? AND julianday(updated_at) < julianday(?)`,
+				ts, rec.ID, ts,
+			); err != nil {
+				return MergeResult{}, fmt.Errorf("session: merge: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return MergeResult{}, fmt.Errorf("session: merge: %w", err)
+	}
+	return result, nil
+}
+
+func sessionExists(tx *sql.Tx, id string) (bool, error) {
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM sessions WHERE id = ?`, id).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// insertSession inserts the session row of rec, keeping its timestamps.
+func insertSession(db execer, rec *SessionRecord) error {
+	createdAt, updatedAt := sessionTimes(rec)
+	_, err := db.Exec(
+		`INSERT INTO sessions (
+		   id, agent_name, connection_id, cwd, title, pinned, archived, created_at, updated_at
+		 )
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.ID, rec.AgentName, rec.ConnectionID, rec.CWD, rec.Title,
+		boolToInt(rec.Pinned), boolToInt(rec.Archived),
+		createdAt.UTC().Format(time.RFC3339Nano), updatedAt.UTC().Format(time.RFC3339Nano),
+	)
+	return err
+}
+
+// insertHistory inserts the tags, messages, tool calls and plans of rec
+// that are not stored yet.
+func insertHistory(db execer, rec *SessionRecord) (MergeResult, error) {
+	var result MergeResult
+	for _, tag := range normalizeTags(rec.Tags) {
+		if _, err := db.Exec(
+			`INSERT INTO session_tags (session_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+			rec.ID, tag,
+		); err != nil {
+			return result, fmt.Errorf("tag %s: %w", tag, err)
+		}
+	}
+	for _, msg := range rec.Messages {
+		added, err := insertMessage(db, rec.ID, msg)
+		if err != nil {
+			return result, fmt.Errorf("message %s: %w", msg.ID, err)
+		}
+		if added {
+			result.Messages++
+		}
+	}
+	for _, tc := range rec.ToolCalls {
+		added, err := insertToolCall(db, rec.ID, tc)
+		if err != nil {
+			return result, fmt.Errorf("tool call %s: %w", tc.ID, err)
+		}
+		if added {
+			result.ToolCalls++
+		}
+	}
+	for _, plan := range rec.Plans {
+		added, err := insertPlan(db, rec.ID, plan)
+		if err != nil {
+			return result, fmt.Errorf("plan %s: %w", plan.ID, err)
+		}
+		if added {
+			result.Plans++
+		}
+	}
+	return result, nil
+}
+
 // Delete removes a session and all child rows.
 func (s *SQLiteStore) Delete(id string) {
 	_, _ = s.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
@@ -358,7 +1069,7 @@ func (s *SQLiteStore) Close() error {
 
 func (s *SQLiteStore) messagesForSession(sessionID string) []Message {
 	rows, err := s.db.Query(
-		`SELECT id, role, content, timestamp
+		`SELECT id, role, kind, content, timestamp
 		 FROM messages
 		 WHERE session_id = ?
 		 ORDER BY timestamp ASC`,
@@ -373,7 +1084,7 @@ func (s *SQLiteStore) messagesForSession(sessionID string) []Message {
 	for rows.Next() {
 		var m Message
 		var ts string
-		if err := rows.Scan(&m.ID, &m.Role, &m.Content, &ts); err != nil {
+		if err := rows.Scan(&m.ID, &m.Role, &m.Kind, &m.Content, &ts); err != nil {
 			continue
 		}
 		m.Timestamp = parseRFC3339(ts)
@@ -383,6 +1094,10 @@ func (s *SQLiteStore) messagesForSession(sessionID string) []Message {
 }
 
 func (s *SQLiteStore) toolCallsForSession(sessionID string) []ToolCallRecord {
+	return s.queryToolCalls(`session_id = ?`, sessionID)
+}
+
+func (s *SQLiteStore) queryToolCalls(where string, args ...any) []ToolCallRecord {
 	rows, err := s.db.Query(
 		`SELECT
 		   tool_call_id, title, kind, status, content,
@@ -392,9 +1107,9 @@ func (s *SQLiteStore) toolCallsForSession(sessionID string) []ToolCallRecord {
 		   COALESCE(diff_files, 0),
 		   timestamp
 		 FROM tool_calls
-		 WHERE session_id = ?
+		 WHERE `+where+`
 		 ORDER BY timestamp ASC`,
-		sessionID,
+		args...,
 	)
 	if err != nil {
 		return []ToolCallRecord{}
@@ -427,6 +1142,137 @@ func (s *SQLiteStore) toolCallsForSession(sessionID string) []ToolCallRecord {
 	return out
 }
 
+func (s *SQLiteStore) plansForSession(sessionID string) []PlanRecord {
+	rows, err := s.db.Query(
+		`SELECT id, entries_json, timestamp
+		 FROM plans
+		 WHERE session_id = ?
+		 ORDER BY timestamp ASC`,
+		sessionID,
+	)
+	if err != nil {
+		return []PlanRecord{}
+	}
+	defer rows.Close()
+
+	out := make([]PlanRecord, 0)
+	for rows.Next() {
+		var p PlanRecord
+		var entriesJSON, ts string
+		if err := rows.Scan(&p.ID, &entriesJSON, &ts); err != nil {
+			continue
+		}
+		if err := json.Unmarshal([]byte(entriesJSON), &p.Entries); err != nil || p.Entries == nil {
+			p.Entries = []PlanEntry{}
+		}
+		p.Timestamp = parseRFC3339(ts)
+		out = append(out, p)
+	}
+	return out
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertMessage inserts msg unless a message with its ID is stored, and
+// reports whether it did.
+func insertMessage(db execer, sessionID string, msg Message) (bool, error) {
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+	if msg.Kind == "" {
+		msg.Kind = MessageText
+	}
+	return inserted(db.Exec(
+		`INSERT INTO messages (id, session_id, role, kind, content, timestamp)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO NOTHING`,
+		msg.ID, sessionID, msg.Role, msg.Kind, msg.Content, msg.Timestamp.UTC().Format(time.RFC3339Nano),
+	))
+}
+
+const toolCallColumns = `session_id, tool_call_id, title, kind, status, content,
+		   parts_json, diff_additions, diff_deletions, diff_files, timestamp`
+
+func toolCallArgs(sessionID string, tc ToolCallRecord) []any {
+	if tc.Timestamp.IsZero() {
+		tc.Timestamp = time.Now().UTC()
+	}
+	return []any{
+		sessionID, tc.ID, tc.Title, tc.Kind, tc.Status, tc.Content,
+		marshalToolCallParts(tc.Parts), tc.DiffSummary.Additions, tc.DiffSummary.Deletions, tc.DiffSummary.Files,
+		tc.Timestamp.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// upsertToolCall inserts tc or replaces the stored call with its ID.
+func upsertToolCall(db execer, sessionID string, tc ToolCallRecord) error {
+	_, err := db.Exec(
+		`INSERT INTO tool_calls (`+toolCallColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(session_id, tool_call_id) DO UPDATE SET
+		   title=excluded.title,
+		   kind=excluded.kind,
+		   status=excluded.status,
+		   content=excluded.content,
+		   parts_json=excluded.parts_json,
+		   diff_additions=excluded.diff_additions,
+		   diff_deletions=excluded.diff_deletions,
+		   diff_files=excluded.diff_files,
+		   timestamp=excluded.timestamp`,
+		toolCallArgs(sessionID, tc)...,
+	)
+	return err
+}
+
+// insertToolCall inserts tc unless the session has a call with its ID, and
+// reports whether it did.
+func insertToolCall(db execer, sessionID string, tc ToolCallRecord) (bool, error) {
+	return inserted(db.Exec(
+		`INSERT INTO tool_calls (`+toolCallColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(session_id, tool_call_id) DO NOTHING`,
+		toolCallArgs(sessionID, tc)...,
+	))
+}
+
+// insertPlan inserts plan unless a plan with its ID is stored, and reports
+// whether it did.
+func insertPlan(db execer, sessionID string, plan PlanRecord) (bool, error) {
+	if plan.ID == "" {
+		plan.ID = uuid.NewString()
+	}
+	if plan.Timestamp.IsZero() {
+		plan.Timestamp = time.Now().UTC()
+	}
+	entries := plan.Entries
+	if entries == nil {
+		entries = []PlanEntry{}
+	}
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return false, err
+	}
+	return inserted(db.Exec(
+		`INSERT INTO plans (id, session_id, entries_json, timestamp) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(id) DO NOTHING`,
+		plan.ID, sessionID, string(entriesJSON), plan.Timestamp.UTC().Format(time.RFC3339Nano),
+	))
+}
+
+func inserted(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 func marshalToolCallParts(parts []ToolCallPart) string {
 	if len(parts) == 0 {
 		return "[]"
@@ -454,6 +1300,20 @@ func parseToolCallParts(raw string) []ToolCallPart {
 	return parts
 }
 
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func boolToInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
 func parseRFC3339(v string) time.Time {
 	if v == "" {
 		return time.Time{}
@@ -0,0 +1,415 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("open sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func search(t *testing.T, store *SQLiteStore, query string, filter SearchFilter) []SearchHit {
+	t.Helper()
+	hits, err := store.Search(query, filter)
+	if err != nil {
+		t.Fatalf("search %q: %v", query, err)
+	}
+	return hits
+}
+
+func TestSQLiteSearchIndexesMessagesAndToolCalls(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	store.Create("s1", "codex", "c1", "/work/api")
+	store.AddMessage("s1", Message{ID: "m1", Role: "user", Content: "The users migration fails on Postgres", Timestamp: base})
+	store.AddMessage("s1", Message{ID: "m2", Role: "agent", Content: "Fixed the migration bug by adding a default <value>.", Timestamp: base.Add(time.Minute)})
+	store.AddToolCall("s1", ToolCallRecord{ID: "tc1", Title: "Edit migrations/0007.sql", Status: "pending", Timestamp: base.Add(2 * time.Minute)})
+
+	store.Create("s2", "opencode", "c2", "/work/web")
+	store.AddMessage("s2", Message{ID: "m3", Role: "user", Content: "Restyle the login page", Timestamp: base.Add(48 * time.Hour)})
+
+	hits := search(t, store, "migration bug", SearchFilter{})
+	if len(hits) != 1 || hits[0].MessageID != "m2" || hits[0].SessionID != "s1" {
+		t.Fatalf("search hits = %+v, want m2", hits)
+	}
+	if !strings.Contains(hits[0].Snippet, "<mark>migration</mark>") || !strings.Contains(hits[0].Snippet, "&lt;value&gt;") {
+		t.Fatalf("snippet = %q", hits[0].Snippet)
+	}
+	if !hits[0].Timestamp.Equal(base.Add(time.Minute)) {
+		t.Fatalf("timestamp = %v", hits[0].Timestamp)
+	}
+
+	// Updates to tool calls are indexed, and the last term matches as a prefix.
+	store.UpdateToolCall("s1", "tc1", "completed", "Added NOT NULL DEFAULT to users.email", nil, ToolCallDiffSummary{})
+	hits = search(t, store, "default users.ema", SearchFilter{})
+	if len(hits) != 1 || hits[0].Kind != SearchHitToolCall || hits[0].ToolCallID != "tc1" {
+		t.Fatalf("tool call hits = %+v, want tc1", hits)
+	}
+
+	if hits := search(t, store, "migration", SearchFilter{AgentName: "opencode"}); len(hits) != 0 {
+		t.Fatalf("agent filter hits = %+v", hits)
+	}
+	if hits := search(t, store, "migration", SearchFilter{CWD: "/work"}); len(hits) != 3 {
+		t.Fatalf("cwd filter hits = %d, want 3", len(hits))
+	}
+	if hits := search(t, store, "login", SearchFilter{To: base.Add(time.Hour)}); len(hits) != 0 {
+		t.Fatalf("date filter hits = %+v", hits)
+	}
+	if hits := search(t, store, `"unbalanced`, SearchFilter{}); len(hits) != 0 {
+		t.Fatalf("query syntax was not escaped: %+v", hits)
+	}
+
+	store.Delete("s1")
+	if hits := search(t, store, "migration", SearchFilter{}); len(hits) != 0 {
+		t.Fatalf("hits after delete = %+v", hits)
+	}
+}
+
+func TestSQLiteSearchBackfillsExistingHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("open sqlite store: %v", err)
+	}
+	store.Create("s1", "codex", "c1", "/work")
+	store.AddMessage("s1", Message{ID: "m1", Role: "agent", Content: "rotated the signing keys"})
+	if _, err := store.db.Exec(`DROP TABLE messages_fts; DROP TABLE tool_calls_fts;`); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	_ = store.Close()
+
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen sqlite store: %v", err)
+	}
+	defer store.Close()
+	if hits := search(t, store, "signing", SearchFilter{}); len(hits) != 1 || hits[0].MessageID != "m1" {
+		t.Fatalf("hits after backfill = %+v", hits)
+	}
+}
+
+func TestSQLiteSearchReportsErrors(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	if _, err := store.db.Exec(`DROP TABLE tool_calls_fts`); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	if hits, err := store.Search("signing", SearchFilter{}); err == nil {
+		t.Fatalf("search without an index = %+v, nil error", hits)
+	}
+}
+
+func TestSQLiteMigratesMessagesToRowIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("open sqlite store: %v", err)
+	}
+	store.Create("s1", "codex", "c1", "/work")
+	if _, err := store.db.Exec(`
+		DROP TABLE messages;
+		DROP TABLE messages_fts;
+		CREATE TABLE messages (
+			id TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			timestamp TEXT NOT NULL,
+			kind TEXT NOT NULL DEFAULT 'text',
+			FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		);
+		INSERT INTO messages (id, session_id, role, content, timestamp)
+		VALUES ('m1', 's1', 'agent', 'rotated the signing keys', '2025-03-01T12:00:00Z');`,
+	); err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+	_ = store.Close()
+
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen sqlite store: %v", err)
+	}
+	defer store.Close()
+	store.AddMessage("s1", Message{ID: "m2", Role: "user", Content: "and the deploy keys?"})
+	if hits := search(t, store, "signing", SearchFilter{}); len(hits) != 1 || hits[0].MessageID != "m1" {
+		t.Fatalf("hits after migration = %+v, want m1", hits)
+	}
+	if rec := store.Get("s1"); rec == nil || messageIDs(rec.Messages) != "m1,m2" {
+		t.Fatalf("messages after migration = %+v", rec)
+	}
+}
+
+func TestSQLiteSummariesAndMessagePages(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	store.Create("old", "codex", "c1", "/work")
+	store.Create("new", "codex", "c2", "/work")
+	for i := range 5 {
+		ts := base.Add(time.Duration(i) * time.Minute)
+		store.AddMessage("old", Message{ID: fmt.Sprintf("m%d", i), Role: "user", Content: "hi", Timestamp: ts})
+		store.AddToolCall("old", ToolCallRecord{ID: fmt.Sprintf("tc%d", i), Title: "Read", Status: "completed", Timestamp: ts.Add(time.Second)})
+	}
+	// Touch "new" last so it sorts first by update time.
+	store.AddMessage("new", Message{ID: "n1", Role: "user", Content: "hello"})
+
+	page, total := store.ListSummaries(ListOptions{Limit: 1})
+	if total != 2 || len(page) != 1 || page[0].ID != "new" || page[0].MessageCount != 1 {
+		t.Fatalf("first page = %+v (total %d), want new", page, total)
+	}
+	page, _ = store.ListSummaries(ListOptions{Offset: 1, Limit: 1})
+	if len(page) != 1 || page[0].ID != "old" || page[0].MessageCount != 5 || page[0].ToolCallCount != 5 {
+		t.Fatalf("second page = %+v, want old with counts", page)
+	}
+	if page, total := store.ListSummaries(ListOptions{ConnectionID: "c1"}); total != 1 || page[0].ID != "old" {
+		t.Fatalf("connection filter = %+v", page)
+	}
+
+	// Pages walk back from the newest message; each carries the tool calls
+	// made after its first message and before the next page.
+	latest := store.ListMessages("old", 0, 2)
+	if latest.Total != 5 || !latest.HasMore() || messageIDs(latest.Messages) != "m3,m4" || toolCallIDs(latest.ToolCalls) != "tc3,tc4" {
+		t.Fatalf("latest page = %+v", latest)
+	}
+	middle := store.ListMessages("old", 2, 2)
+	if messageIDs(middle.Messages) != "m1,m2" || toolCallIDs(middle.ToolCalls) != "tc1,tc2" {
+		t.Fatalf("middle page = %+v", middle)
+	}
+	oldest := store.ListMessages("old", 4, 2)
+	if oldest.HasMore() || messageIDs(oldest.Messages) != "m0" || toolCallIDs(oldest.ToolCalls) != "tc0" {
+		t.Fatalf("oldest page = %+v", oldest)
+	}
+	if past := store.ListMessages("old", 5, 2); len(past.Messages) != 0 || len(past.ToolCalls) != 0 {
+		t.Fatalf("page past the start = %+v", past)
+	}
+}
+
+func messageIDs(msgs []Message) string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.ID
+	}
+	return strings.Join(out, ",")
+}
+
+func toolCallIDs(calls []ToolCallRecord) string {
+	out := make([]string, len(calls))
+	for i, tc := range calls {
+		out[i] = tc.ID
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSQLiteSessionMetadata(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	store.Create("a", "codex", "c1", "/work")
+	store.Create("b", "codex", "c1", "/work")
+	store.Create("c", "codex", "c1", "/work")
+
+	store.SetTitle("a", TitleFromPrompt("\n  Fix the   login\tredirect \nand more"))
+	store.SetTags("a", []string{" bug", "auth", "bug", ""})
+	store.SetTags("b", []string{"auth"})
+	store.SetPinned("a", true)
+	store.SetArchived("c", true)
+
+	a := store.Get("a")
+	if a.Title != "Fix the login redirect" || strings.Join(a.Tags, ",") != "auth,bug" || !a.Pinned {
+		t.Fatalf("metadata = %+v", a)
+	}
+
+	page, total := store.ListSummaries(ListOptions{})
+	if total != 2 || page[0].ID != "a" {
+		t.Fatalf("default list = %+v, want pinned a first and c hidden", page)
+	}
+	if page, _ := store.ListSummaries(ListOptions{Tags: []string{"auth", "bug"}}); len(page) != 1 || page[0].ID != "a" {
+		t.Fatalf("tag filter = %+v", page)
+	}
+	if page, _ := store.ListSummaries(ListOptions{Archived: ArchivedOnly}); len(page) != 1 || page[0].ID != "c" {
+		t.Fatalf("archived filter = %+v", page)
+	}
+	if _, total := store.ListSummaries(ListOptions{Archived: ArchivedInclude}); total != 3 {
+		t.Fatalf("archived include total = %d, want 3", total)
+	}
+
+	// Reopening a session keeps its metadata.
+	store.Create("a", "codex", "c2", "/work")
+	if a := store.GetSummary("a"); a.Title == "" || len(a.Tags) != 2 || a.ConnectionID != "c2" {
+		t.Fatalf("metadata after re-create = %+v", a)
+	}
+}
+
+func TestSQLiteImportRestoresHistory(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	rec := &SessionRecord{
+		ID:        "imported",
+		AgentName: "codex",
+		CWD:       "/work",
+		Title:     "Imported",
+		Tags:      []string{"ops"},
+		Messages: []Message{
+			{ID: "m1", Role: "user", Kind: MessageText, Content: "deploy", Timestamp: base},
+			{ID: "m2", Role: "agent", Kind: MessageThought, Content: "checking", Timestamp: base.Add(time.Second)},
+		},
+		ToolCalls: []ToolCallRecord{{ID: "tc1", Title: "Run deploy", Status: "completed", Parts: []ToolCallPart{{Type: "terminal", TerminalID: "t1", Text: "ok"}}, Timestamp: base.Add(2 * time.Second)}},
+		Plans:     []PlanRecord{{ID: "p1", Entries: []PlanEntry{{Content: "Deploy", Status: "completed"}}, Timestamp: base.Add(3 * time.Second)}},
+		CreatedAt: base,
+		UpdatedAt: base.Add(3 * time.Second),
+	}
+	if err := store.Import(rec); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if err := store.Import(rec); err != ErrSessionExists {
+		t.Fatalf("second import err = %v, want ErrSessionExists", err)
+	}
+
+	got := store.Get("imported")
+	if got.Title != "Imported" || len(got.Tags) != 1 || !got.UpdatedAt.Equal(rec.UpdatedAt) {
+		t.Fatalf("imported session = %+v", got)
+	}
+	if len(got.Messages) != 2 || got.Messages[1].Kind != MessageThought {
+		t.Fatalf("imported messages = %+v", got.Messages)
+	}
+	if len(got.ToolCalls) != 1 || got.ToolCalls[0].Parts[0].Text != "ok" {
+		t.Fatalf("imported tool calls = %+v", got.ToolCalls)
+	}
+	if len(got.Plans) != 1 || got.Plans[0].Entries[0].Content != "Deploy" {
+		t.Fatalf("imported plans = %+v", got.Plans)
+	}
+	if hits := search(t, store, "deploy", SearchFilter{}); len(hits) == 0 {
+		t.Fatalf("imported history is not searchable")
+	}
+}
+
+func TestSQLiteMergeSkipsKnownEntries(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	base := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	rec := &SessionRecord{
+		ID:        "merged",
+		AgentName: "opencode",
+		CWD:       "/work",
+		Messages: []Message{
+			{ID: "m1", Role: "user", Content: "hello", Timestamp: base},
+			{ID: "m2", Role: "agent", Content: "hi", Timestamp: base.Add(time.Second)},
+		},
+		ToolCalls: []ToolCallRecord{{ID: "tc1", Title: "Read", Status: "completed", Timestamp: base.Add(2 * time.Second)}},
+	}
+	result, err := store.Merge(rec)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if !result.Created || result.Messages != 2 || result.ToolCalls != 1 {
+		t.Fatalf("first merge = %+v", result)
+	}
+	if got := store.GetSummary("merged"); got == nil || !got.UpdatedAt.Equal(base.Add(2*time.Second)) {
+		t.Fatalf("merged summary = %+v", got)
+	}
+
+	rec.Title = "Greeting"
+	rec.Messages = append(rec.Messages, Message{ID: "m3", Role: "user", Content: "bye", Timestamp: base.Add(time.Minute)})
+	result, err = store.Merge(rec)
+	if err != nil {
+		t.Fatalf("second merge: %v", err)
+	}
+	if result.Created || result.Added() != 1 || result.Messages != 1 {
+		t.Fatalf("second merge = %+v", result)
+	}
+	got := store.Get("merged")
+	if got.Title != "Greeting" || messageIDs(got.Messages) != "m1,m2,m3" || !got.UpdatedAt.Equal(base.Add(time.Minute)) {
+		t.Fatalf("merged session = %+v", got)
+	}
+
+	if result, _ := store.Merge(rec); result.Added() != 0 {
+		t.Fatalf("repeated merge added %+v", result)
+	}
+}
+
+func TestSQLitePragmasApplyToEveryConnection(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	// Hold two connections at once so the pool has to open a second one.
+	for i := 0; i < 2; i++ {
+		conn, err := store.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("conn: %v", err)
+		}
+		defer conn.Close()
+
+		var timeout, foreignKeys int
+		if err := conn.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+			t.Fatalf("busy_timeout: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+			t.Fatalf("foreign_keys: %v", err)
+		}
+		if timeout != 5000 || foreignKeys != 1 {
+			t.Fatalf("connection %d: busy_timeout = %d, foreign_keys = %d", i, timeout, foreignKeys)
+		}
+	}
+}
+
+func TestSQLiteMessagePagesOrderFractionalTimestamps(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// RFC 3339 drops a zero fraction, so "12:00:00.5Z" sorts before
+	// "12:00:00Z" as text.
+	store.Create("s1", "codex", "c1", "/work")
+	store.AddMessage("s1", Message{ID: "m0", Role: "user", Content: "a", Timestamp: base})
+	store.AddMessage("s1", Message{ID: "m2", Role: "user", Content: "c", Timestamp: base.Add(time.Second)})
+	store.AddMessage("s1", Message{ID: "m1", Role: "agent", Content: "b", Timestamp: base.Add(500 * time.Millisecond)})
+	store.AddToolCall("s1", ToolCallRecord{ID: "tc1", Title: "Read", Status: "completed", Timestamp: base.Add(700 * time.Millisecond)})
+
+	if page := store.ListMessages("s1", 0, 0); messageIDs(page.Messages) != "m0,m1,m2" {
+		t.Fatalf("all messages = %s, want m0,m1,m2", messageIDs(page.Messages))
+	}
+	middle := store.ListMessages("s1", 1, 1)
+	if messageIDs(middle.Messages) != "m1" || toolCallIDs(middle.ToolCalls) != "tc1" {
+		t.Fatalf("middle page = %+v", middle)
+	}
+	if oldest := store.ListMessages("s1", 2, 1); messageIDs(oldest.Messages) != "m0" || len(oldest.ToolCalls) != 0 {
+		t.Fatalf("oldest page = %+v", oldest)
+	}
+}
+
+func TestSQLitePagesUseTimeIndexes(t *testing.T) {
+	store := newTestSQLiteStore(t)
+
+	// The ordering of ListMessages and ListSummaries, which compare
+	// timestamps with julianday().
+	for _, q := range []string{
+		`SELECT id FROM messages WHERE session_id = 's1' ORDER BY julianday(timestamp) DESC, row_id DESC LIMIT 50 OFFSET 50`,
+		`SELECT id FROM sessions s WHERE s.archived = 0 ORDER BY s.pinned DESC, julianday(s.updated_at) DESC, s.id ASC LIMIT 50`,
+		`SELECT id FROM sessions s WHERE s.archived = 0 ORDER BY s.pinned DESC, julianday(s.created_at) DESC, s.id ASC LIMIT 50`,
+	} {
+		rows, err := store.db.Query(`EXPLAIN QUERY PLAN ` + q)
+		if err != nil {
+			t.Fatalf("explain: %v", err)
+		}
+		var plan []string
+		for rows.Next() {
+			var id, parent, unused int
+			var detail string
+			if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+				t.Fatalf("scan plan: %v", err)
+			}
+			plan = append(plan, detail)
+		}
+		rows.Close()
+		if joined := strings.Join(plan, "; "); !strings.Contains(joined, "USING INDEX") || strings.Contains(joined, "TEMP B-TREE") {
+			t.Fatalf("plan for %q = %s, want an index scan without sorting", q, joined)
+		}
+	}
+}
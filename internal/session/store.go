@@ -1,17 +1,37 @@
 package session
 
 import (
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+// ErrSessionExists is returned by Store.Import when a session with the same
+// ID is already stored.
+var ErrSessionExists = errors.New("session: session already exists")
+
+// MergeResult counts what Store.Merge added.
+type MergeResult struct {
+	Created   bool // the session was not stored before
+	Messages  int
+	ToolCalls int
+	Plans     int
+}
+
+// Added returns the number of entries added.
+func (r MergeResult) Added() int {
+	return r.Messages + r.ToolCalls + r.Plans
+}
+
 // Store is the session persistence contract used by the app.
 type Store interface {
 	Create(id, agentName, connectionID, cwd string) *SessionRecord
 	Get(id string) *SessionRecord
 	AddMessage(sessionID string, msg Message)
 	AddToolCall(sessionID string, tc ToolCallRecord)
+	AddPlan(sessionID string, plan PlanRecord)
 	UpdateToolCall(
 		sessionID,
 		toolCallID,
@@ -20,7 +40,28 @@ type Store interface {
 		parts []ToolCallPart,
 		diffSummary ToolCallDiffSummary,
 	)
+	AddFileChange(change FileChangeRecord)
+	GetFileChange(id string) *FileChangeRecord
+	ListFileChanges(sessionID string) []FileChangeRecord
+	MarkFileChangeReverted(id string, at time.Time)
+	Search(query string, filter SearchFilter) ([]SearchHit, error)
 	List() []*SessionRecord
+	GetSummary(id string) *SessionSummary
+	ListSummaries(opts ListOptions) ([]SessionSummary, int)
+	ListMessages(sessionID string, offset, limit int) MessagePage
+	// Metadata updates do not change UpdatedAt, so editing a session does
+	// not move it in the list.
+	SetTitle(id, title string)
+	SetTags(id string, tags []string)
+	SetPinned(id string, pinned bool)
+	SetArchived(id string, archived bool)
+	// Import stores a complete session record, including its history and
+	// metadata. It fails with ErrSessionExists if the ID is taken.
+	Import(rec *SessionRecord) error
+	// Merge stores the messages, tool calls and plans of rec whose IDs are
+	// not stored yet, keeping their timestamps. The session is created
+	// when missing; otherwise only an empty title is filled in.
+	Merge(rec *SessionRecord) (MergeResult, error)
 	Delete(id string)
 	Close() error
 }
@@ -42,3 +83,49 @@ func NewStore() Store {
 
 	return sqliteStore
 }
+
+// activitySpan returns the earliest and latest timestamps among the
+// entries of rec.
+func activitySpan(rec *SessionRecord) (first, last time.Time) {
+	add := func(t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	for _, m := range rec.Messages {
+		add(m.Timestamp)
+	}
+	for _, tc := range rec.ToolCalls {
+		add(tc.Timestamp)
+	}
+	for _, p := range rec.Plans {
+		add(p.Timestamp)
+	}
+	return first, last
+}
+
+// sessionTimes returns the creation and update times of rec, derived from
+// its entries when unset.
+func sessionTimes(rec *SessionRecord) (createdAt, updatedAt time.Time) {
+	first, last := activitySpan(rec)
+	createdAt, updatedAt = rec.CreatedAt, rec.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = first
+	}
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+		if last.After(updatedAt) {
+			updatedAt = last
+		}
+	}
+	return createdAt, updatedAt
+}
@@ -0,0 +1,129 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Session list sort orders. Both list pinned sessions first, then the newest.
+const (
+	SortByUpdated = "updated"
+	SortByCreated = "created"
+)
+
+// ListOptions selects a page of session summaries.
+type ListOptions struct {
+	// SortBy is SortByUpdated (the default) or SortByCreated.
+	SortBy string
+	// ConnectionID keeps only the sessions opened on one connection.
+	ConnectionID string
+	// Tags keeps only the sessions carrying every listed tag.
+	Tags []string
+	// Archived is ArchivedExclude (the default), ArchivedOnly or
+	// ArchivedInclude.
+	Archived string
+	Offset   int
+	// Limit caps the page size; zero returns every remaining session.
+	Limit int
+}
+
+// SessionSummary describes a session without loading its history.
+type SessionSummary struct {
+	ID            string
+	AgentName     string
+	ConnectionID  string
+	CWD           string
+	Title         string
+	Tags          []string
+	Pinned        bool
+	Archived      bool
+	MessageCount  int
+	ToolCallCount int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// MessagePage is a window of a session's history. Pages are counted back
+// from the newest message so the first page is the end of the conversation.
+type MessagePage struct {
+	// Messages are in chronological order.
+	Messages []Message
+	// ToolCalls holds the tool calls made between the page's first message
+	// and the first message of the next newer page. The oldest page also
+	// carries the tool calls made before its first message.
+	ToolCalls []ToolCallRecord
+	// Offset is the number of newer messages skipped.
+	Offset int
+	// Total is the number of messages in the session.
+	Total int
+}
+
+// HasMore reports whether older messages remain before the page.
+func (p MessagePage) HasMore() bool {
+	return p.Offset+len(p.Messages) < p.Total
+}
+
+// record turns the summary into a session record without history.
+func (s SessionSummary) record() *SessionRecord {
+	return &SessionRecord{
+		ID:           s.ID,
+		AgentName:    s.AgentName,
+		ConnectionID: s.ConnectionID,
+		CWD:          s.CWD,
+		Title:        s.Title,
+		Tags:         s.Tags,
+		Pinned:       s.Pinned,
+		Archived:     s.Archived,
+		Messages:     []Message{},
+		ToolCalls:    []ToolCallRecord{},
+		Plans:        []PlanRecord{},
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+	}
+}
+
+func (o ListOptions) sortColumn() string {
+	if o.SortBy == SortByCreated {
+		return "created_at"
+	}
+	return "updated_at"
+}
+
+func (o ListOptions) sortTime(s SessionSummary) time.Time {
+	if o.SortBy == SortByCreated {
+		return s.CreatedAt
+	}
+	return s.UpdatedAt
+}
+
+// paginate applies offset and limit to an already sorted slice.
+func paginate[T any](items []T, offset, limit int) []T {
+	offset = max(offset, 0)
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// pageToolCalls picks the tool calls that belong to a page whose messages
+// span from (inclusive) to before (exclusive). Zero bounds are open.
+func pageToolCalls(calls []ToolCallRecord, from, before time.Time) []ToolCallRecord {
+	out := make([]ToolCallRecord, 0)
+	for _, tc := range calls {
+		if !from.IsZero() && tc.Timestamp.Before(from) {
+			continue
+		}
+		if !before.IsZero() && !tc.Timestamp.Before(before) {
+			continue
+		}
+		out = append(out, tc)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
@@ -2,10 +2,17 @@ package session
 
 import "time"
 
+// Message kinds.
+const (
+	MessageText    = "text"
+	MessageThought = "thought"
+)
+
 // Message represents a single message in a session's conversation history.
 type Message struct {
 	ID        string
 	Role      string // "user", "agent", "system"
+	Kind      string // MessageText or MessageThought; empty means text
 	Content   string
 	Timestamp time.Time
 }
@@ -39,6 +46,43 @@ type ToolCallRecord struct {
 	Timestamp   time.Time
 }
 
+// PlanEntry is one step of an agent plan.
+type PlanEntry struct {
+	Content  string
+	Priority string
+	Status   string
+}
+
+// PlanRecord is a plan update sent by the agent. Each update replaces the
+// previous plan.
+type PlanRecord struct {
+	ID        string
+	Entries   []PlanEntry
+	Timestamp time.Time
+}
+
+// File change statuses.
+const (
+	FileChangeApplied  = "applied"
+	FileChangeReverted = "reverted"
+)
+
+// FileChangeRecord is one file write made by an agent, kept so it can be
+// reviewed and reverted.
+type FileChangeRecord struct {
+	ID         string
+	SessionID  string
+	ToolCallID string
+	AgentName  string
+	Path       string
+	OldContent string
+	NewContent string
+	Created    bool // the file did not exist before the write
+	Status     string
+	Timestamp  time.Time
+	RevertedAt time.Time
+}
+
 // SessionRecord holds the full state of a single agent session including
 // its conversation history and tool call records.
 type SessionRecord struct {
@@ -46,8 +90,13 @@ type SessionRecord struct {
 	AgentName    string
 	ConnectionID string
 	CWD          string
+	Title        string
+	Tags         []string
+	Pinned       bool
+	Archived     bool
 	Messages     []Message
 	ToolCalls    []ToolCallRecord
+	Plans        []PlanRecord
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -0,0 +1,211 @@
+package transcript
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"bytesmith/internal/session"
+)
+
+// WriteHTML renders rec as a single self-contained HTML page. Tool calls
+// are collapsible; nothing is loaded from outside the file.
+func WriteHTML(w io.Writer, rec *session.SessionRecord) error {
+	view := htmlView{
+		Title:   Title(rec),
+		Agent:   rec.AgentName,
+		CWD:     rec.CWD,
+		ID:      rec.ID,
+		Started: formatTime(rec.CreatedAt),
+		Tags:    rec.Tags,
+	}
+	for _, e := range timeline(rec) {
+		item := htmlItem{Time: formatTime(e.Timestamp)}
+		switch {
+		case e.Message != nil:
+			item.Message = &htmlMessage{
+				Label:   roleLabel(e.Message),
+				Class:   messageClass(e.Message),
+				Content: strings.TrimSpace(e.Message.Content),
+			}
+		case e.ToolCall != nil:
+			item.ToolCall = toHTMLToolCall(e.ToolCall)
+		case e.Plan != nil:
+			item.Plan = e.Plan
+		}
+		view.Items = append(view.Items, item)
+	}
+	return htmlTemplate.Execute(w, view)
+}
+
+type htmlView struct {
+	Title   string
+	Agent   string
+	CWD     string
+	ID      string
+	Started string
+	Tags    []string
+	Items   []htmlItem
+}
+
+type htmlItem struct {
+	Time     string
+	Message  *htmlMessage
+	ToolCall *htmlToolCall
+	Plan     *session.PlanRecord
+}
+
+type htmlMessage struct {
+	Label   string
+	Class   string
+	Content string
+}
+
+type htmlToolCall struct {
+	Title    string
+	Status   string
+	Sections []htmlSection
+}
+
+type htmlSection struct {
+	Label string
+	Text  string
+	Diff  []htmlDiffLine
+}
+
+type htmlDiffLine struct {
+	Class string
+	Text  string
+}
+
+func messageClass(m *session.Message) string {
+	if m.Kind == session.MessageThought {
+		return "thought"
+	}
+	switch m.Role {
+	case "user", "system":
+		return m.Role
+	default:
+		return "agent"
+	}
+}
+
+func toHTMLToolCall(tc *session.ToolCallRecord) *htmlToolCall {
+	out := &htmlToolCall{Title: strings.TrimSpace(tc.Title), Status: tc.Status}
+	if out.Title == "" {
+		out.Title = tc.ID
+	}
+
+	if len(tc.Parts) == 0 {
+		if content := strings.TrimSpace(tc.Content); content != "" {
+			out.Sections = append(out.Sections, htmlSection{Text: content})
+		}
+		return out
+	}
+	for _, p := range tc.Parts {
+		switch p.Type {
+		case "diff":
+			section := htmlSection{Label: p.Path}
+			for _, line := range strings.Split(strings.TrimRight(partDiff(p), "\n"), "\n") {
+				section.Diff = append(section.Diff, htmlDiffLine{Class: diffLineClass(line), Text: line})
+			}
+			out.Sections = append(out.Sections, section)
+		case "terminal":
+			if strings.TrimSpace(p.Text) != "" {
+				out.Sections = append(out.Sections, htmlSection{Label: "Terminal output", Text: strings.TrimRight(p.Text, "\n")})
+			}
+		default:
+			if text := strings.TrimSpace(p.Text); text != "" {
+				out.Sections = append(out.Sections, htmlSection{Text: text})
+			}
+		}
+	}
+	return out
+}
+
+func diffLineClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return "file"
+	case strings.HasPrefix(line, "@@"):
+		return "hunk"
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	default:
+		return ""
+	}
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"planMark": planMark,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0 auto; max-width: 960px; padding: 24px; font: 14px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; color: #1f2328; background: #fff; }
+h1 { font-size: 22px; margin: 0 0 8px; }
+.meta { color: #59636e; margin: 0 0 24px; padding: 0; list-style: none; }
+.meta code { font-size: 12px; }
+.item { margin: 12px 0; }
+.time { color: #818b98; font-size: 11px; margin-left: 6px; font-weight: normal; }
+.message { border-radius: 8px; padding: 10px 14px; white-space: pre-wrap; word-wrap: break-word; }
+.message .label { display: block; font-weight: 600; margin-bottom: 4px; white-space: normal; }
+.user { background: #ddf4ff; }
+.agent { background: #f6f8fa; }
+.system { background: #fff8c5; }
+.thought { background: #fbefff; color: #59636e; font-style: italic; }
+details { border: 1px solid #d1d9e0; border-radius: 8px; }
+summary { cursor: pointer; padding: 8px 14px; font-weight: 600; }
+.status { color: #59636e; font-weight: normal; }
+.section { padding: 0 14px 10px; }
+.section .label { font-family: monospace; font-size: 12px; color: #59636e; }
+pre { margin: 4px 0 0; padding: 8px; background: #f6f8fa; border-radius: 6px; overflow-x: auto; font-size: 12px; }
+pre span { display: block; }
+.add { background: #dafbe1; }
+.del { background: #ffebe9; }
+.hunk { color: #0969da; }
+.file { color: #59636e; font-weight: 600; }
+.plan { border-left: 3px solid #d1d9e0; padding: 4px 14px; }
+.plan ul { margin: 4px 0; padding-left: 0; list-style: none; font-family: monospace; }
+.priority { color: #818b98; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul class="meta">
+<li>Agent: {{.Agent}}</li>
+{{- if .CWD}}<li>Directory: <code>{{.CWD}}</code></li>{{end}}
+<li>Session: <code>{{.ID}}</code></li>
+{{- if .Started}}<li>Started: {{.Started}}</li>{{end}}
+{{- if .Tags}}<li>Tags: {{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}</li>{{end}}
+</ul>
+{{range .Items -}}
+<div class="item">
+{{- if .Message}}
+<div class="message {{.Message.Class}}"><span class="label">{{.Message.Label}}<span class="time">{{.Time}}</span></span>{{.Message.Content}}</div>
+{{- else if .ToolCall}}
+<details>
+<summary>{{.ToolCall.Title}}{{if .ToolCall.Status}} <span class="status">({{.ToolCall.Status}})</span>{{end}}<span class="time">{{.Time}}</span></summary>
+{{- range .ToolCall.Sections}}
+<div class="section">
+{{- if .Label}}<div class="label">{{.Label}}</div>{{end}}
+{{- if .Diff}}<pre>{{range .Diff}}<span class="{{.Class}}">{{.Text}}</span>{{end}}</pre>
+{{- else}}<pre>{{.Text}}</pre>{{end}}
+</div>
+{{- end}}
+</details>
+{{- else if .Plan}}
+<div class="plan"><strong>Plan</strong><span class="time">{{.Time}}</span>
+<ul>{{range .Plan.Entries}}<li>{{planMark .Status}} {{.Content}}{{if .Priority}} <span class="priority">({{.Priority}})</span>{{end}}</li>{{end}}</ul>
+</div>
+{{- end}}
+</div>
+{{end -}}
+</body>
+</html>
+`))
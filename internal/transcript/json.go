@@ -0,0 +1,200 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"bytesmith/internal/session"
+)
+
+// Identification of the JSON format. Readers reject newer versions.
+const (
+	formatName    = "bytesmith-session"
+	formatVersion = 1
+)
+
+type document struct {
+	Format     string      `json:"format"`
+	Version    int         `json:"version"`
+	ExportedAt time.Time   `json:"exportedAt"`
+	Session    sessionJSON `json:"session"`
+}
+
+type sessionJSON struct {
+	ID           string         `json:"id"`
+	AgentName    string         `json:"agentName"`
+	ConnectionID string         `json:"connectionId,omitempty"`
+	CWD          string         `json:"cwd"`
+	Title        string         `json:"title,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+	Pinned       bool           `json:"pinned,omitempty"`
+	Archived     bool           `json:"archived,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	Messages     []messageJSON  `json:"messages"`
+	ToolCalls    []toolCallJSON `json:"toolCalls"`
+	Plans        []planJSON     `json:"plans"`
+}
+
+type messageJSON struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	Kind      string    `json:"kind,omitempty"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type toolCallJSON struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Kind        string        `json:"kind,omitempty"`
+	Status      string        `json:"status"`
+	Content     string        `json:"content,omitempty"`
+	Parts       []partJSON    `json:"parts,omitempty"`
+	DiffSummary diffStatsJSON `json:"diffSummary"`
+	Timestamp   time.Time     `json:"timestamp"`
+}
+
+type partJSON struct {
+	Type       string `json:"type"`
+	Text       string `json:"text,omitempty"`
+	Path       string `json:"path,omitempty"`
+	OldText    string `json:"oldText,omitempty"`
+	NewText    string `json:"newText,omitempty"`
+	TerminalID string `json:"terminalId,omitempty"`
+}
+
+type diffStatsJSON struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+	Files     int `json:"files"`
+}
+
+type planJSON struct {
+	ID        string          `json:"id"`
+	Entries   []planEntryJSON `json:"entries"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+type planEntryJSON struct {
+	Content  string `json:"content"`
+	Priority string `json:"priority,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+// WriteJSON writes rec in the lossless JSON format.
+func WriteJSON(w io.Writer, rec *session.SessionRecord) error {
+	doc := document{
+		Format:     formatName,
+		Version:    formatVersion,
+		ExportedAt: time.Now().UTC(),
+		Session: sessionJSON{
+			ID:           rec.ID,
+			AgentName:    rec.AgentName,
+			ConnectionID: rec.ConnectionID,
+			CWD:          rec.CWD,
+			Title:        rec.Title,
+			Tags:         rec.Tags,
+			Pinned:       rec.Pinned,
+			Archived:     rec.Archived,
+			CreatedAt:    rec.CreatedAt,
+			UpdatedAt:    rec.UpdatedAt,
+			Messages:     make([]messageJSON, 0, len(rec.Messages)),
+			ToolCalls:    make([]toolCallJSON, 0, len(rec.ToolCalls)),
+			Plans:        make([]planJSON, 0, len(rec.Plans)),
+		},
+	}
+	for _, m := range rec.Messages {
+		doc.Session.Messages = append(doc.Session.Messages, messageJSON(m))
+	}
+	for _, tc := range rec.ToolCalls {
+		out := toolCallJSON{
+			ID:          tc.ID,
+			Title:       tc.Title,
+			Kind:        tc.Kind,
+			Status:      tc.Status,
+			Content:     tc.Content,
+			DiffSummary: diffStatsJSON(tc.DiffSummary),
+			Timestamp:   tc.Timestamp,
+		}
+		for _, p := range tc.Parts {
+			out.Parts = append(out.Parts, partJSON(p))
+		}
+		doc.Session.ToolCalls = append(doc.Session.ToolCalls, out)
+	}
+	for _, p := range rec.Plans {
+		out := planJSON{ID: p.ID, Entries: make([]planEntryJSON, 0, len(p.Entries)), Timestamp: p.Timestamp}
+		for _, e := range p.Entries {
+			out.Entries = append(out.Entries, planEntryJSON(e))
+		}
+		doc.Session.Plans = append(doc.Session.Plans, out)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ReadJSON reads a session written by WriteJSON.
+func ReadJSON(r io.Reader) (*session.SessionRecord, error) {
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("transcript: decode: %w", err)
+	}
+	if doc.Format != formatName {
+		return nil, fmt.Errorf("transcript: not a ByteSmith session export")
+	}
+	if doc.Version < 1 || doc.Version > formatVersion {
+		return nil, fmt.Errorf("transcript: unsupported format version %d", doc.Version)
+	}
+	in := doc.Session
+	if strings.TrimSpace(in.ID) == "" {
+		return nil, fmt.Errorf("transcript: session has no id")
+	}
+
+	rec := &session.SessionRecord{
+		ID:           in.ID,
+		AgentName:    in.AgentName,
+		ConnectionID: in.ConnectionID,
+		CWD:          in.CWD,
+		Title:        in.Title,
+		Tags:         append([]string{}, in.Tags...),
+		Pinned:       in.Pinned,
+		Archived:     in.Archived,
+		CreatedAt:    in.CreatedAt,
+		UpdatedAt:    in.UpdatedAt,
+		Messages:     make([]session.Message, 0, len(in.Messages)),
+		ToolCalls:    make([]session.ToolCallRecord, 0, len(in.ToolCalls)),
+		Plans:        make([]session.PlanRecord, 0, len(in.Plans)),
+	}
+	for _, m := range in.Messages {
+		rec.Messages = append(rec.Messages, session.Message(m))
+	}
+	for _, tc := range in.ToolCalls {
+		out := session.ToolCallRecord{
+			ID:          tc.ID,
+			Title:       tc.Title,
+			Kind:        tc.Kind,
+			Status:      tc.Status,
+			Content:     tc.Content,
+			Parts:       make([]session.ToolCallPart, 0, len(tc.Parts)),
+			DiffSummary: session.ToolCallDiffSummary(tc.DiffSummary),
+			Timestamp:   tc.Timestamp,
+		}
+		for _, p := range tc.Parts {
+			out.Parts = append(out.Parts, session.ToolCallPart(p))
+		}
+		rec.ToolCalls = append(rec.ToolCalls, out)
+	}
+	for _, p := range in.Plans {
+		out := session.PlanRecord{ID: p.ID, Entries: make([]session.PlanEntry, 0, len(p.Entries)), Timestamp: p.Timestamp}
+		for _, e := range p.Entries {
+			out.Entries = append(out.Entries, session.PlanEntry(e))
+		}
+		rec.Plans = append(rec.Plans, out)
+	}
+	return rec, nil
+}
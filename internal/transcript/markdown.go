@@ -0,0 +1,124 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"bytesmith/internal/session"
+)
+
+// WriteMarkdown renders rec as a Markdown transcript. Thoughts are quoted
+// and tool calls list their diffs and terminal output in fenced blocks.
+func WriteMarkdown(w io.Writer, rec *session.SessionRecord) error {
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "# %s\n\n", Title(rec))
+	fmt.Fprintf(b, "- **Agent:** %s\n", rec.AgentName)
+	if rec.CWD != "" {
+		fmt.Fprintf(b, "- **Directory:** `%s`\n", rec.CWD)
+	}
+	fmt.Fprintf(b, "- **Session:** `%s`\n", rec.ID)
+	if s := formatTime(rec.CreatedAt); s != "" {
+		fmt.Fprintf(b, "- **Started:** %s\n", s)
+	}
+	if len(rec.Tags) > 0 {
+		fmt.Fprintf(b, "- **Tags:** %s\n", strings.Join(rec.Tags, ", "))
+	}
+
+	for _, e := range timeline(rec) {
+		b.WriteString("\n")
+		switch {
+		case e.Message != nil:
+			writeMarkdownMessage(b, e.Message)
+		case e.ToolCall != nil:
+			writeMarkdownToolCall(b, e.ToolCall)
+		case e.Plan != nil:
+			writeMarkdownPlan(b, e.Plan)
+		}
+	}
+	return b.Flush()
+}
+
+func writeMarkdownMessage(b *bufio.Writer, m *session.Message) {
+	fmt.Fprintf(b, "### %s", roleLabel(m))
+	if s := formatTime(m.Timestamp); s != "" {
+		fmt.Fprintf(b, " · %s", s)
+	}
+	b.WriteString("\n\n")
+
+	content := strings.TrimSpace(m.Content)
+	if m.Kind == session.MessageThought {
+		for _, line := range strings.Split(content, "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		return
+	}
+	b.WriteString(content + "\n")
+}
+
+func writeMarkdownToolCall(b *bufio.Writer, tc *session.ToolCallRecord) {
+	title := strings.TrimSpace(tc.Title)
+	if title == "" {
+		title = tc.ID
+	}
+	fmt.Fprintf(b, "#### Tool call: %s", title)
+	if tc.Status != "" {
+		fmt.Fprintf(b, " (%s)", tc.Status)
+	}
+	b.WriteString("\n")
+
+	if len(tc.Parts) == 0 {
+		if content := strings.TrimSpace(tc.Content); content != "" {
+			b.WriteString("\n")
+			writeFence(b, "", content)
+		}
+		return
+	}
+	for _, p := range tc.Parts {
+		switch p.Type {
+		case "diff":
+			fmt.Fprintf(b, "\n`%s`\n\n", p.Path)
+			writeFence(b, "diff", strings.TrimRight(partDiff(p), "\n"))
+		case "terminal":
+			if strings.TrimSpace(p.Text) == "" {
+				continue
+			}
+			b.WriteString("\nTerminal output:\n\n")
+			writeFence(b, "console", strings.TrimRight(p.Text, "\n"))
+		default:
+			if text := strings.TrimSpace(p.Text); text != "" {
+				b.WriteString("\n")
+				writeFence(b, "", text)
+			}
+		}
+	}
+}
+
+func writeMarkdownPlan(b *bufio.Writer, p *session.PlanRecord) {
+	b.WriteString("#### Plan\n\n")
+	for _, e := range p.Entries {
+		fmt.Fprintf(b, "- %s %s", planMark(e.Status), e.Content)
+		if e.Priority != "" {
+			fmt.Fprintf(b, " _(%s)_", e.Priority)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeFence writes text in a code fence longer than any backtick run in
+// it, so the text cannot close the fence early.
+func writeFence(b *bufio.Writer, lang, text string) {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n", fence, lang, text, fence)
+}
@@ -0,0 +1,142 @@
+// Package transcript renders stored sessions for sharing and reads them back.
+//
+// Sessions export to Markdown for pasting into pull requests and documents,
+// to a standalone HTML page, and to a lossless JSON format that Decode turns
+// back into a session record.
+package transcript
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"bytesmith/internal/diff"
+	"bytesmith/internal/session"
+)
+
+// Export formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+)
+
+// ParseFormat normalizes a format name. File extensions are accepted too.
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), ".")) {
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "json":
+		return FormatJSON, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("transcript: unknown format %q", name)
+	}
+}
+
+// Extension returns the file extension for a format, with the dot.
+func Extension(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	default:
+		return ".json"
+	}
+}
+
+// Write renders rec to w in the given format.
+func Write(w io.Writer, rec *session.SessionRecord, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return WriteMarkdown(w, rec)
+	case FormatJSON:
+		return WriteJSON(w, rec)
+	case FormatHTML:
+		return WriteHTML(w, rec)
+	default:
+		return fmt.Errorf("transcript: unknown format %q", format)
+	}
+}
+
+// Title returns the session title, or a fallback naming the session.
+func Title(rec *session.SessionRecord) string {
+	if t := strings.TrimSpace(rec.Title); t != "" {
+		return t
+	}
+	return "Session " + rec.ID
+}
+
+// entry is one item of a session timeline. Exactly one of the pointers is
+// set.
+type entry struct {
+	Timestamp time.Time
+	Message   *session.Message
+	ToolCall  *session.ToolCallRecord
+	Plan      *session.PlanRecord
+}
+
+// timeline merges messages, tool calls and plan updates in time order.
+func timeline(rec *session.SessionRecord) []entry {
+	entries := make([]entry, 0, len(rec.Messages)+len(rec.ToolCalls)+len(rec.Plans))
+	for i := range rec.Messages {
+		entries = append(entries, entry{Timestamp: rec.Messages[i].Timestamp, Message: &rec.Messages[i]})
+	}
+	for i := range rec.ToolCalls {
+		entries = append(entries, entry{Timestamp: rec.ToolCalls[i].Timestamp, ToolCall: &rec.ToolCalls[i]})
+	}
+	for i := range rec.Plans {
+		entries = append(entries, entry{Timestamp: rec.Plans[i].Timestamp, Plan: &rec.Plans[i]})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries
+}
+
+// roleLabel names the author of a message.
+func roleLabel(m *session.Message) string {
+	if m.Kind == session.MessageThought {
+		return "Thought"
+	}
+	switch m.Role {
+	case "user":
+		return "User"
+	case "system":
+		return "System"
+	default:
+		return "Agent"
+	}
+}
+
+// partDiff renders a diff part as a unified diff.
+func partDiff(p session.ToolCallPart) string {
+	name := strings.TrimPrefix(p.Path, "/")
+	if name == "" {
+		name = "file"
+	}
+	return diff.Unified("a/"+name, "b/"+name, p.OldText, p.NewText, diff.DefaultContext)
+}
+
+// planMark returns the checkbox for a plan entry status.
+func planMark(status string) string {
+	switch status {
+	case "completed":
+		return "[x]"
+	case "in_progress":
+		return "[~]"
+	default:
+		return "[ ]"
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
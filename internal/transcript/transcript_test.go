@@ -0,0 +1,108 @@
+package transcript
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bytesmith/internal/session"
+)
+
+func testRecord() *session.SessionRecord {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &session.SessionRecord{
+		ID:        "s1",
+		AgentName: "codex",
+		CWD:       "/work",
+		Title:     "Fix the <login> page",
+		Tags:      []string{"auth"},
+		Pinned:    true,
+		Messages: []session.Message{
+			{ID: "m1", Role: "user", Kind: session.MessageText, Content: "Fix it", Timestamp: base},
+			{ID: "m2", Role: "agent", Kind: session.MessageThought, Content: "Looking at\nlogin.go", Timestamp: base.Add(time.Second)},
+			{ID: "m3", Role: "agent", Kind: session.MessageText, Content: "Done, see ```diff```.", Timestamp: base.Add(4 * time.Second)},
+		},
+		ToolCalls: []session.ToolCallRecord{{
+			ID:     "tc1",
+			Title:  "Edit login.go",
+			Kind:   "edit",
+			Status: "completed",
+			Parts: []session.ToolCallPart{
+				{Type: "diff", Path: "/work/login.go", OldText: "a\nb\n", NewText: "a\nc\n"},
+				{Type: "terminal", TerminalID: "t1", Text: "ok\n"},
+			},
+			DiffSummary: session.ToolCallDiffSummary{Additions: 1, Deletions: 1, Files: 1},
+			Timestamp:   base.Add(3 * time.Second),
+		}},
+		Plans: []session.PlanRecord{{
+			ID:        "p1",
+			Entries:   []session.PlanEntry{{Content: "Patch login", Priority: "high", Status: "completed"}},
+			Timestamp: base.Add(2 * time.Second),
+		}},
+		CreatedAt: base,
+		UpdatedAt: base.Add(4 * time.Second),
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	rec := testRecord()
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, rec); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(got, rec) {
+		t.Fatalf("round trip mismatch:\n got  %+v\n want %+v", got, rec)
+	}
+
+	if _, err := ReadJSON(strings.NewReader(`{"format":"other","version":1}`)); err == nil {
+		t.Fatalf("foreign document was accepted")
+	}
+}
+
+func TestMarkdownAndHTML(t *testing.T) {
+	var md bytes.Buffer
+	if err := WriteMarkdown(&md, testRecord()); err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+	out := md.String()
+	for _, want := range []string{
+		"# Fix the <login> page",
+		"> Looking at\n> login.go",
+		"#### Plan\n\n- [x] Patch login _(high)_",
+		"#### Tool call: Edit login.go (completed)",
+		"```diff\n--- a/work/login.go\n+++ b/work/login.go\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n```",
+		"```console\nok\n```",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("markdown lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "#### Plan") > strings.Index(out, "#### Tool call") {
+		t.Fatalf("timeline is out of order:\n%s", out)
+	}
+
+	var page bytes.Buffer
+	if err := WriteHTML(&page, testRecord()); err != nil {
+		t.Fatalf("html: %v", err)
+	}
+	out = page.String()
+	for _, want := range []string{
+		"<title>Fix the &lt;login&gt; page</title>",
+		"<details>\n<summary>Edit login.go",
+		`<span class="add">&#43;c</span>`,
+		`<div class="message thought">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("html lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<link") || strings.Contains(out, "<script") {
+		t.Fatalf("html is not self-contained")
+	}
+}
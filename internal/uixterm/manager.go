@@ -7,8 +7,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/google/uuid"
@@ -17,19 +19,36 @@ import (
 const (
 	defaultCols = 120
 	defaultRows = 32
+
+	// scrollbackLimit is how many bytes of output are kept per terminal.
+	scrollbackLimit = 256 * 1024
 )
 
 // SessionInfo is the UI-facing metadata for an embedded terminal process.
 type SessionInfo struct {
-	ID    string
-	CWD   string
-	Shell string
+	ID        string
+	CWD       string
+	Shell     string
+	CreatedAt time.Time
 }
 
 type session struct {
 	info SessionInfo
 	cmd  *exec.Cmd
 	pty  *os.File
+
+	scrollbackMu sync.Mutex
+	scrollback   []byte
+}
+
+// appendScrollback keeps the last scrollbackLimit bytes of output.
+func (s *session) appendScrollback(data []byte) {
+	s.scrollbackMu.Lock()
+	defer s.scrollbackMu.Unlock()
+	s.scrollback = append(s.scrollback, data...)
+	if excess := len(s.scrollback) - scrollbackLimit; excess > 0 {
+		s.scrollback = append(s.scrollback[:0], s.scrollback[excess:]...)
+	}
 }
 
 // Manager controls all embedded terminal PTY sessions.
@@ -79,9 +98,10 @@ func (m *Manager) Create(cwd string) (SessionInfo, error) {
 
 	id := uuid.NewString()
 	info := SessionInfo{
-		ID:    id,
-		CWD:   dir,
-		Shell: filepath.Base(shell),
+		ID:        id,
+		CWD:       dir,
+		Shell:     filepath.Base(shell),
+		CreatedAt: time.Now(),
 	}
 
 	s := &session{info: info, cmd: cmd, pty: ptyFile}
@@ -145,6 +165,36 @@ func (m *Manager) Close(terminalID string) error {
 	return nil
 }
 
+// List returns the active terminals, oldest first.
+func (m *Manager) List() []SessionInfo {
+	m.mu.RLock()
+	out := make([]SessionInfo, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		out = append(out, s.info)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Scrollback returns the most recent output of a terminal, up to maxBytes
+// (all kept output when maxBytes is 0 or negative).
+func (m *Manager) Scrollback(terminalID string, maxBytes int) (string, error) {
+	s, err := m.get(terminalID)
+	if err != nil {
+		return "", err
+	}
+
+	s.scrollbackMu.Lock()
+	defer s.scrollbackMu.Unlock()
+	data := s.scrollback
+	if maxBytes > 0 && len(data) > maxBytes {
+		data = data[len(data)-maxBytes:]
+	}
+	return string(data), nil
+}
+
 // CloseAll terminates all active embedded terminals.
 func (m *Manager) CloseAll() {
 	m.mu.RLock()
@@ -164,6 +214,7 @@ func (m *Manager) readLoop(s *session) {
 	for {
 		n, err := s.pty.Read(buf)
 		if n > 0 {
+			s.appendScrollback(buf[:n])
 			m.emitOutput(s.info.ID, string(buf[:n]))
 		}
 		if err != nil {
@@ -1,5 +1,14 @@
 package main
 
+import (
+	"os"
+
+	"bytesmith/internal/cli"
+)
+
 func main() {
+	if code, handled := cli.Run(os.Args[1:]); handled {
+		os.Exit(code)
+	}
 	run()
 }